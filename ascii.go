@@ -0,0 +1,135 @@
+package csslexer
+
+import (
+	"io"
+
+	"go.baoshuo.dev/cssutil"
+)
+
+// Classes of ASCII code points, as bit flags.
+const (
+	classIdent      uint8 = 1 << iota // ident code points: letters, digits, '_' and '-'
+	classDigit                        // digits
+	classWhitespace                   // whitespace: '\t', '\n', '\f', '\r' and ' '
+)
+
+// asciiClass holds the classes of each ASCII code point.
+var asciiClass [128]uint8
+
+// asciiToken holds the type of the token made of a single ASCII code
+// point, for code points that always form a token by themselves.
+var asciiToken [128]TokenType
+
+// asciiString holds the one-character string of each ASCII code point, so
+// that single-character tokens do not allocate their value.
+var asciiString [128]string
+
+func init() {
+	for c := 0; c < 128; c++ {
+		asciiString[c] = string(rune(c))
+
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == '-':
+			asciiClass[c] |= classIdent
+		case c >= '0' && c <= '9':
+			asciiClass[c] |= classIdent | classDigit
+		case c == '\t', c == '\n', c == '\f', c == '\r', c == ' ':
+			asciiClass[c] |= classWhitespace
+		}
+	}
+
+	for c, tokenType := range map[rune]TokenType{
+		'(': LeftParenthesisToken, ')': RightParenthesisToken,
+		'[': LeftBracketToken, ']': RightBracketToken,
+		'{': LeftBraceToken, '}': RightBraceToken,
+		',': CommaToken, ':': ColonToken, ';': SemicolonToken,
+	} {
+		asciiToken[c] = tokenType
+	}
+	for _, c := range []rune{
+		1, 2, 3, 4, 5, 6, 7, 8, 11, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24,
+		25, 26, 27, 28, 29, 30, 31, '!', '%', '&', '=', '>', '?', '`', 127,
+	} {
+		asciiToken[c] = DelimiterToken
+	}
+}
+
+// isIdentCodePoint is cssutil.IsIdentCodePoint with a fast path for ASCII.
+func isIdentCodePoint(r rune) bool {
+	if uint32(r) < 128 {
+		return asciiClass[r]&classIdent != 0
+	}
+	return cssutil.IsIdentCodePoint(r)
+}
+
+// moveTo moves to the given position, which must not be before the
+// current position.
+func (z *Input) moveTo(pos int) {
+	if pos >= len(z.runes) {
+		pos = len(z.runes)
+		z.err = io.EOF
+	}
+	z.pos = pos
+}
+
+// moveWhileClass advances the position past a run of ASCII code points of
+// the given class.
+func (z *Input) moveWhileClass(class uint8) {
+	i := z.pos
+	for i < len(z.runes) {
+		r := z.runes[i]
+		if uint32(r) >= 128 || asciiClass[r]&class == 0 {
+			break
+		}
+		i++
+	}
+	z.moveTo(i)
+}
+
+// moveWhileIdent advances the position past a run of ident code points,
+// not including escapes.
+func (z *Input) moveWhileIdent() {
+	i := z.pos
+	for i < len(z.runes) && isIdentCodePoint(z.runes[i]) {
+		i++
+	}
+	z.moveTo(i)
+}
+
+// moveUntilStringEnd advances the position up to the next code point that
+// may end a string or needs special handling in it: the closing quote, a
+// backslash, a newline or a template slot.
+func (z *Input) moveUntilStringEnd(quote rune) {
+	i := z.pos
+	for i < len(z.runes) {
+		r := z.runes[i]
+		if r == quote || r == '\\' || r == '\n' || r == '\r' || r == '\f' || r == 0 {
+			break
+		}
+		i++
+	}
+	z.moveTo(i)
+}
+
+// moveUntilCommentEnd advances the position up to the "*/" ending a
+// comment, or a template slot.
+func (z *Input) moveUntilCommentEnd() {
+	i := z.pos
+	for i < len(z.runes) {
+		r := z.runes[i]
+		if r == '*' && i+1 < len(z.runes) && z.runes[i+1] == '/' || r == 0 {
+			break
+		}
+		i++
+	}
+	z.moveTo(i)
+}
+
+// runesString converts runes to a string, without allocating for a single
+// ASCII code point.
+func runesString(runes []rune) string {
+	if len(runes) == 1 && uint32(runes[0]) < 128 {
+		return asciiString[runes[0]]
+	}
+	return string(runes)
+}
@@ -0,0 +1,32 @@
+package csslexer
+
+import (
+	"testing"
+
+	"go.baoshuo.dev/cssutil"
+)
+
+// TestASCIIClass checks the ASCII tables against the code point
+// definitions of cssutil.
+func TestASCIIClass(t *testing.T) {
+	for r := rune(0); r < 0x800; r++ {
+		if isIdentCodePoint(r) != cssutil.IsIdentCodePoint(r) {
+			t.Errorf("isIdentCodePoint(%q) = %v", r, isIdentCodePoint(r))
+		}
+		if r >= 128 {
+			continue
+		}
+		if (asciiClass[r]&classDigit != 0) != cssutil.IsDigit(r) {
+			t.Errorf("class of %q: digit = %v", r, asciiClass[r]&classDigit != 0)
+		}
+		if (asciiClass[r]&classWhitespace != 0) != cssutil.IsWhitespace(r) {
+			t.Errorf("class of %q: whitespace = %v", r, asciiClass[r]&classWhitespace != 0)
+		}
+		if asciiToken[r] != DefaultToken && (isIdentCodePoint(r) || cssutil.IsWhitespace(r)) {
+			t.Errorf("asciiToken[%q] = %s, expected no single-character token", r, asciiToken[r])
+		}
+		if runesString([]rune{r}) != string(r) {
+			t.Errorf("runesString(%q) = %q", r, runesString([]rune{r}))
+		}
+	}
+}
@@ -5,6 +5,10 @@ import (
 	"compress/gzip"
 	"embed"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"go.baoshuo.dev/csslexer"
@@ -17,6 +21,174 @@ import (
 var fs embed.FS
 
 func BenchmarkLexer(b *testing.B) {
+	benchmarkFiles(b, func(input *csslexer.Input) {
+		lexer := csslexer.NewLexer(input)
+		for {
+			token := lexer.Next()
+			if token.Type == csslexer.EOFToken {
+				break
+			}
+		}
+	})
+}
+
+// BenchmarkLexerRaw reads tokens without their values, which does not
+// allocate.
+func BenchmarkLexerRaw(b *testing.B) {
+	benchmarkFiles(b, func(input *csslexer.Input) {
+		lexer := csslexer.NewLexer(input)
+		for {
+			token := lexer.NextRaw()
+			if token.Type == csslexer.EOFToken {
+				break
+			}
+		}
+	})
+}
+
+// BenchmarkLexerRawValue reads tokens without their values, and computes
+// the values of identifiers only, as a minifier looking for keywords would.
+func BenchmarkLexerRawValue(b *testing.B) {
+	benchmarkFiles(b, func(input *csslexer.Input) {
+		lexer := csslexer.NewLexer(input)
+		for {
+			token := lexer.NextRaw()
+			if token.Type == csslexer.EOFToken {
+				break
+			}
+			if token.Type == csslexer.IdentToken {
+				_ = lexer.Value(token)
+			}
+		}
+	})
+}
+
+// BenchmarkLexerInterner reads the tokens with a new Interner for each
+// file, deduplicating identifier-like values.
+func BenchmarkLexerInterner(b *testing.B) {
+	benchmarkFiles(b, func(input *csslexer.Input) {
+		lexer := csslexer.NewLexer(input)
+		lexer.SetInterner(csslexer.NewInterner())
+		for {
+			token := lexer.Next()
+			if token.Type == csslexer.EOFToken {
+				break
+			}
+		}
+	})
+}
+
+// BenchmarkLexerParallel reads the tokens on GOMAXPROCS goroutines.
+func BenchmarkLexerParallel(b *testing.B) {
+	benchmarkFiles(b, func(input *csslexer.Input) {
+		csslexer.TokenizeParallel(input, 0)
+	})
+}
+
+// BenchmarkInputFile creates inputs from files with NewInputFile.
+func BenchmarkInputFile(b *testing.B) {
+	benchmarkInputs(b, func(name string) *csslexer.Input {
+		input, err := csslexer.NewInputFile(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return input
+	})
+}
+
+// BenchmarkInputReader creates inputs from files with NewInputReader.
+func BenchmarkInputReader(b *testing.B) {
+	benchmarkInputs(b, func(name string) *csslexer.Input {
+		f, err := os.Open(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer f.Close()
+		return csslexer.NewInputReader(f)
+	})
+}
+
+// benchmarkInputs runs a benchmark for each file in testdata, creating an
+// input from the uncompressed file with the given function.
+func benchmarkInputs(b *testing.B, open func(name string) *csslexer.Input) {
+	files, err := fs.ReadDir("testdata")
+	if err != nil {
+		b.Fatalf("failed to read testdata directory: %v", err)
+	}
+	dir := b.TempDir()
+	for _, file := range files {
+		dataGz, err := fs.ReadFile("testdata/" + file.Name())
+		if err != nil {
+			b.Fatalf("failed to read file %s: %v", file.Name(), err)
+		}
+		data := ungzip(dataGz)
+		name := filepath.Join(dir, strings.TrimSuffix(file.Name(), ".gz"))
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(file.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				input := open(name)
+				input.Close()
+			}
+		})
+	}
+}
+
+// inlineStyles are small inputs, such as the style attributes of a page.
+var inlineStyles = []string{
+	"color: red",
+	"margin: 0 auto; padding: 4px 8px",
+	"background: url(a.png) no-repeat; width: calc(100% - 2px) !important",
+	"font: 12px/1.5 \"Helvetica Neue\", sans-serif; color: #333",
+	"display: none",
+}
+
+// BenchmarkInlineStyles lexes each small input with a new Input and Lexer.
+func BenchmarkInlineStyles(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, style := range inlineStyles {
+			lexer := csslexer.NewLexer(csslexer.NewInput(style))
+			for lexer.NextRaw().Type != csslexer.EOFToken {
+			}
+		}
+	}
+}
+
+// tokenizer is a pooled Input and Lexer.
+type tokenizer struct {
+	input *csslexer.Input
+	lexer *csslexer.Lexer
+}
+
+var tokenizers = sync.Pool{New: func() interface{} {
+	input := csslexer.NewInput("")
+	return &tokenizer{input: input, lexer: csslexer.NewLexer(input)}
+}}
+
+// BenchmarkInlineStylesReset lexes each small input with a pooled Input
+// and Lexer, which does not allocate once the pool is warm.
+func BenchmarkInlineStylesReset(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, style := range inlineStyles {
+			t := tokenizers.Get().(*tokenizer)
+			t.input.Reset(style)
+			t.lexer.Reset(t.input)
+			for t.lexer.NextRaw().Type != csslexer.EOFToken {
+			}
+			tokenizers.Put(t)
+		}
+	}
+}
+
+// benchmarkFiles runs a benchmark for each file in testdata, lexing the
+// input of the file with the given function.
+func benchmarkFiles(b *testing.B, lex func(input *csslexer.Input)) {
 	files, err := fs.ReadDir("testdata")
 	if err != nil {
 		b.Fatalf("failed to read testdata directory: %v", err)
@@ -30,24 +202,19 @@ func BenchmarkLexer(b *testing.B) {
 			if err != nil {
 				b.Fatalf("failed to read file %s: %v", file.Name(), err)
 			}
+			data := ungzip(dataGz)
 
+			b.ReportAllocs()
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
 				b.StopTimer()
-				input := csslexer.NewInputBytes(ungzip(dataGz))
-				lexer := csslexer.NewLexer(input)
-
+				input := csslexer.NewInputBytes(data)
 				b.StartTimer()
-				for {
-					token := lexer.Next()
-					if token.Type == csslexer.EOFToken {
-						break
-					}
-				}
+				lex(input)
 			}
 			b.StopTimer()
 
-			totalBytes := len(ungzip(dataGz)) * b.N
+			totalBytes := len(data) * b.N
 			totalMiB := totalBytes / 1024 / 1024
 			b.ReportMetric(float64(totalMiB)/b.Elapsed().Seconds(), "MiB/s")
 		})
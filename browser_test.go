@@ -0,0 +1,222 @@
+package csslexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// browserTestDir is the directory of the token streams recorded from
+// browsers, see tests/browser/README.md.
+const browserTestDir = "browser"
+
+// browserFixture is a file of token streams recorded from a browser.
+type browserFixture struct {
+	Browser string        `json:"browser"` // Name and version of the browser
+	Cases   []browserCase `json:"cases"`
+}
+
+type browserCase struct {
+	Name   string      `json:"name"`
+	Source string      `json:"source"`
+	Tokens []testToken `json:"tokens"` // Without the EOF token
+}
+
+// divergence is a token of a browser differing from the lexer.
+type divergence struct {
+	Index    int
+	Expected testToken
+	Actual   Token
+}
+
+func (d divergence) String() string {
+	return fmt.Sprintf("token %d: expected %s %q (raw %q), got %s %q (raw %q)",
+		d.Index, d.Expected.Type, d.Expected.Value, d.Expected.Raw, d.Actual.Type, d.Actual.Value, string(d.Actual.Raw))
+}
+
+// typeStats counts the tokens of a type compared with a browser.
+type typeStats struct {
+	Compared int
+	Diverged int
+}
+
+// eofTokenName is the type under which the end of the token streams is
+// counted, so that missing and extra tokens appear in the stats.
+const eofTokenName = "EOF"
+
+// compareBrowserCase lexes the source of a case, compares its tokens with
+// the recorded ones by position and returns all the divergences. The
+// compared and diverging tokens are counted by type in stats, including
+// the end of the stream, which diverges if the lexer reads more tokens.
+// Recorded tokens beyond the end of the tokens of the lexer are compared
+// with its EOFToken.
+func compareBrowserCase(c browserCase, stats map[string]*typeStats) []divergence {
+	count := func(name string, diverged bool) {
+		s := stats[name]
+		if s == nil {
+			s = &typeStats{}
+			stats[name] = s
+		}
+		s.Compared++
+		if diverged {
+			s.Diverged++
+		}
+	}
+
+	var divergences []divergence
+	lexer := NewLexer(NewInput(c.Source))
+	for i, expected := range c.Tokens {
+		token := lexer.Next()
+
+		// Browsers may not expose the raw text of tokens.
+		diverged := token.Type != convertTestTokenName(expected.Type) || token.Value != expected.Value ||
+			expected.Raw != "" && string(token.Raw) != expected.Raw
+		count(expected.Type, diverged)
+		if diverged {
+			divergences = append(divergences, divergence{Index: i, Expected: expected, Actual: token})
+		}
+	}
+
+	token := lexer.Next()
+	diverged := token.Type != EOFToken
+	count(eofTokenName, diverged)
+	if diverged {
+		divergences = append(divergences, divergence{Index: len(c.Tokens), Expected: testToken{Type: eofTokenName}, Actual: token})
+	}
+	return divergences
+}
+
+// formatTypeStats returns a table of the divergences by token type.
+func formatTypeStats(stats map[string]*typeStats) string {
+	types := make([]string, 0, len(stats))
+	for name := range stats {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	var sb strings.Builder
+	sb.WriteString("| Token type | Compared | Diverged |\n")
+	sb.WriteString("|------------|----------|----------|\n")
+	for _, name := range types {
+		fmt.Fprintf(&sb, "| %s | %d | %d |\n", name, stats[name].Compared, stats[name].Diverged)
+	}
+	return sb.String()
+}
+
+// TestBrowser compares the lexer with the token streams recorded from
+// browsers, reporting the divergences by token type for each browser.
+func TestBrowser(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(testDataDir, browserTestDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("no browser fixtures found")
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fixture browserFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			stats := make(map[string]*typeStats)
+			diverged := 0
+			for _, c := range fixture.Cases {
+				if divergences := compareBrowserCase(c, stats); len(divergences) > 0 {
+					diverged++
+					t.Errorf("%s: %d tokens diverge, first %s", c.Name, len(divergences), divergences[0])
+				}
+			}
+			if diverged > 0 {
+				t.Logf("%s: %d of %d cases diverge\n%s", fixture.Browser, diverged, len(fixture.Cases), formatTypeStats(stats))
+			}
+		})
+	}
+}
+
+// TestCompareBrowserCase checks the harness itself with hand-written
+// cases, which are not recorded from a browser.
+func TestCompareBrowserCase(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       browserCase
+		indices []int // Indices of the divergences
+	}{
+		{
+			name: "Same tokens",
+			c: browserCase{Source: "a:1px", Tokens: []testToken{
+				{Type: "ident-token", Raw: "a", Value: "a"},
+				{Type: "colon-token", Value: ":"},
+				{Type: "dimension-token", Raw: "1px", Value: "1px"},
+			}},
+		},
+		{
+			name: "Different tokens",
+			c: browserCase{Source: "a b c", Tokens: []testToken{
+				{Type: "ident-token", Raw: "a", Value: "a"},
+				{Type: "ident-token", Raw: " ", Value: " "},
+				{Type: "ident-token", Raw: "b", Value: "b"},
+				{Type: "whitespace-token", Raw: " ", Value: " "},
+				{Type: "ident-token", Raw: "d", Value: "d"},
+			}},
+			indices: []int{1, 4},
+		},
+		{
+			name:    "Extra tokens of the lexer",
+			c:       browserCase{Source: "a b", Tokens: []testToken{{Type: "ident-token", Value: "a"}}},
+			indices: []int{1},
+		},
+		{
+			name: "Missing tokens of the lexer",
+			c: browserCase{Source: "a", Tokens: []testToken{
+				{Type: "ident-token", Value: "a"},
+				{Type: "whitespace-token", Value: " "},
+				{Type: "ident-token", Value: "b"},
+			}},
+			indices: []int{1, 2},
+		},
+	}
+
+	stats := make(map[string]*typeStats)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			divergences := compareBrowserCase(tt.c, stats)
+			var indices []int
+			for _, d := range divergences {
+				indices = append(indices, d.Index)
+			}
+			if fmt.Sprint(indices) != fmt.Sprint(tt.indices) {
+				t.Errorf("compareBrowserCase() diverges at %v, expected %v: %v", indices, tt.indices, divergences)
+			}
+		})
+	}
+
+	expected := map[string]typeStats{
+		"ident-token":      {Compared: 8, Diverged: 3},
+		"whitespace-token": {Compared: 2, Diverged: 1},
+		"colon-token":      {Compared: 1},
+		"dimension-token":  {Compared: 1},
+		eofTokenName:       {Compared: 4, Diverged: 1},
+	}
+	for name, e := range expected {
+		if s := stats[name]; s == nil || *s != e {
+			t.Errorf("stats of %s = %v, expected %v", name, s, e)
+		}
+	}
+	if len(stats) != len(expected) {
+		t.Errorf("stats of %d types, expected %d", len(stats), len(expected))
+	}
+	if table := formatTypeStats(stats); !strings.Contains(table, "| ident-token | 8 | 3 |") || !strings.Contains(table, "| EOF | 4 | 1 |") {
+		t.Errorf("formatTypeStats() = %q", table)
+	}
+}
@@ -0,0 +1,313 @@
+// Package bundle inlines @import rules to combine style sheets into one.
+//
+// Imported style sheets are wrapped in @layer, @supports and @media blocks
+// matching the conditions of their @import rule, and relative URLs inside
+// them are rewritten to stay valid from the location of the entry style
+// sheet. Everything else is copied from the source unchanged.
+//
+// https://www.w3.org/TR/css-cascade-5/#at-import
+package bundle
+
+import (
+	"path"
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/cssutil"
+)
+
+// CycleError is returned when style sheets import each other in a cycle.
+type CycleError struct {
+	Chain []string // Paths of the style sheets forming the cycle, the first one repeated at the end
+}
+
+func (e *CycleError) Error() string {
+	return "bundle: import cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// ConditionalExternalError is returned when an external import appears in
+// a style sheet that is itself imported with conditions. External imports
+// are hoisted to the start of the bundle, where those conditions would be
+// lost.
+type ConditionalExternalError struct {
+	Path string // Path of the style sheet containing the import
+	URL  string // URL of the external import
+}
+
+func (e *ConditionalExternalError) Error() string {
+	return "bundle: cannot hoist external import " + e.URL + " from conditionally imported " + e.Path
+}
+
+// Bundle loads the style sheet at entry and recursively inlines its
+// imports, returning the combined style sheet.
+func Bundle(loader Loader, entry string) (string, error) {
+	b := &bundler{
+		loader: loader,
+		entry:  path.Clean(entry),
+		active: make(map[string]bool),
+	}
+
+	body, err := b.bundle(b.entry, false)
+	if err != nil {
+		return "", err
+	}
+
+	if len(b.external) == 0 {
+		return body, nil
+	}
+	// External imports go after the @charset and @layer statements leading
+	// the entry, which must stay first to keep the order of layers.
+	head := body[:b.head]
+	if head != "" {
+		head += "\n"
+	}
+	return head + strings.Join(b.external, "") + body[b.head:], nil
+}
+
+type bundler struct {
+	loader   Loader
+	entry    string
+	stack    []string        // style sheets being bundled, outermost first
+	active   map[string]bool // the set of paths in stack
+	external []string        // hoisted external @import rules
+	head     int             // length of the leading @charset and @layer statements of the entry
+}
+
+func (b *bundler) resolve(from, specifier string) (string, error) {
+	if r, ok := b.loader.(Resolver); ok {
+		return r.Resolve(from, specifier)
+	}
+	return DefaultResolve(from, specifier)
+}
+
+// bundle returns the contents of the style sheet at name with its imports
+// inlined. conditional reports whether the style sheet is imported with
+// conditions.
+func (b *bundler) bundle(name string, conditional bool) (string, error) {
+	if b.active[name] {
+		chain := append([]string{}, b.stack...)
+		for len(chain) > 0 && chain[0] != name {
+			chain = chain[1:]
+		}
+		return "", &CycleError{Chain: append(chain, name)}
+	}
+
+	source, err := b.loader.Load(name)
+	if err != nil {
+		return "", err
+	}
+
+	b.stack = append(b.stack, name)
+	b.active[name] = true
+	defer func() {
+		b.stack = b.stack[:len(b.stack)-1]
+		delete(b.active, name)
+	}()
+
+	tokens := tokenize(source)
+	var out strings.Builder
+
+	// @import rules are only valid before any other rule except @charset
+	// and @layer statements.
+	preamble := true
+	leading := name == b.entry // before the first @import of the entry
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if preamble {
+			switch token.Type {
+			case csslexer.WhitespaceToken, csslexer.CommentToken, csslexer.CDOToken, csslexer.CDCToken:
+				out.WriteString(string(token.Raw))
+				continue
+
+			case csslexer.AtKeywordToken:
+				end := statementEnd(tokens, i)
+				switch strings.ToLower(token.Value) {
+				case "charset":
+					// @charset is only allowed at the very start of a style
+					// sheet, so only the one of the entry is kept.
+					if leading && i == 0 {
+						out.WriteString(csslexer.Serialize(tokens[i:end]))
+						b.head = out.Len()
+					}
+					i = end - 1
+					continue
+
+				case "import":
+					leading = false
+					text, err := b.inline(name, tokens[i+1:end], tokens[i:end], conditional)
+					if err != nil {
+						return "", err
+					}
+					out.WriteString(text)
+					i = end - 1
+					continue
+
+				case "layer":
+					if tokens[end-1].Type == csslexer.SemicolonToken {
+						out.WriteString(csslexer.Serialize(tokens[i:end]))
+						if leading {
+							b.head = out.Len()
+						}
+						i = end - 1
+						continue
+					}
+				}
+			}
+			preamble = false
+		}
+
+		out.WriteString(b.rewriteURL(name, tokens, &i))
+	}
+
+	return out.String(), nil
+}
+
+// inline returns the replacement text of an @import rule, where prelude
+// holds the tokens after the at-keyword up to the semicolon.
+func (b *bundler) inline(from string, prelude, rule []csslexer.Token, conditional bool) (string, error) {
+	imp, ok := parseImport(prelude)
+	if !ok {
+		// Invalid @import rules are ignored by browsers, keep them as is.
+		return csslexer.Serialize(rule), nil
+	}
+
+	name, err := b.resolve(from, imp.url)
+	if err == ErrExternal {
+		if conditional {
+			return "", &ConditionalExternalError{Path: from, URL: imp.url}
+		}
+		b.external = append(b.external, strings.TrimSpace(csslexer.Serialize(rule))+"\n")
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	body, err := b.bundle(name, conditional || imp.isConditional())
+	if err != nil {
+		return "", err
+	}
+
+	return imp.wrap(body), nil
+}
+
+// rewriteURL returns the text of tokens[*i], rewriting it when it is a
+// relative URL in a style sheet other than the entry. String arguments of
+// url() and src() functions are rewritten as well, advancing *i past them.
+func (b *bundler) rewriteURL(name string, tokens []csslexer.Token, i *int) string {
+	token := tokens[*i]
+	if name == b.entry {
+		return string(token.Raw)
+	}
+
+	switch token.Type {
+	case csslexer.UrlToken:
+		if u, ok := b.relocate(name, token.Value); ok {
+			// Quoted, as the path may have characters not allowed in an
+			// unquoted URL.
+			return "url(" + cssutil.SerializeString(u) + ")"
+		}
+
+	case csslexer.FunctionToken:
+		fn := strings.ToLower(token.Value)
+		if fn != "url" && fn != "src" {
+			break
+		}
+		j := *i + 1
+		for j < len(tokens) && tokens[j].Type == csslexer.WhitespaceToken {
+			j++
+		}
+		if j == len(tokens) || tokens[j].Type != csslexer.StringToken {
+			break
+		}
+		if u, ok := b.relocate(name, tokens[j].Value); ok {
+			text := csslexer.Serialize(tokens[*i:j]) + cssutil.SerializeString(u)
+			*i = j
+			return text
+		}
+	}
+
+	return string(token.Raw)
+}
+
+// relocate rewrites a URL relative to the style sheet at name to be
+// relative to the entry style sheet instead.
+func (b *bundler) relocate(name, u string) (string, bool) {
+	if u == "" || isExternal(u) || strings.HasPrefix(u, "/") || strings.HasPrefix(u, "#") {
+		return "", false
+	}
+
+	suffix := ""
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u, suffix = u[:i], u[i:]
+	}
+
+	target := path.Join(path.Dir(name), u)
+	return relativePath(path.Dir(b.entry), target) + suffix, true
+}
+
+// relativePath returns the path of target relative to the directory base.
+// Both paths must be clean and slash-separated.
+func relativePath(base, target string) string {
+	split := func(p string) []string {
+		if p == "." {
+			return nil
+		}
+		return strings.Split(p, "/")
+	}
+	from, to := split(base), split(target)
+
+	common := 0
+	for common < len(from) && common < len(to) && from[common] == to[common] {
+		common++
+	}
+
+	parts := make([]string, 0, len(from)-common+len(to)-common)
+	for range from[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, to[common:]...)
+	if len(parts) == 0 {
+		return "."
+	}
+	return strings.Join(parts, "/")
+}
+
+func tokenize(source []byte) []csslexer.Token {
+	lexer := csslexer.NewLexer(csslexer.NewInputBytes(source))
+
+	var tokens []csslexer.Token
+	for {
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			return tokens
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+// statementEnd returns the index after the end of the at-rule starting at
+// tokens[start]: after its semicolon, its block, or the end of the input.
+func statementEnd(tokens []csslexer.Token, start int) int {
+	depth := 0
+	for i := start + 1; i < len(tokens); i++ {
+		switch tokens[i].Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken, csslexer.LeftBraceToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.RightBraceToken:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case csslexer.SemicolonToken:
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(tokens)
+}
@@ -0,0 +1,280 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := make(fstest.MapFS, len(files))
+	for name, data := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	return fsys
+}
+
+func TestBundle(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		entry    string
+		expected string
+	}{
+		{
+			name: "Plain import",
+			files: map[string]string{
+				"main.css": "@import 'a.css';\nb { x: y }",
+				"a.css":    "a { x: y }",
+			},
+			entry:    "main.css",
+			expected: "a { x: y }\nb { x: y }",
+		},
+		{
+			name: "Import forms",
+			files: map[string]string{
+				"main.css": "@import url(a.css);@import url('b.css');@import \"c.css\";",
+				"a.css":    "a{}",
+				"b.css":    "b{}",
+				"c.css":    "c{}",
+			},
+			entry:    "main.css",
+			expected: "a{}b{}c{}",
+		},
+		{
+			name: "Media, supports and layer",
+			files: map[string]string{
+				"main.css": "@import 'a.css' layer(base) supports(display: grid) screen and (min-width: 10px);",
+				"a.css":    "a{}",
+			},
+			entry:    "main.css",
+			expected: "@media screen and (min-width: 10px){@supports (display: grid){@layer base{a{}}}}",
+		},
+		{
+			name: "Anonymous layer and supports condition",
+			files: map[string]string{
+				"main.css": "@import 'a.css' layer supports(not (display: grid));",
+				"a.css":    "a{}",
+			},
+			entry:    "main.css",
+			expected: "@supports not (display: grid){@layer{a{}}}",
+		},
+		{
+			name: "Nested imports",
+			files: map[string]string{
+				"main.css":       "@import 'lib/a.css' print;",
+				"lib/a.css":      "@import 'b/b.css';\na{}",
+				"lib/b/b.css":    "b{}",
+				"lib/unused.css": "unused{}",
+			},
+			entry:    "main.css",
+			expected: "@media print{b{}\na{}}",
+		},
+		{
+			name: "Root-relative import",
+			files: map[string]string{
+				"css/main.css": "@import '/shared/a.css';",
+				"shared/a.css": "a{}",
+			},
+			entry:    "css/main.css",
+			expected: "a{}",
+		},
+		{
+			name: "Diamond imports are repeated",
+			files: map[string]string{
+				"main.css": "@import 'a.css';@import 'b.css';",
+				"a.css":    "@import 'c.css';a{}",
+				"b.css":    "@import 'c.css';b{}",
+				"c.css":    "c{}",
+			},
+			entry:    "main.css",
+			expected: "c{}a{}c{}b{}",
+		},
+		{
+			name: "URLs are relative to the entry",
+			files: map[string]string{
+				"css/main.css": "@import '../lib/a.css';x{background:url(x.png)}",
+				"lib/a.css":    "a{background:url(img/a.png)} b{src:url('../fonts/b.woff2?v=1#x')} c{mask:url(#m)}",
+			},
+			entry:    "css/main.css",
+			expected: `a{background:url("../lib/img/a.png")} b{src:url("../fonts/b.woff2?v=1#x")} c{mask:url(#m)}x{background:url(x.png)}`,
+		},
+		{
+			name: "Rewritten URLs are escaped",
+			files: map[string]string{
+				"main.css":  "@import 'a b/a.css';",
+				"a b/a.css": `a{b:url(x\)\ \'\\y.png) url('"z".png')}`,
+			},
+			entry:    "main.css",
+			expected: `a{b:url("a b/x) '\\y.png") url("a b/\"z\".png")}`,
+		},
+		{
+			name: "Absolute URLs are kept",
+			files: map[string]string{
+				"main.css":  "@import 'lib/a.css';",
+				"lib/a.css": "a{b:url(https://example.com/a.png) url(/a.png) url(data:image/png;base64,AA==) url(//cdn/a.png)}",
+			},
+			entry:    "main.css",
+			expected: "a{b:url(https://example.com/a.png) url(/a.png) url(data:image/png;base64,AA==) url(//cdn/a.png)}",
+		},
+		{
+			name: "External imports are hoisted",
+			files: map[string]string{
+				"main.css": "@charset \"utf-8\";\n@import 'a.css';\n@import url(https://fonts.example.com/css?family=X) screen;\nb{}",
+				"a.css":    "@import '//cdn.example.com/reset.css';\na{}",
+			},
+			entry:    "main.css",
+			expected: "@charset \"utf-8\";\n@import '//cdn.example.com/reset.css';\n@import url(https://fonts.example.com/css?family=X) screen;\n\n\na{}\n\nb{}",
+		},
+		{
+			name: "External imports after layer statements",
+			files: map[string]string{
+				"main.css": "@layer reset, base;\n@import 'a.css';\n@layer theme;",
+				"a.css":    "@import url(https://cdn.example.com/reset.css) layer(reset);\na{}",
+			},
+			entry:    "main.css",
+			expected: "@layer reset, base;\n@import url(https://cdn.example.com/reset.css) layer(reset);\n\n\na{}\n@layer theme;",
+		},
+		{
+			name: "Charset of imported style sheets is dropped",
+			files: map[string]string{
+				"main.css": "@import 'a.css';",
+				"a.css":    "@charset \"utf-8\";a{}",
+			},
+			entry:    "main.css",
+			expected: "a{}",
+		},
+		{
+			name: "Layer statements before imports",
+			files: map[string]string{
+				"main.css": "@layer base, theme;\n@import 'a.css' layer(theme);",
+				"a.css":    "a{}",
+			},
+			entry:    "main.css",
+			expected: "@layer base, theme;\n@layer theme{a{}}",
+		},
+		{
+			name: "Imports after rules are left alone",
+			files: map[string]string{
+				"main.css": "a{}\n@import 'b.css';",
+			},
+			entry:    "main.css",
+			expected: "a{}\n@import 'b.css';",
+		},
+		{
+			name: "Invalid imports are left alone",
+			files: map[string]string{
+				"main.css": "@import foo;\na{}",
+			},
+			entry:    "main.css",
+			expected: "@import foo;\na{}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Bundle(NewFSLoader(mapFS(tt.files)), tt.entry)
+			if err != nil {
+				t.Fatalf("Bundle() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Bundle() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBundleErrors(t *testing.T) {
+	t.Run("Import cycle", func(t *testing.T) {
+		fsys := mapFS(map[string]string{
+			"main.css": "@import 'a.css';",
+			"a.css":    "@import 'b.css';",
+			"b.css":    "@import 'a.css';",
+		})
+
+		_, err := Bundle(NewFSLoader(fsys), "main.css")
+		var cycle *CycleError
+		if !errors.As(err, &cycle) {
+			t.Fatalf("Bundle() error = %v, expected a CycleError", err)
+		}
+		if expected := "bundle: import cycle: a.css -> b.css -> a.css"; cycle.Error() != expected {
+			t.Errorf("Error() = %q, expected %q", cycle.Error(), expected)
+		}
+	})
+
+	t.Run("Conditional external import", func(t *testing.T) {
+		fsys := mapFS(map[string]string{
+			"main.css": "@import 'a.css' print;",
+			"a.css":    "@import 'https://example.com/a.css';",
+		})
+
+		_, err := Bundle(NewFSLoader(fsys), "main.css")
+		var external *ConditionalExternalError
+		if !errors.As(err, &external) {
+			t.Fatalf("Bundle() error = %v, expected a ConditionalExternalError", err)
+		}
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		fsys := mapFS(map[string]string{
+			"main.css": "@import 'missing.css';",
+		})
+
+		if _, err := Bundle(NewFSLoader(fsys), "main.css"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Bundle() error = %v, expected os.ErrNotExist", err)
+		}
+	})
+}
+
+type prefixResolver struct {
+	*FSLoader
+}
+
+func (r prefixResolver) Resolve(from, specifier string) (string, error) {
+	if specifier == "pkg" {
+		return "node_modules/pkg/index.css", nil
+	}
+	return DefaultResolve(from, specifier)
+}
+
+func TestResolver(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"main.css":                   "@import 'pkg';",
+		"node_modules/pkg/index.css": "a{b:url(a.png)}",
+	})
+
+	result, err := Bundle(prefixResolver{NewFSLoader(fsys)}, "main.css")
+	if err != nil {
+		t.Fatalf("Bundle() error = %v", err)
+	}
+	if expected := `a{b:url("node_modules/pkg/a.png")}`; result != expected {
+		t.Errorf("Bundle() = %q, expected %q", result, expected)
+	}
+}
+
+func TestLocalLoader(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app/main.css": "@import '../shared/a.css';",
+		"shared/a.css": "a{b:url(a.png)}",
+	}
+	for name, data := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Bundle(NewLocalLoader(filepath.Join(dir, "app")), "main.css")
+	if err != nil {
+		t.Fatalf("Bundle() error = %v", err)
+	}
+	if expected := `a{b:url("../shared/a.png")}`; result != expected {
+		t.Errorf("Bundle() = %q, expected %q", result, expected)
+	}
+}
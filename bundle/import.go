@@ -0,0 +1,150 @@
+package bundle
+
+import (
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+// importRule is a parsed @import prelude.
+//
+// https://www.w3.org/TR/css-cascade-5/#at-import
+type importRule struct {
+	url      string
+	layer    bool   // whether the import has a layer
+	name     string // name of the layer, empty for an anonymous layer
+	supports string // supports condition, with parentheses
+	media    string // media query list
+}
+
+// parseImport parses the tokens following an @import keyword, including the
+// closing semicolon if any.
+func parseImport(prelude []csslexer.Token) (importRule, bool) {
+	var imp importRule
+
+	tokens := tokenutil.TrimBlank(prelude)
+	if len(tokens) > 0 && tokens[len(tokens)-1].Type == csslexer.SemicolonToken {
+		tokens = tokenutil.TrimBlank(tokens[:len(tokens)-1])
+	}
+	if len(tokens) == 0 {
+		return imp, false
+	}
+
+	switch first := tokens[0]; first.Type {
+	case csslexer.StringToken, csslexer.UrlToken:
+		imp.url = first.Value
+		tokens = tokens[1:]
+
+	case csslexer.FunctionToken:
+		if !strings.EqualFold(first.Value, "url") {
+			return imp, false
+		}
+		args, rest, ok := arguments(tokens)
+		if !ok || len(args) != 1 || args[0].Type != csslexer.StringToken {
+			return imp, false
+		}
+		imp.url = args[0].Value
+		tokens = rest
+
+	default:
+		return imp, false
+	}
+
+	tokens = tokenutil.TrimBlank(tokens)
+	if len(tokens) > 0 {
+		switch {
+		case tokens[0].Type == csslexer.IdentToken && strings.EqualFold(tokens[0].Value, "layer"):
+			imp.layer = true
+			tokens = tokenutil.TrimBlank(tokens[1:])
+
+		case tokens[0].Type == csslexer.FunctionToken && strings.EqualFold(tokens[0].Value, "layer"):
+			args, rest, ok := arguments(tokens)
+			if !ok || len(args) == 0 {
+				return imp, false
+			}
+			imp.layer = true
+			imp.name = csslexer.Serialize(args)
+			tokens = tokenutil.TrimBlank(rest)
+		}
+	}
+
+	if len(tokens) > 0 && tokens[0].Type == csslexer.FunctionToken && strings.EqualFold(tokens[0].Value, "supports") {
+		args, rest, ok := arguments(tokens)
+		if !ok || len(args) == 0 {
+			return imp, false
+		}
+		imp.supports = csslexer.Serialize(args)
+		if !isParenthesized(args) {
+			// A bare declaration, as in `supports(display: grid)`.
+			imp.supports = "(" + imp.supports + ")"
+		}
+		tokens = tokenutil.TrimBlank(rest)
+	}
+
+	imp.media = csslexer.Serialize(tokens)
+
+	return imp, true
+}
+
+// isConditional reports whether the imported style sheet only applies
+// under some condition, or in a layer.
+func (imp importRule) isConditional() bool {
+	return imp.layer || imp.supports != "" || imp.media != ""
+}
+
+// wrap wraps the contents of the imported style sheet in at-rules matching
+// the conditions of the import.
+func (imp importRule) wrap(body string) string {
+	if imp.layer {
+		if imp.name != "" {
+			body = "@layer " + imp.name + "{" + body + "}"
+		} else {
+			body = "@layer{" + body + "}"
+		}
+	}
+	if imp.supports != "" {
+		body = "@supports " + imp.supports + "{" + body + "}"
+	}
+	if imp.media != "" {
+		body = "@media " + imp.media + "{" + body + "}"
+	}
+	return body
+}
+
+// arguments returns the trimmed arguments of the function starting at
+// tokens[0], and the tokens following its closing parenthesis.
+func arguments(tokens []csslexer.Token) (args, rest []csslexer.Token, ok bool) {
+	depth := 0
+	for i, token := range tokens {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken, csslexer.LeftBraceToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken, csslexer.RightBraceToken:
+			depth--
+			if depth == 0 {
+				return tokenutil.TrimBlank(tokens[1:i]), tokens[i+1:], true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// isParenthesized reports whether tokens form a single parenthesized
+// block, or a condition made of such blocks, rather than a declaration.
+func isParenthesized(tokens []csslexer.Token) bool {
+	depth := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.ColonToken:
+			if depth == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package bundle
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrExternal is returned by a Resolver for imports that refer to
+// resources outside of the loader, such as remote URLs. External imports
+// are kept as @import rules instead of being inlined.
+var ErrExternal = errors.New("bundle: external import")
+
+// Loader loads style sheets by path.
+//
+// Paths are slash-separated and relative to the root of the loader, as
+// with fs.FS.
+type Loader interface {
+	Load(path string) ([]byte, error)
+}
+
+// Resolver is implemented by loaders that resolve import specifiers
+// themselves, for example to look up packages. Loaders without it use
+// DefaultResolve.
+type Resolver interface {
+	// Resolve returns the path of the style sheet imported by specifier
+	// from the style sheet at path from, or ErrExternal.
+	Resolve(from, specifier string) (string, error)
+}
+
+// DefaultResolve resolves an import specifier like a relative URL: it is
+// joined with the directory of the importing style sheet, and a leading
+// slash refers to the root of the loader. Specifiers with a scheme, such
+// as `https:` or `data:`, and protocol-relative URLs are external.
+func DefaultResolve(from, specifier string) (string, error) {
+	if isExternal(specifier) {
+		return "", ErrExternal
+	}
+	if i := strings.IndexAny(specifier, "?#"); i >= 0 {
+		specifier = specifier[:i]
+	}
+	if strings.HasPrefix(specifier, "/") {
+		return path.Clean(strings.TrimLeft(specifier, "/")), nil
+	}
+	return path.Join(path.Dir(from), specifier), nil
+}
+
+// isExternal reports whether a URL has a scheme or is protocol-relative.
+func isExternal(u string) bool {
+	if strings.HasPrefix(u, "//") {
+		return true
+	}
+	for i := 0; i < len(u); i++ {
+		c := u[i]
+		switch {
+		case c == ':':
+			return i > 0
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// FSLoader loads style sheets from an fs.FS.
+type FSLoader struct {
+	fsys fs.FS
+}
+
+// NewFSLoader creates a new FSLoader reading from the given file system.
+func NewFSLoader(fsys fs.FS) *FSLoader {
+	return &FSLoader{fsys: fsys}
+}
+
+// Load reads the style sheet at the given path.
+func (l *FSLoader) Load(name string) ([]byte, error) {
+	return fs.ReadFile(l.fsys, name)
+}
+
+// LocalLoader loads style sheets from the local file system. Unlike an
+// FSLoader over os.DirFS, it follows imports that leave its root
+// directory.
+type LocalLoader struct {
+	root string
+}
+
+// NewLocalLoader creates a new LocalLoader resolving paths against the
+// given directory.
+func NewLocalLoader(root string) *LocalLoader {
+	return &LocalLoader{root: root}
+}
+
+// Load reads the style sheet at the given path.
+func (l *LocalLoader) Load(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(l.root, filepath.FromSlash(name)))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"go.baoshuo.dev/csslexer/incremental"
+)
+
+// document is an open text document with its tokens, kept up to date
+// incrementally as the client sends changes.
+type document struct {
+	*incremental.Document
+	text  []rune // The runes of the document, shared with Document
+	lines []int  // rune offset of the start of each line
+}
+
+func newDocument(text string) *document {
+	d := &document{Document: incremental.NewDocument(text)}
+	d.text = d.Runes()
+	d.lines = appendLineStarts([]int{0}, d.text, 0, len(d.text))
+	return d
+}
+
+// appendLineStarts appends to lines the starts of the lines following the
+// runes of text[from:to].
+func appendLineStarts(lines []int, text []rune, from, to int) []int {
+	for i := from; i < to; i++ {
+		switch r := text[i]; {
+		case r == '\r' && i+1 < len(text) && text[i+1] == '\n':
+			// The line starts after the LF.
+		case r == '\n', r == '\r':
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// change applies a content change sent by the client. The line starts are
+// only scanned around the edit: whether a rune ends a line depends on the
+// next rune only.
+func (d *document) change(c TextDocumentContentChangeEvent) {
+	start, end := 0, len(d.text)
+	if c.Range != nil {
+		start, end = d.offset(c.Range.Start), d.offset(c.Range.End)
+		if end < start {
+			start, end = end, start
+		}
+	}
+	d.Apply(incremental.Edit{Start: start, End: end, Text: c.Text})
+	d.text = d.Runes()
+
+	// The runes before start-1 and from end on end the same lines as
+	// before the edit, after which the latter are moved by delta.
+	inserted := utf8.RuneCountInString(c.Text)
+	delta := inserted - (end - start)
+	kept := sort.SearchInts(d.lines, start)
+	if kept == 0 {
+		kept = 1 // The first line always starts at 0.
+	}
+	moved := sort.SearchInts(d.lines, end+1)
+
+	lines := make([]int, kept, len(d.lines)+inserted/8+1)
+	copy(lines, d.lines[:kept])
+	from := start - 1
+	if from < 0 {
+		from = 0
+	}
+	lines = appendLineStarts(lines, d.text, from, start+inserted)
+	for _, line := range d.lines[moved:] {
+		lines = append(lines, line+delta)
+	}
+	d.lines = lines
+}
+
+// position converts a rune offset to an LSP position.
+func (d *document) position(offset int) Position {
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	character := 0
+	for _, r := range d.text[d.lines[line]:offset] {
+		character += utf16Len(r)
+	}
+	return Position{Line: line, Character: character}
+}
+
+// offset converts an LSP position to a rune offset, clamping positions
+// outside of the document.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	end := len(d.text)
+	if pos.Line+1 < len(d.lines) {
+		end = d.lines[pos.Line+1]
+	}
+
+	offset, character := d.lines[pos.Line], 0
+	for offset < end && character < pos.Character {
+		if r := d.text[offset]; r == '\n' || r == '\r' {
+			break
+		}
+		character += utf16Len(d.text[offset])
+		offset++
+	}
+	return offset
+}
+
+func (d *document) rangeOf(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"math"
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/color"
+	"go.baoshuo.dev/csslexer/parser"
+)
+
+// semanticTokenTypes is the legend of the semantic tokens, indexed by the
+// values of semanticTokenType.
+var semanticTokenTypes = []string{"comment", "string", "number", "keyword", "function", "property", "variable", "operator"}
+
+// semanticTokenType returns the index in semanticTokenTypes of a token
+// type, or -1 for tokens that are not highlighted.
+func semanticTokenType(t csslexer.TokenType) int {
+	switch t {
+	case csslexer.CommentToken:
+		return 0
+	case csslexer.StringToken, csslexer.BadStringToken, csslexer.UrlToken, csslexer.BadUrlToken:
+		return 1
+	case csslexer.NumberToken, csslexer.PercentageToken, csslexer.DimensionToken, csslexer.UnicodeRangeToken:
+		return 2
+	case csslexer.AtKeywordToken:
+		return 3
+	case csslexer.FunctionToken:
+		return 4
+	case csslexer.IdentToken:
+		return 5
+	case csslexer.HashToken:
+		return 6
+	case csslexer.DelimiterToken, csslexer.ColonToken, csslexer.SemicolonToken, csslexer.CommaToken,
+		csslexer.IncludeMatchToken, csslexer.DashMatchToken, csslexer.PrefixMatchToken, csslexer.SuffixMatchToken,
+		csslexer.SubstringMatchToken, csslexer.ColumnToken, csslexer.CDOToken, csslexer.CDCToken:
+		return 7
+	}
+	return -1
+}
+
+// semanticTokens encodes the tokens of the document relative to each
+// other. Tokens spanning multiple lines are split at line breaks.
+func semanticTokens(d *document) []int {
+	data := []int{}
+	var prev Position
+
+	emit := func(start Position, length, tokenType int) {
+		if length == 0 {
+			return
+		}
+		deltaStart := start.Character
+		if start.Line == prev.Line {
+			deltaStart -= prev.Character
+		}
+		data = append(data, start.Line-prev.Line, deltaStart, length, tokenType, 0)
+		prev = start
+	}
+
+	for i, token := range d.Tokens() {
+		tokenType := semanticTokenType(token.Type)
+		if tokenType < 0 {
+			continue
+		}
+
+		offset := d.Offset(i)
+		start, length := d.position(offset), 0
+		for j, r := range token.Raw {
+			if r == '\n' || r == '\r' || r == '\f' {
+				emit(start, length, tokenType)
+				start, length = d.position(offset+j+1), 0
+				continue
+			}
+			length += utf16Len(r)
+		}
+		emit(start, length, tokenType)
+	}
+
+	return data
+}
+
+// documentSymbols returns the rules and at-rules of the document.
+func documentSymbols(d *document) []DocumentSymbol {
+	return symbols(d, parser.ParseString(d.Text()).Rules)
+}
+
+func symbols(d *document, nodes []parser.Node) []DocumentSymbol {
+	result := []DocumentSymbol{}
+	for _, node := range nodes {
+		var symbol DocumentSymbol
+		var block []parser.Node
+
+		switch n := node.(type) {
+		case *parser.QualifiedRule:
+			symbol = DocumentSymbol{Name: symbolName(n.Prelude), Kind: SymbolKindClass}
+			block = n.Block
+		case *parser.AtRule:
+			name := "@" + n.Name
+			if prelude := symbolName(n.Prelude); prelude != "" {
+				name += " " + prelude
+			}
+			symbol = DocumentSymbol{Name: name, Kind: SymbolKindModule}
+			block = n.Block
+		default:
+			continue
+		}
+
+		if symbol.Name == "" {
+			symbol.Name = "<empty>"
+		}
+		span := node.Pos()
+		symbol.Range = d.rangeOf(span.Start, span.End)
+		symbol.SelectionRange = symbol.Range
+		if children := symbols(d, block); len(children) > 0 {
+			symbol.Children = children
+		}
+		result = append(result, symbol)
+	}
+	return result
+}
+
+// symbolName returns the text of a prelude on a single line.
+func symbolName(prelude []csslexer.Token) string {
+	return strings.Join(strings.Fields(csslexer.Serialize(prelude)), " ")
+}
+
+// foldingRanges returns the blocks and comments spanning multiple lines.
+func foldingRanges(d *document) []FoldingRange {
+	result := []FoldingRange{}
+	var open []int // start lines of the open blocks
+
+	for i, token := range d.Tokens() {
+		switch token.Type {
+		case csslexer.LeftBraceToken:
+			open = append(open, d.position(d.Offset(i)).Line)
+
+		case csslexer.RightBraceToken:
+			if len(open) == 0 {
+				continue
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			// Keep the line of the closing brace visible.
+			if end := d.position(d.Offset(i)).Line - 1; end > start {
+				result = append(result, FoldingRange{StartLine: start, EndLine: end})
+			}
+
+		case csslexer.CommentToken:
+			start, end := d.position(d.Offset(i)).Line, d.position(d.Offset(i+1)).Line
+			if end > start {
+				result = append(result, FoldingRange{StartLine: start, EndLine: end, Kind: "comment"})
+			}
+		}
+	}
+
+	return result
+}
+
+// diagnostics reports the parse errors of the tokenizer.
+func diagnostics(d *document) []Diagnostic {
+	result := []Diagnostic{}
+	report := func(i int, message string) {
+		result = append(result, Diagnostic{
+			Range:    d.rangeOf(d.Offset(i), d.Offset(i+1)),
+			Severity: SeverityError,
+			Source:   "css-lsp",
+			Message:  message,
+		})
+	}
+
+	tokens := d.Tokens()
+	for i, token := range tokens {
+		// Only the last token can be ended by the end of the document.
+		atEOF := i == len(tokens)-1 && unterminated(token.Raw)
+		switch token.Type {
+		case csslexer.BadStringToken:
+			report(i, "Unterminated string")
+		case csslexer.StringToken:
+			if atEOF {
+				report(i, "Unterminated string")
+			}
+		case csslexer.BadUrlToken:
+			report(i, "Invalid url()")
+		case csslexer.UrlToken:
+			if atEOF {
+				report(i, "Unterminated url()")
+			}
+		case csslexer.CommentToken:
+			if atEOF {
+				report(i, "Unterminated comment")
+			}
+		case csslexer.DelimiterToken:
+			if token.Value == "\\" {
+				report(i, "Invalid escape")
+			}
+		}
+	}
+
+	return result
+}
+
+// unterminated reports whether a string, URL or comment is ended by the
+// end of its text rather than by its closing delimiter, such as `"a\"`:
+// the lexer then does not end it the same way before a newline.
+func unterminated(raw []rune) bool {
+	text := make([]rune, len(raw), len(raw)+1)
+	copy(text, raw)
+	token := csslexer.NewLexer(csslexer.NewInputRunes(append(text, '\n'))).Next()
+	return token.Type == csslexer.BadStringToken || len(token.Raw) != len(raw)
+}
+
+// colorFunctions are the functions whose values are decorated as colors.
+var colorFunctions = map[string]bool{
+	"rgb": true, "rgba": true, "hsl": true, "hsla": true, "hwb": true,
+	"lab": true, "lch": true, "oklab": true, "oklch": true, "color": true, "color-mix": true,
+}
+
+// documentColors returns the hex colors and color functions in
+// declaration values.
+func documentColors(d *document) []ColorInformation {
+	result := []ColorInformation{}
+	tokens := d.Tokens()
+
+	depth, inValue := 0, false
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.Type {
+		case csslexer.LeftBraceToken:
+			depth++
+			inValue = false
+		case csslexer.RightBraceToken:
+			depth--
+			inValue = false
+		case csslexer.SemicolonToken:
+			inValue = false
+		case csslexer.ColonToken:
+			inValue = depth > 0
+
+		case csslexer.HashToken:
+			if !inValue {
+				continue
+			}
+			if c, err := color.Parse(tokens[i : i+1]); err == nil {
+				result = append(result, colorInformation(d, i, i+1, c))
+			}
+
+		case csslexer.FunctionToken:
+			if !inValue || !colorFunctions[strings.ToLower(token.Value)] {
+				continue
+			}
+			end := closingParen(tokens, i)
+			if c, err := color.Parse(tokens[i:end]); err == nil && !c.IsKeyword() {
+				result = append(result, colorInformation(d, i, end, c))
+			}
+			i = end - 1
+		}
+	}
+
+	return result
+}
+
+func colorInformation(d *document, start, end int, c color.Color) ColorInformation {
+	srgb := c.Convert(color.SRGB).ToGamut(color.SRGB)
+	channel := func(v float64) float64 {
+		if math.IsNaN(v) {
+			return 0
+		}
+		return math.Max(0, math.Min(1, v))
+	}
+
+	return ColorInformation{
+		Range: d.rangeOf(d.Offset(start), d.Offset(end)),
+		Color: Color{
+			Red:   channel(srgb.Channels[0]),
+			Green: channel(srgb.Channels[1]),
+			Blue:  channel(srgb.Channels[2]),
+			Alpha: channel(srgb.Alpha),
+		},
+	}
+}
+
+// colorPresentations returns the ways to write a color picked by the user.
+func colorPresentations(c Color) []ColorPresentation {
+	to8Bit := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	rgba := color.RGBA(to8Bit(c.Red), to8Bit(c.Green), to8Bit(c.Blue), to8Bit(c.Alpha))
+
+	return []ColorPresentation{
+		{Label: rgba.Minify()},
+		{Label: rgba.String()},
+	}
+}
+
+// closingParen returns the index after the parenthesis closing the
+// function at tokens[start], or len(tokens) if it is unclosed.
+func closingParen(tokens []csslexer.Token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken:
+			depth++
+		case csslexer.RightParenthesisToken:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(tokens)
+}
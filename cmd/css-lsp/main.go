@@ -0,0 +1,23 @@
+// Command css-lsp is a language server for CSS built on the lexer.
+//
+// It communicates over standard input and output, and provides semantic
+// tokens, document symbols, folding ranges, diagnostics for tokenizer
+// errors and color decorators.
+//
+// Usage:
+//
+//	css-lsp
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	code, err := newServer(os.Stdin, os.Stdout).run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "css-lsp:", err)
+	}
+	os.Exit(code)
+}
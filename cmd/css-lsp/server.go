@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// server is a language server communicating over a pair of streams.
+type server struct {
+	in  *bufio.Reader
+	out io.Writer
+	mu  sync.Mutex // serializes writes to out
+
+	documents map[string]*document
+	shutdown  bool
+}
+
+func newServer(in io.Reader, out io.Writer) *server {
+	return &server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		documents: make(map[string]*document),
+	}
+}
+
+// run serves requests until the exit notification or the end of the input.
+// It returns the exit code of the process: 0 after a shutdown request, 1
+// otherwise.
+func (s *server) run() (int, error) {
+	for {
+		msg, err := readMessage(s.in)
+		if err == io.EOF {
+			return 1, nil
+		}
+		if rpcErr, ok := err.(*rpcError); ok {
+			if err := s.write(&errorResponse{JSONRPC: "2.0", Error: rpcErr}); err != nil {
+				return 1, err
+			}
+			continue
+		}
+		if err != nil {
+			return 1, err
+		}
+
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return 0, nil
+			}
+			return 1, nil
+		}
+
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			// Notifications have no response, even when they fail.
+			continue
+		}
+		if err != nil {
+			rpcErr, ok := err.(*rpcError)
+			if !ok {
+				rpcErr = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+			}
+			err = s.write(&errorResponse{JSONRPC: "2.0", ID: msg.ID, Error: rpcErr})
+		} else {
+			err = s.write(&response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		}
+		if err != nil {
+			return 1, err
+		}
+	}
+}
+
+func (s *server) write(msg interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeMessage(s.out, msg)
+}
+
+// handle handles a request or notification and returns its result.
+func (s *server) handle(msg *message) (interface{}, error) {
+	if s.shutdown && msg.Method != "exit" && msg.ID != nil {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(), nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		d := newDocument(params.TextDocument.Text)
+		s.documents[params.TextDocument.URI] = d
+		return nil, s.publishDiagnostics(params.TextDocument.URI, d)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		d, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		for _, change := range params.ContentChanges {
+			d.change(change)
+		}
+		return nil, s.publishDiagnostics(params.TextDocument.URI, d)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.write(&notification{
+			JSONRPC: "2.0",
+			Method:  "textDocument/publishDiagnostics",
+			Params:  PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}},
+		})
+
+	case "textDocument/semanticTokens/full":
+		return s.withDocument(msg, func(d *document) interface{} {
+			return SemanticTokens{Data: semanticTokens(d)}
+		})
+
+	case "textDocument/documentSymbol":
+		return s.withDocument(msg, func(d *document) interface{} {
+			return documentSymbols(d)
+		})
+
+	case "textDocument/foldingRange":
+		return s.withDocument(msg, func(d *document) interface{} {
+			return foldingRanges(d)
+		})
+
+	case "textDocument/documentColor":
+		return s.withDocument(msg, func(d *document) interface{} {
+			return documentColors(d)
+		})
+
+	case "textDocument/colorPresentation":
+		var params ColorPresentationParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return colorPresentations(params.Color), nil
+	}
+
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+// withDocument runs fn with the document of a request that only has
+// document parameters.
+func (s *server) withDocument(msg *message, fn func(d *document) interface{}) (interface{}, error) {
+	var params TextDocumentParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return nil, err
+	}
+	d, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown document: " + params.TextDocument.URI}
+	}
+	return fn(d), nil
+}
+
+func (s *server) initialize() interface{} {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"change":    2, // incremental
+			},
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": SemanticTokensLegend{TokenTypes: semanticTokenTypes, TokenModifiers: []string{}},
+				"full":   true,
+			},
+			"documentSymbolProvider": true,
+			"foldingRangeProvider":   true,
+			"colorProvider":          true,
+		},
+		"serverInfo": map[string]interface{}{
+			"name": "css-lsp",
+		},
+	}
+}
+
+func (s *server) publishDiagnostics(uri string, d *document) error {
+	return s.write(&notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics(d)},
+	})
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// client is a scripted JSON-RPC client talking to a server over pipes.
+type client struct {
+	t      *testing.T
+	in     *bufio.Reader
+	out    io.WriteCloser
+	nextID int
+	code   chan int
+
+	// pending holds the notifications received while waiting for a
+	// response.
+	pending []incoming
+}
+
+// incoming is a response or notification sent by the server.
+type incoming struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, in: bufio.NewReader(clientIn), out: clientOut, code: make(chan int, 1)}
+	go func() {
+		code, err := newServer(serverIn, serverOut).run()
+		if err != nil {
+			t.Errorf("run() error = %v", err)
+		}
+		serverOut.Close()
+		c.code <- code
+	}()
+	return c
+}
+
+func (c *client) send(msg interface{}) {
+	c.t.Helper()
+	if err := writeMessage(c.out, msg); err != nil {
+		c.t.Fatalf("writeMessage() error = %v", err)
+	}
+}
+
+func (c *client) receive() incoming {
+	c.t.Helper()
+
+	body, err := readFrame(c.in)
+	if err != nil {
+		c.t.Fatalf("readFrame() error = %v", err)
+	}
+	var in incoming
+	if err := json.Unmarshal(body, &in); err != nil {
+		c.t.Fatalf("cannot decode %s: %v", body, err)
+	}
+	return in
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(&notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// call sends a request and decodes the result of its response into
+// result, returning the error of the response if any.
+func (c *client) call(method string, params, result interface{}) *rpcError {
+	c.t.Helper()
+
+	c.nextID++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+
+	for {
+		in := c.receive()
+		if in.ID == nil {
+			c.pending = append(c.pending, in)
+			continue
+		}
+		if *in.ID != c.nextID {
+			c.t.Fatalf("%s: response id = %d, expected %d", method, *in.ID, c.nextID)
+		}
+		if in.Error != nil {
+			return in.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(in.Result, result); err != nil {
+				c.t.Fatalf("%s: cannot decode result %s: %v", method, in.Result, err)
+			}
+		}
+		return nil
+	}
+}
+
+// diagnostics returns the next diagnostics published by the server.
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+
+	for {
+		var in incoming
+		if len(c.pending) > 0 {
+			in, c.pending = c.pending[0], c.pending[1:]
+		} else {
+			in = c.receive()
+		}
+		if in.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(in.Params, &params); err != nil {
+			c.t.Fatal(err)
+		}
+		return params
+	}
+}
+
+const uri = "file:///style.css"
+
+const source = `/* header
+   comment */
+.card {
+  color: #ff0000;
+  background: rgb(0 0 255 / 50%);
+  @media (min-width: 10px) {
+    border: 1px solid red;
+  }
+}
+`
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+
+	var init struct {
+		Capabilities struct {
+			TextDocumentSync struct {
+				Change int `json:"change"`
+			} `json:"textDocumentSync"`
+			SemanticTokensProvider struct {
+				Legend SemanticTokensLegend `json:"legend"`
+			} `json:"semanticTokensProvider"`
+			ColorProvider bool `json:"colorProvider"`
+		} `json:"capabilities"`
+	}
+	if err := c.call("initialize", map[string]interface{}{}, &init); err != nil {
+		t.Fatalf("initialize error = %v", err)
+	}
+	if init.Capabilities.TextDocumentSync.Change != 2 || !init.Capabilities.ColorProvider {
+		t.Errorf("initialize capabilities = %+v", init.Capabilities)
+	}
+	if !reflect.DeepEqual(init.Capabilities.SemanticTokensProvider.Legend.TokenTypes, semanticTokenTypes) {
+		t.Errorf("semantic tokens legend = %v", init.Capabilities.SemanticTokensProvider.Legend)
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "css", Version: 1, Text: source},
+	})
+	if diags := c.diagnostics(); diags.URI != uri || len(diags.Diagnostics) != 0 {
+		t.Errorf("diagnostics after didOpen = %+v, expected none", diags)
+	}
+
+	t.Run("Semantic tokens", func(t *testing.T) {
+		var tokens SemanticTokens
+		if err := c.call("textDocument/semanticTokens/full", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &tokens); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		expected := []int{
+			0, 0, 9, 0, 0, // "/* header", first line of the comment
+			1, 0, 13, 0, 0, // "   comment */"
+			1, 0, 1, 7, 0, // "."
+			0, 1, 4, 5, 0, // "card"
+			1, 2, 5, 5, 0, // "color"
+			0, 5, 1, 7, 0, // ":"
+			0, 2, 7, 6, 0, // "#ff0000"
+		}
+		if len(tokens.Data) < len(expected) || !reflect.DeepEqual(tokens.Data[:len(expected)], expected) {
+			t.Errorf("data = %v, expected to start with %v", tokens.Data, expected)
+		}
+		if len(tokens.Data)%5 != 0 {
+			t.Errorf("len(data) = %d, expected a multiple of 5", len(tokens.Data))
+		}
+	})
+
+	t.Run("Document symbols", func(t *testing.T) {
+		var symbols []DocumentSymbol
+		if err := c.call("textDocument/documentSymbol", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if len(symbols) != 1 || symbols[0].Name != ".card" || symbols[0].Kind != SymbolKindClass {
+			t.Fatalf("symbols = %+v", symbols)
+		}
+		if expected := (Range{Start: Position{2, 0}, End: Position{8, 1}}); symbols[0].Range != expected {
+			t.Errorf("range = %+v, expected %+v", symbols[0].Range, expected)
+		}
+		children := symbols[0].Children
+		if len(children) != 1 || children[0].Name != "@media (min-width: 10px)" || children[0].Kind != SymbolKindModule {
+			t.Errorf("children = %+v", children)
+		}
+	})
+
+	t.Run("Folding ranges", func(t *testing.T) {
+		var ranges []FoldingRange
+		if err := c.call("textDocument/foldingRange", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &ranges); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		expected := []FoldingRange{
+			{StartLine: 0, EndLine: 1, Kind: "comment"},
+			{StartLine: 5, EndLine: 6},
+			{StartLine: 2, EndLine: 7},
+		}
+		if !reflect.DeepEqual(ranges, expected) {
+			t.Errorf("ranges = %+v, expected %+v", ranges, expected)
+		}
+	})
+
+	t.Run("Colors", func(t *testing.T) {
+		var colors []ColorInformation
+		if err := c.call("textDocument/documentColor", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &colors); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		expected := []ColorInformation{
+			{Range: Range{Start: Position{3, 9}, End: Position{3, 16}}, Color: Color{Red: 1, Alpha: 1}},
+			{Range: Range{Start: Position{4, 14}, End: Position{4, 32}}, Color: Color{Blue: 1, Alpha: 0.5}},
+		}
+		if !reflect.DeepEqual(colors, expected) {
+			t.Errorf("colors = %+v, expected %+v", colors, expected)
+		}
+
+		var presentations []ColorPresentation
+		params := ColorPresentationParams{TextDocument: TextDocumentIdentifier{URI: uri}, Color: Color{Red: 1, Green: 1, Blue: 1, Alpha: 1}}
+		if err := c.call("textDocument/colorPresentation", params, &presentations); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if expected := []ColorPresentation{{Label: "#fff"}, {Label: "rgb(255, 255, 255)"}}; !reflect.DeepEqual(presentations, expected) {
+			t.Errorf("presentations = %+v, expected %+v", presentations, expected)
+		}
+	})
+
+	t.Run("Incremental changes and diagnostics", func(t *testing.T) {
+		// Open a string at the end of line 3 and an unterminated comment.
+		c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			ContentChanges: []TextDocumentContentChangeEvent{
+				{Range: &Range{Start: Position{3, 17}, End: Position{3, 17}}, Text: " 'x"},
+				{Range: &Range{Start: Position{9, 0}, End: Position{9, 0}}, Text: "/* end"},
+			},
+		})
+		diags := c.diagnostics()
+		expected := []Diagnostic{
+			{Range: Range{Start: Position{3, 18}, End: Position{3, 20}}, Severity: SeverityError, Source: "css-lsp", Message: "Unterminated string"},
+			{Range: Range{Start: Position{9, 0}, End: Position{9, 6}}, Severity: SeverityError, Source: "css-lsp", Message: "Unterminated comment"},
+		}
+		if !reflect.DeepEqual(diags.Diagnostics, expected) {
+			t.Errorf("diagnostics = %+v, expected %+v", diags.Diagnostics, expected)
+		}
+
+		// Replace the whole document.
+		c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+			TextDocument:   TextDocumentIdentifier{URI: uri},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: "a{}"}},
+		})
+		if diags := c.diagnostics(); len(diags.Diagnostics) != 0 {
+			t.Errorf("diagnostics = %+v, expected none", diags.Diagnostics)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if err := c.call("textDocument/hover", map[string]interface{}{}, nil); err == nil || err.Code != codeMethodNotFound {
+			t.Errorf("unknown method error = %v, expected method not found", err)
+		}
+		if err := c.call("textDocument/documentSymbol", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: "file:///missing.css"}}, nil); err == nil || err.Code != codeInvalidParams {
+			t.Errorf("unknown document error = %v, expected invalid params", err)
+		}
+	})
+
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatalf("shutdown error = %v", err)
+	}
+	c.notify("exit", nil)
+	if code := <-c.code; code != 0 {
+		t.Errorf("exit code = %d, expected 0", code)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newClient(t)
+	c.notify("exit", nil)
+	if code := <-c.code; code != 1 {
+		t.Errorf("exit code = %d, expected 1", code)
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		body  string // Empty if readFrame fails
+	}{
+		{"Valid", "Content-Length: 2\r\n\r\n{}", "{}"},
+		{"Invalid length", "Content-Length: -1\r\n\r\n{}", ""},
+		{"Too large", fmt.Sprintf("Content-Length: %d\r\n\r\n{}", maxFrameSize+1), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := readFrame(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.body == "" {
+				if err == nil {
+					t.Errorf("readFrame() = %q, expected an error", body)
+				}
+			} else if err != nil || string(body) != tt.body {
+				t.Errorf("readFrame() = %q, %v, expected %q", body, err, tt.body)
+			}
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		text     string
+		messages []string
+	}{
+		{`a{b:"c"}`, nil},
+		{`a{b:"c`, []string{"Unterminated string"}},
+		{`a{b:"c\"`, []string{"Unterminated string"}},
+		{`a{b:"c\`, []string{"Unterminated string"}},
+		{`a{b:"c` + "\n}", []string{"Unterminated string"}},
+		{`a{b:url(c)}`, nil},
+		{`a{b:url(c\)`, []string{"Unterminated url()"}},
+		{`/**/`, nil},
+		{`/*/`, []string{"Unterminated comment"}},
+		{`/* a *\/`, []string{"Unterminated comment"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var messages []string
+			for _, diagnostic := range diagnostics(newDocument(tt.text)) {
+				messages = append(messages, diagnostic.Message)
+			}
+			if !reflect.DeepEqual(messages, tt.messages) {
+				t.Errorf("diagnostics(%q) = %q, expected %q", tt.text, messages, tt.messages)
+			}
+		})
+	}
+}
+
+func TestDocumentPositions(t *testing.T) {
+	d := newDocument("a\r\nb😀c\nd")
+
+	tests := []struct {
+		offset   int
+		position Position
+	}{
+		{0, Position{0, 0}},
+		{1, Position{0, 1}},
+		{3, Position{1, 0}},
+		{5, Position{1, 3}},
+		{6, Position{1, 4}},
+		{7, Position{2, 0}},
+		{8, Position{2, 1}},
+	}
+
+	for _, tt := range tests {
+		if result := d.position(tt.offset); result != tt.position {
+			t.Errorf("position(%d) = %v, expected %v", tt.offset, result, tt.position)
+		}
+		if result := d.offset(tt.position); result != tt.offset {
+			t.Errorf("offset(%v) = %d, expected %d", tt.position, result, tt.offset)
+		}
+	}
+}
+
+func TestDocumentChanges(t *testing.T) {
+	const text = "a\r\nb\rc\n\r😀d"
+	n := len([]rune(text))
+	for start := 0; start <= n; start++ {
+		for end := start; end <= n; end++ {
+			for _, insert := range []string{"", "x", "\n", "\r", "\r\n", "\n\r", "😀\r"} {
+				d := newDocument(text)
+				r := d.rangeOf(start, end)
+				d.change(TextDocumentContentChangeEvent{Range: &r, Text: insert})
+
+				expected := newDocument(d.Text())
+				if !reflect.DeepEqual(d.lines, expected.lines) {
+					t.Fatalf("lines after replacing %d-%d of %q with %q = %v, expected %v", start, end, text, insert, d.lines, expected.lines)
+				}
+			}
+		}
+	}
+}
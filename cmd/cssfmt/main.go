@@ -0,0 +1,108 @@
+// Command cssfmt formats style sheets.
+//
+// Without files, it formats standard input to standard output. With files,
+// it prints the formatted files, or rewrites them in place with -w.
+//
+// Usage:
+//
+//	cssfmt [flags] [file ...]
+//
+// The flags are:
+//
+//	-w
+//		write the result to the files instead of standard output
+//	-l
+//		list the files whose formatting differs
+//	-indent string
+//		indentation of one nesting level (default two spaces)
+//	-lower-hex
+//		lowercase hex colors
+//	-lower-keywords
+//		lowercase property names, functions, units and keyword values
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go.baoshuo.dev/csslexer/format"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with the given arguments and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cssfmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write the result to the files instead of standard output")
+	list := flags.Bool("l", false, "list the files whose formatting differs")
+	var opts format.Options
+	flags.StringVar(&opts.Indent, "indent", "  ", "indentation of one nesting level")
+	flags.BoolVar(&opts.LowercaseHex, "lower-hex", false, "lowercase hex colors")
+	flags.BoolVar(&opts.LowercaseKeywords, "lower-keywords", false, "lowercase property names, functions, units and keyword values")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "cssfmt: cannot use -w with standard input")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, "cssfmt:", err)
+			return 1
+		}
+		result := format.Format(string(src), opts)
+		if *list {
+			if result != string(src) {
+				fmt.Fprintln(stdout, "<standard input>")
+			}
+			return 0
+		}
+		io.WriteString(stdout, result)
+		return 0
+	}
+
+	code := 0
+	for _, name := range flags.Args() {
+		if err := file(name, opts, *write, *list, stdout); err != nil {
+			fmt.Fprintln(stderr, "cssfmt:", err)
+			code = 1
+		}
+	}
+	return code
+}
+
+// file formats a single file.
+func file(name string, opts format.Options, write, list bool, stdout io.Writer) error {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	result := format.Format(string(src), opts)
+	changed := result != string(src)
+
+	if list && changed {
+		fmt.Fprintln(stdout, name)
+	}
+	if write {
+		if !changed {
+			return nil
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, []byte(result), info.Mode().Perm())
+	}
+	if !list {
+		_, err = io.WriteString(stdout, result)
+	}
+	return err
+}
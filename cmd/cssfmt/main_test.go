@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	formatted := filepath.Join(dir, "formatted.css")
+	unformatted := filepath.Join(dir, "unformatted.css")
+	if err := os.WriteFile(formatted, []byte("a {\n  b: c;\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(unformatted, []byte("A{COLOR:#FFF}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "Standard input",
+			stdin:  "a{b:c}",
+			stdout: "a {\n  b: c;\n}\n",
+		},
+		{
+			name:   "Options",
+			args:   []string{"-indent", "\t", "-lower-hex", "-lower-keywords"},
+			stdin:  "a{B:#ABC}",
+			stdout: "a {\n\tb: #abc;\n}\n",
+		},
+		{
+			name:   "Files",
+			args:   []string{formatted, unformatted},
+			stdout: "a {\n  b: c;\n}\nA {\n  COLOR: #FFF;\n}\n",
+		},
+		{
+			name:   "List",
+			args:   []string{"-l", formatted, unformatted},
+			stdout: unformatted + "\n",
+		},
+		{
+			name:   "Missing file",
+			args:   []string{filepath.Join(dir, "missing.css")},
+			code:   1,
+			stderr: "no such file or directory",
+		},
+		{
+			name:   "Write without files",
+			args:   []string{"-w"},
+			code:   2,
+			stderr: "cannot use -w with standard input",
+		},
+		{
+			name:   "Unknown flag",
+			args:   []string{"-x"},
+			code:   2,
+			stderr: "flag provided but not defined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.code {
+				t.Errorf("run() = %d, expected %d (stderr: %s)", code, tt.code, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("stdout = %q, expected %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr = %q, expected it to contain %q", stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
+
+func TestRunWrite(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.css")
+	if err := os.WriteFile(name, []byte("a{b:c}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-w", name}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, expected nothing", stdout.String())
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "a {\n  b: c;\n}\n"; string(data) != expected {
+		t.Errorf("file = %q, expected %q", data, expected)
+	}
+}
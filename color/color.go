@@ -0,0 +1,199 @@
+// Package color parses CSS color values from lexer tokens, converts them
+// between color spaces and serializes them back to CSS.
+//
+// It implements the color syntaxes defined by CSS Color Module Level 4 and
+// the color-mix() and relative color syntaxes of CSS Color Module Level 5.
+//
+// https://www.w3.org/TR/css-color-4/
+// https://www.w3.org/TR/css-color-5/
+package color
+
+import (
+	"math"
+)
+
+// ===== Space =====
+
+// Space identifies the color space a Color's channels are expressed in.
+type Space int
+
+const (
+	SRGB        Space = iota // srgb, also used by hex colors, named colors and rgb()
+	SRGBLinear               // srgb-linear
+	DisplayP3                // display-p3
+	A98RGB                   // a98-rgb
+	ProPhotoRGB              // prophoto-rgb
+	Rec2020                  // rec2020
+	XYZD50                   // xyz-d50
+	XYZD65                   // xyz-d65 (and xyz)
+	HSL                      // hsl()
+	HWB                      // hwb()
+	Lab                      // lab()
+	LCH                      // lch()
+	OKLab                    // oklab()
+	OKLCH                    // oklch()
+)
+
+// String returns the name of the color space as used in color() and
+// color-mix().
+func (s Space) String() string {
+	switch s {
+	case SRGB:
+		return "srgb"
+	case SRGBLinear:
+		return "srgb-linear"
+	case DisplayP3:
+		return "display-p3"
+	case A98RGB:
+		return "a98-rgb"
+	case ProPhotoRGB:
+		return "prophoto-rgb"
+	case Rec2020:
+		return "rec2020"
+	case XYZD50:
+		return "xyz-d50"
+	case XYZD65:
+		return "xyz-d65"
+	case HSL:
+		return "hsl"
+	case HWB:
+		return "hwb"
+	case Lab:
+		return "lab"
+	case LCH:
+		return "lch"
+	case OKLab:
+		return "oklab"
+	case OKLCH:
+		return "oklch"
+	default:
+		return "unknown"
+	}
+}
+
+// spaceByName looks up a color space by the name used in color() and
+// color-mix(). The name must already be lowercased.
+func spaceByName(name string) (Space, bool) {
+	switch name {
+	case "srgb":
+		return SRGB, true
+	case "srgb-linear":
+		return SRGBLinear, true
+	case "display-p3":
+		return DisplayP3, true
+	case "a98-rgb":
+		return A98RGB, true
+	case "prophoto-rgb":
+		return ProPhotoRGB, true
+	case "rec2020":
+		return Rec2020, true
+	case "xyz-d50":
+		return XYZD50, true
+	case "xyz", "xyz-d65":
+		return XYZD65, true
+	case "hsl":
+		return HSL, true
+	case "hwb":
+		return HWB, true
+	case "lab":
+		return Lab, true
+	case "lch":
+		return LCH, true
+	case "oklab":
+		return OKLab, true
+	case "oklch":
+		return OKLCH, true
+	}
+	return 0, false
+}
+
+// isPredefined reports whether the space is only reachable through color().
+func (s Space) isPredefined() bool {
+	return s <= XYZD65
+}
+
+// isRGB reports whether the space is an RGB space with a [0, 1] gamut.
+func (s Space) isRGB() bool {
+	return s <= Rec2020
+}
+
+// hueIndex returns the index of the hue channel, or -1 for spaces without a
+// hue.
+func (s Space) hueIndex() int {
+	switch s {
+	case HSL, HWB:
+		return 0
+	case LCH, OKLCH:
+		return 2
+	}
+	return -1
+}
+
+// ===== Color =====
+
+// Color is a parsed CSS color.
+//
+// A channel or the alpha value is NaN when it is missing, i.e. it was
+// written as the `none` keyword or it became powerless during a
+// conversion.
+type Color struct {
+	Space    Space      // The color space of the channels
+	Channels [3]float64 // Channel values, in the ranges used by the space's CSS function
+	Alpha    float64    // Alpha value in the range [0, 1]
+
+	// Keyword holds the lowercased name of colors that cannot be resolved
+	// without context, i.e. `currentcolor` and the system colors. The other
+	// fields are meaningless when it is set.
+	Keyword string
+
+	// legacy marks sRGB colors written with the legacy color syntax (hex
+	// colors, named colors, rgb(), hsl() and hwb()), which serialize with
+	// 8-bit precision.
+	legacy bool
+}
+
+// Channel ranges, in the units stored in Color.Channels:
+//
+//	srgb, ..., rec2020   r, g, b in [0, 1]
+//	xyz-d50, xyz-d65     x, y, z with 1 being the white point luminance
+//	hsl                  h in [0, 360), s and l in [0, 100]
+//	hwb                  h in [0, 360), w and b in [0, 100]
+//	lab                  l in [0, 100], a and b around [-125, 125]
+//	lch                  l in [0, 100], c around [0, 150], h in [0, 360)
+//	oklab                l in [0, 1], a and b around [-0.4, 0.4]
+//	oklch                l in [0, 1], c around [0, 0.4], h in [0, 360)
+
+// IsKeyword reports whether the color is `currentcolor` or a system color.
+func (c Color) IsKeyword() bool {
+	return c.Keyword != ""
+}
+
+// IsLegacy reports whether the color was written with the legacy sRGB color
+// syntax.
+func (c Color) IsLegacy() bool {
+	return c.legacy
+}
+
+// RGBA returns an sRGB color from 8-bit channels, as if written as a hex
+// color.
+func RGBA(r, g, b, a uint8) Color {
+	return Color{
+		Space:    SRGB,
+		Channels: [3]float64{float64(r) / 255, float64(g) / 255, float64(b) / 255},
+		Alpha:    float64(a) / 255,
+		legacy:   true,
+	}
+}
+
+// isNone reports whether a channel value is missing.
+func isNone(v float64) bool {
+	return math.IsNaN(v)
+}
+
+// orZero replaces a missing channel value with zero.
+func orZero(v float64) float64 {
+	if isNone(v) {
+		return 0
+	}
+	return v
+}
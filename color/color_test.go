@@ -0,0 +1,217 @@
+package color
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Short hex", input: "#f00", expected: "rgb(255, 0, 0)"},
+		{name: "Short hex with alpha", input: "#f008", expected: "rgba(255, 0, 0, 0.533)"},
+		{name: "Long hex", input: "#00FF7f", expected: "rgb(0, 255, 127)"},
+		{name: "Long hex with alpha", input: "#0000ff80", expected: "rgba(0, 0, 255, 0.5)"},
+		{name: "Named color", input: "RebeccaPurple", expected: "rgb(102, 51, 153)"},
+		{name: "Transparent", input: "transparent", expected: "rgba(0, 0, 0, 0)"},
+		{name: "Current color", input: "currentColor", expected: "currentcolor"},
+		{name: "System color", input: "CanvasText", expected: "canvastext"},
+		{name: "Legacy rgb", input: "rgb(255, 128, 0)", expected: "rgb(255, 128, 0)"},
+		{name: "Legacy rgba percentages", input: "rgba(100%, 50%, 0%, 0.25)", expected: "rgba(255, 128, 0, 0.25)"},
+		{name: "Legacy rgb clamped", input: "rgb(300, -10, 0)", expected: "rgb(255, 0, 0)"},
+		{name: "Modern rgb", input: "rgb(255 128 0 / 50%)", expected: "rgba(255, 128, 0, 0.5)"},
+		{name: "Modern rgb with none", input: "rgb(none 128 0)", expected: "rgb(0, 128, 0)"},
+		{name: "Legacy hsl", input: "hsl(120, 100%, 25%)", expected: "rgb(0, 128, 0)"},
+		{name: "Modern hsl with angle", input: "hsl(0.5turn 100 50)", expected: "rgb(0, 255, 255)"},
+		{name: "Hwb", input: "hwb(0 0% 0%)", expected: "rgb(255, 0, 0)"},
+		{name: "Hwb gray", input: "hwb(90 60% 60%)", expected: "rgb(128, 128, 128)"},
+		{name: "Lab", input: "lab(50% 40 -20)", expected: "lab(50 40 -20)"},
+		{name: "Lch with alpha", input: "lch(50 100% 400deg / .5)", expected: "lch(50 150 40 / 0.5)"},
+		{name: "Oklab", input: "oklab(0.5 -0.1 10%)", expected: "oklab(0.5 -0.1 0.04)"},
+		{name: "Oklch clamped lightness", input: "oklch(150% 0.2 none)", expected: "oklch(1 0.2 none)"},
+		{name: "Color function", input: "color(display-p3 1 0.5 0)", expected: "color(display-p3 1 0.5 0)"},
+		{name: "Color function xyz alias", input: "color(xyz 0.5 50% 1)", expected: "color(xyz-d65 0.5 0.5 1)"},
+		{name: "Comments and whitespace", input: " /* a */ rgb( 1 /**/ 2 3 ) ", expected: "rgb(1, 2, 3)"},
+		{name: "Color mix", input: "color-mix(in srgb, red, blue)", expected: "color(srgb 0.5 0 0.5)"},
+		{name: "Color mix with percentage", input: "color-mix(in srgb, red 25%, 75% blue)", expected: "color(srgb 0.25 0 0.75)"},
+		{name: "Color mix with alpha multiplier", input: "color-mix(in srgb, red 30%, blue 20%)", expected: "color(srgb 0.6 0 0.4 / 0.5)"},
+		{name: "Color mix with hue method", input: "color-mix(in hsl longer hue, hsl(10 100 50), hsl(350 100 50))", expected: "hsl(180 100 50)"},
+		{name: "Color mix premultiplied", input: "color-mix(in srgb, rgb(255 0 0 / 0), blue)", expected: "color(srgb 0 0 1 / 0.5)"},
+		{name: "Relative rgb", input: "rgb(from #ff8000 b g r)", expected: "rgb(0, 128, 255)"},
+		{name: "Relative hsl", input: "hsl(from red h s 25 / alpha)", expected: "rgb(128, 0, 0)"},
+		{name: "Relative color function", input: "color(from red srgb r 1 b / 0.5)", expected: "color(srgb 1 1 0 / 0.5)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			color, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseString(%q) returned error: %v", tt.input, err)
+			}
+			if result := color.String(); result != tt.expected {
+				t.Errorf("ParseString(%q).String() = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseStringInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected error
+	}{
+		{name: "Empty", input: "", expected: ErrInvalid},
+		{name: "Two values", input: "red blue", expected: ErrInvalid},
+		{name: "Bad hex length", input: "#ff00f", expected: ErrInvalid},
+		{name: "Bad hex digit", input: "#ggg", expected: ErrInvalid},
+		{name: "Unknown name", input: "reddish", expected: ErrInvalid},
+		{name: "Unknown function", input: "rgbx(1 2 3)", expected: ErrInvalid},
+		{name: "Legacy mixed types", input: "rgb(255, 50%, 0)", expected: ErrInvalid},
+		{name: "Legacy none", input: "rgb(none, 0, 0)", expected: ErrInvalid},
+		{name: "Legacy hsl numbers", input: "hsl(120, 100, 50)", expected: ErrInvalid},
+		{name: "Missing channel", input: "lab(50 20)", expected: ErrInvalid},
+		{name: "Missing slash", input: "lab(50 20 10 0.5)", expected: ErrInvalid},
+		{name: "Percentage hue", input: "lch(50 20 10%)", expected: ErrInvalid},
+		{name: "Unknown angle unit", input: "hsl(10px 50 50)", expected: ErrInvalid},
+		{name: "Unknown color space", input: "color(cmyk 0 0 0)", expected: ErrInvalid},
+		{name: "Mix without space", input: "color-mix(red, blue)", expected: ErrInvalid},
+		{name: "Mix hue method in rectangular space", input: "color-mix(in srgb longer hue, red, blue)", expected: ErrInvalid},
+		{name: "Mix zero percentages", input: "color-mix(in srgb, red 0%, blue 0%)", expected: ErrInvalid},
+		{name: "Unclosed block", input: "rgb(1 2 3))", expected: ErrInvalid},
+		{name: "Math function", input: "rgb(calc(1) 2 3)", expected: ErrUnsupported},
+		{name: "Mix with currentcolor", input: "color-mix(in srgb, currentcolor, red)", expected: ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseString(tt.input)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("ParseString(%q) error = %v, expected %v", tt.input, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "#ff0000", expected: "red"},
+		{input: "rgb(255, 255, 255)", expected: "#fff"},
+		{input: "#F0FFFF", expected: "azure"},
+		{input: "navy", expected: "navy"},
+		{input: "#123456", expected: "#123456"},
+		{input: "rgba(0, 0, 0, 0)", expected: "#0000"},
+		{input: "transparent", expected: "#0000"},
+		{input: "rgb(255 0 0 / 0.4)", expected: "#f006"},
+		{input: "hsl(0 100% 50% / 50%)", expected: "#ff000080"},
+		{input: "color(srgb 1 1 0)", expected: "#ff0"},
+		{input: "color(srgb 0.25 0.5 1)", expected: "color(srgb .25 .5 1)"},
+		{input: "lab(50 -0.5 10 / 0.25)", expected: "lab(50 -.5 10/.25)"},
+		{input: "oklch(0.5 0.1 none)", expected: "oklch(.5 .1 none)"},
+		{input: "CurrentColor", expected: "currentcolor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			color, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseString(%q) returned error: %v", tt.input, err)
+			}
+			if result := color.Minify(); result != tt.expected {
+				t.Errorf("ParseString(%q).Minify() = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		space    Space
+		expected [3]float64
+	}{
+		{name: "Red to oklch", input: "red", space: OKLCH, expected: [3]float64{0.627955, 0.257683, 29.233885}},
+		{name: "Red to lab", input: "red", space: Lab, expected: [3]float64{54.290541, 80.804920, 69.890996}},
+		{name: "White to xyz-d50", input: "white", space: XYZD50, expected: [3]float64{0.964296, 1, 0.825105}},
+		{name: "Green to display-p3", input: "lime", space: DisplayP3, expected: [3]float64{0.458407, 0.985265, 0.298290}},
+		{name: "Oklab to srgb", input: "oklab(0.627955 0.224863 0.125846)", space: SRGB, expected: [3]float64{1, 0, 0}},
+		{name: "Rec2020 to a98-rgb", input: "color(rec2020 0.5 0.5 0.5)", space: A98RGB, expected: [3]float64{0.541715, 0.541715, 0.541715}},
+		{name: "Prophoto round trip", input: "color(prophoto-rgb 0.2 0.4 0.6)", space: ProPhotoRGB, expected: [3]float64{0.2, 0.4, 0.6}},
+		{name: "Hsl to hwb", input: "hsl(200 50% 40%)", space: HWB, expected: [3]float64{200, 20, 40}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			color, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseString(%q) returned error: %v", tt.input, err)
+			}
+			result := color.Convert(tt.space)
+			if result.Space != tt.space {
+				t.Fatalf("Convert(%s) returned a color in %s", tt.space, result.Space)
+			}
+			for i, v := range result.Channels {
+				if math.Abs(v-tt.expected[i]) > 1e-4 {
+					t.Errorf("Convert(%s).Channels = %v, expected %v", tt.space, result.Channels, tt.expected)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestConvertAchromaticHue(t *testing.T) {
+	color, _ := ParseString("#808080")
+	if hue := color.Convert(OKLCH).Channels[2]; !math.IsNaN(hue) {
+		t.Errorf("expected missing hue for gray in oklch, got %v", hue)
+	}
+}
+
+func TestToGamut(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		space Space
+	}{
+		{name: "P3 red to srgb", input: "color(display-p3 1 0 0)", space: SRGB},
+		{name: "Vivid oklch to srgb", input: "oklch(0.7 0.4 150)", space: SRGB},
+		{name: "Vivid lch to hsl", input: "lch(60 140 300)", space: HSL},
+		{name: "Rec2020 green to display-p3", input: "color(rec2020 0 1 0)", space: DisplayP3},
+		{name: "Too light", input: "oklch(1 0.3 100)", space: SRGB},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			color, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseString(%q) returned error: %v", tt.input, err)
+			}
+			if color.InGamut(tt.space) {
+				t.Fatalf("expected %q to be out of the %s gamut", tt.input, tt.space)
+			}
+			mapped := color.ToGamut(tt.space)
+			if mapped.Space != tt.space {
+				t.Fatalf("ToGamut(%s) returned a color in %s", tt.space, mapped.Space)
+			}
+			if !mapped.InGamut(tt.space) {
+				t.Errorf("ToGamut(%s) = %s, which is out of gamut", tt.space, mapped)
+			}
+			if e := deltaEOK(mapped, color); e > 0.5 {
+				t.Errorf("ToGamut(%s) = %s, too far from the original (deltaEOK %v)", tt.space, mapped, e)
+			}
+		})
+	}
+
+	red, _ := ParseString("red")
+	if mapped := red.ToGamut(SRGB); mapped.String() != "rgb(255, 0, 0)" {
+		t.Errorf("ToGamut on an in-gamut color = %s, expected it unchanged", mapped)
+	}
+}
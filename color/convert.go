@@ -0,0 +1,468 @@
+package color
+
+import (
+	"math"
+)
+
+// The conversion matrices and transfer functions below are taken from the
+// sample code of CSS Color Module Level 4.
+//
+// https://www.w3.org/TR/css-color-4/#color-conversion-code
+
+type matrix [3][3]float64
+
+func (m *matrix) mul(v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+var (
+	d50White = [3]float64{0.3457 / 0.3585, 1.0, (1.0 - 0.3457 - 0.3585) / 0.3585}
+
+	d65ToD50 = matrix{
+		{1.0479298208405488, 0.022946793341019088, -0.05019222954313557},
+		{0.029627815688159344, 0.990434484573249, -0.01707382502938514},
+		{-0.009243058152591178, 0.015055144896577895, 0.7518742899580008},
+	}
+	d50ToD65 = matrix{
+		{0.9554734527042182, -0.023098536874261423, 0.0632593086610217},
+		{-0.028369706963208136, 1.0099954580058226, 0.021041398966943008},
+		{0.012314001688319899, -0.020507696433477912, 1.3303659366080753},
+	}
+
+	linSRGBToXYZ = matrix{
+		{0.41239079926595934, 0.357584339383878, 0.1804807884018343},
+		{0.21263900587151027, 0.715168678767756, 0.07219231536073371},
+		{0.01933081871559182, 0.11919477979462598, 0.9505321522496607},
+	}
+	xyzToLinSRGB = matrix{
+		{3.2409699419045226, -1.537383177570094, -0.4986107602930034},
+		{-0.9692436362808796, 1.8759675015077202, 0.04155505740717559},
+		{0.05563007969699366, -0.20397695888897652, 1.0569715142428786},
+	}
+
+	linP3ToXYZ = matrix{
+		{0.4865709486482162, 0.26566769316909306, 0.1982172852343625},
+		{0.2289745640697488, 0.6917385218365064, 0.079286914093745},
+		{0.0000000000000000, 0.04511338185890264, 1.043944368900976},
+	}
+	xyzToLinP3 = matrix{
+		{2.493496911941425, -0.9313836179191239, -0.40271078445071684},
+		{-0.8294889695615747, 1.7626640603183463, 0.023624685841943577},
+		{0.03584583024378447, -0.07617238926804182, 0.9568845240076872},
+	}
+
+	linA98ToXYZ = matrix{
+		{0.5766690429101305, 0.1855582379065463, 0.1882286462349947},
+		{0.29734497525053605, 0.6273635662554661, 0.07529145849399788},
+		{0.02703136138641234, 0.07068885253582723, 0.9913375368376388},
+	}
+	xyzToLinA98 = matrix{
+		{2.0415879038107465, -0.5650069742788596, -0.34473135077832956},
+		{-0.9692436362808795, 1.8759675015077202, 0.04155505740717557},
+		{0.013444280632031142, -0.11836239223101838, 1.0151749943912054},
+	}
+
+	// ProPhoto RGB uses the D50 white point.
+	linProPhotoToXYZD50 = matrix{
+		{0.7977666449006423, 0.13518129740053308, 0.0313477341283922},
+		{0.2880748288194013, 0.711835234241873, 0.00008993693872564},
+		{0.0, 0.0, 0.8251046025104602},
+	}
+	xyzD50ToLinProPhoto = matrix{
+		{1.3457868816471583, -0.25557208737979464, -0.05110186497554526},
+		{-0.5446307051249019, 1.5082477428451468, 0.02052744743642139},
+		{0.0, 0.0, 1.2119675456389452},
+	}
+
+	linRec2020ToXYZ = matrix{
+		{0.6369580483012914, 0.14461690358620832, 0.1688809751641721},
+		{0.2627002120112671, 0.6779980715188708, 0.05930171646986196},
+		{0.000000000000000, 0.028072693049087428, 1.060985057710791},
+	}
+	xyzToLinRec2020 = matrix{
+		{1.7166511879712674, -0.35567078377639233, -0.25336628137365974},
+		{-0.6666843518324892, 1.6164812366349395, 0.01576854581391113},
+		{0.017639857445310783, -0.042770613257808524, 0.9421031212354738},
+	}
+
+	xyzToLMS = matrix{
+		{0.8190224379967030, 0.3619062600528904, -0.1288737815209879},
+		{0.0329836539323885, 0.9292868615863434, 0.0361446663506424},
+		{0.0481771893596242, 0.2642395317527308, 0.6335478284694309},
+	}
+	lmsToOKLab = matrix{
+		{0.2104542683093140, 0.7936177747023054, -0.0040720430116193},
+		{1.9779985324311684, -2.4285922420485799, 0.4505937096174110},
+		{0.0259040424655478, 0.7827717124575296, -0.8086757549230774},
+	}
+	lmsToXYZ = matrix{
+		{1.2268798758459243, -0.5578149944602171, 0.2813910456659647},
+		{-0.0405757452148008, 1.1122868032803170, -0.0717110580655164},
+		{-0.0763729366746601, -0.4214933324022432, 1.5869240198367816},
+	}
+	okLabToLMS = matrix{
+		{1.0000000000000000, 0.3963377773761749, 0.2158037573099136},
+		{1.0000000000000000, -0.1055613458156586, -0.0638541728258133},
+		{1.0000000000000000, -0.0894841775298119, -1.2914855480194092},
+	}
+)
+
+// Convert returns the color expressed in the given color space.
+//
+// Missing channels are treated as zero, except when the color already is
+// in the target space. Converting to a space with a hue channel makes the
+// hue missing when the color is achromatic. Keyword colors are returned
+// unchanged.
+func (c Color) Convert(to Space) Color {
+	if c.IsKeyword() || c.Space == to {
+		return c
+	}
+
+	ch := [3]float64{orZero(c.Channels[0]), orZero(c.Channels[1]), orZero(c.Channels[2])}
+
+	var out [3]float64
+	if isSRGBFamily(c.Space) && isSRGBFamily(to) {
+		// Avoid the round trip through XYZ between the sRGB based
+		// spaces, which keeps 8-bit values exact.
+		out = fromSRGB(to, toSRGB(c.Space, ch))
+	} else {
+		out = fromXYZD65(to, toXYZD65(c.Space, ch))
+	}
+
+	if i := to.hueIndex(); i >= 0 && isAchromatic(to, out) {
+		out[i] = math.NaN()
+	}
+
+	return Color{
+		Space:    to,
+		Channels: out,
+		Alpha:    c.Alpha,
+		legacy:   c.legacy && isSRGBFamily(to),
+	}
+}
+
+func isSRGBFamily(s Space) bool {
+	return s == SRGB || s == HSL || s == HWB
+}
+
+// isAchromatic reports whether the hue of a color in a polar space is
+// powerless.
+func isAchromatic(s Space, ch [3]float64) bool {
+	switch s {
+	case HSL:
+		return math.Abs(ch[1]) < 1e-5 || ch[2] <= 1e-5 || ch[2] >= 100-1e-5
+	case HWB:
+		return ch[1]+ch[2] >= 100-1e-5
+	case LCH:
+		return ch[1] < 0.0015
+	case OKLCH:
+		return ch[1] < 0.000004
+	}
+	return false
+}
+
+func toSRGB(s Space, ch [3]float64) [3]float64 {
+	switch s {
+	case HSL:
+		return hslToSRGB(ch)
+	case HWB:
+		return hwbToSRGB(ch)
+	}
+	return ch
+}
+
+func fromSRGB(s Space, rgb [3]float64) [3]float64 {
+	switch s {
+	case HSL:
+		return srgbToHSL(rgb)
+	case HWB:
+		return srgbToHWB(rgb)
+	}
+	return rgb
+}
+
+// toXYZD65 converts channels in the given space to CIE XYZ relative to D65.
+func toXYZD65(s Space, ch [3]float64) [3]float64 {
+	switch s {
+	case SRGB:
+		return linSRGBToXYZ.mul(mapChannels(ch, linSRGB))
+	case SRGBLinear:
+		return linSRGBToXYZ.mul(ch)
+	case DisplayP3:
+		return linP3ToXYZ.mul(mapChannels(ch, linSRGB))
+	case A98RGB:
+		return linA98ToXYZ.mul(mapChannels(ch, linA98))
+	case ProPhotoRGB:
+		xyz := linProPhotoToXYZD50.mul(mapChannels(ch, linProPhoto))
+		return d50ToD65.mul(xyz)
+	case Rec2020:
+		return linRec2020ToXYZ.mul(mapChannels(ch, linRec2020))
+	case XYZD50:
+		return d50ToD65.mul(ch)
+	case XYZD65:
+		return ch
+	case HSL, HWB:
+		return toXYZD65(SRGB, toSRGB(s, ch))
+	case Lab:
+		return d50ToD65.mul(labToXYZD50(ch))
+	case LCH:
+		return toXYZD65(Lab, polarToRect(ch))
+	case OKLab:
+		return okLabToXYZ(ch)
+	case OKLCH:
+		return okLabToXYZ(polarToRect(ch))
+	}
+	return ch
+}
+
+// fromXYZD65 converts CIE XYZ relative to D65 to channels in the given
+// space.
+func fromXYZD65(s Space, xyz [3]float64) [3]float64 {
+	switch s {
+	case SRGB:
+		return mapChannels(xyzToLinSRGB.mul(xyz), gamSRGB)
+	case SRGBLinear:
+		return xyzToLinSRGB.mul(xyz)
+	case DisplayP3:
+		return mapChannels(xyzToLinP3.mul(xyz), gamSRGB)
+	case A98RGB:
+		return mapChannels(xyzToLinA98.mul(xyz), gamA98)
+	case ProPhotoRGB:
+		return mapChannels(xyzD50ToLinProPhoto.mul(d65ToD50.mul(xyz)), gamProPhoto)
+	case Rec2020:
+		return mapChannels(xyzToLinRec2020.mul(xyz), gamRec2020)
+	case XYZD50:
+		return d65ToD50.mul(xyz)
+	case XYZD65:
+		return xyz
+	case HSL, HWB:
+		return fromSRGB(s, fromXYZD65(SRGB, xyz))
+	case Lab:
+		return xyzD50ToLab(d65ToD50.mul(xyz))
+	case LCH:
+		return rectToPolar(fromXYZD65(Lab, xyz))
+	case OKLab:
+		return xyzToOKLab(xyz)
+	case OKLCH:
+		return rectToPolar(xyzToOKLab(xyz))
+	}
+	return xyz
+}
+
+func mapChannels(ch [3]float64, f func(float64) float64) [3]float64 {
+	return [3]float64{f(ch[0]), f(ch[1]), f(ch[2])}
+}
+
+// ===== Transfer functions =====
+
+func linSRGB(v float64) float64 {
+	abs := math.Abs(v)
+	if abs <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Copysign(math.Pow((abs+0.055)/1.055, 2.4), v)
+}
+
+func gamSRGB(v float64) float64 {
+	abs := math.Abs(v)
+	if abs > 0.0031308 {
+		return math.Copysign(1.055*math.Pow(abs, 1/2.4)-0.055, v)
+	}
+	return 12.92 * v
+}
+
+func linA98(v float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), 563.0/256.0), v)
+}
+
+func gamA98(v float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), 256.0/563.0), v)
+}
+
+func linProPhoto(v float64) float64 {
+	abs := math.Abs(v)
+	if abs <= 16.0/512.0 {
+		return v / 16
+	}
+	return math.Copysign(math.Pow(abs, 1.8), v)
+}
+
+func gamProPhoto(v float64) float64 {
+	abs := math.Abs(v)
+	if abs >= 1.0/512.0 {
+		return math.Copysign(math.Pow(abs, 1/1.8), v)
+	}
+	return 16 * v
+}
+
+const (
+	rec2020Alpha = 1.09929682680944
+	rec2020Beta  = 0.018053968510807
+)
+
+func linRec2020(v float64) float64 {
+	abs := math.Abs(v)
+	if abs < rec2020Beta*4.5 {
+		return v / 4.5
+	}
+	return math.Copysign(math.Pow((abs+rec2020Alpha-1)/rec2020Alpha, 1/0.45), v)
+}
+
+func gamRec2020(v float64) float64 {
+	abs := math.Abs(v)
+	if abs > rec2020Beta {
+		return math.Copysign(rec2020Alpha*math.Pow(abs, 0.45)-(rec2020Alpha-1), v)
+	}
+	return 4.5 * v
+}
+
+// ===== Lab and OKLab =====
+
+const (
+	labEpsilon = 216.0 / 24389.0
+	labKappa   = 24389.0 / 27.0
+)
+
+func xyzD50ToLab(xyz [3]float64) [3]float64 {
+	var f [3]float64
+	for i := range xyz {
+		v := xyz[i] / d50White[i]
+		if v > labEpsilon {
+			f[i] = math.Cbrt(v)
+		} else {
+			f[i] = (labKappa*v + 16) / 116
+		}
+	}
+	return [3]float64{116*f[1] - 16, 500 * (f[0] - f[1]), 200 * (f[1] - f[2])}
+}
+
+func labToXYZD50(lab [3]float64) [3]float64 {
+	f1 := (lab[0] + 16) / 116
+	f0 := lab[1]/500 + f1
+	f2 := f1 - lab[2]/200
+
+	var xyz [3]float64
+	if f0*f0*f0 > labEpsilon {
+		xyz[0] = f0 * f0 * f0
+	} else {
+		xyz[0] = (116*f0 - 16) / labKappa
+	}
+	if lab[0] > labKappa*labEpsilon {
+		xyz[1] = f1 * f1 * f1
+	} else {
+		xyz[1] = lab[0] / labKappa
+	}
+	if f2*f2*f2 > labEpsilon {
+		xyz[2] = f2 * f2 * f2
+	} else {
+		xyz[2] = (116*f2 - 16) / labKappa
+	}
+
+	for i := range xyz {
+		xyz[i] *= d50White[i]
+	}
+	return xyz
+}
+
+func xyzToOKLab(xyz [3]float64) [3]float64 {
+	return lmsToOKLab.mul(mapChannels(xyzToLMS.mul(xyz), math.Cbrt))
+}
+
+func okLabToXYZ(lab [3]float64) [3]float64 {
+	lms := okLabToLMS.mul(lab)
+	return lmsToXYZ.mul(mapChannels(lms, func(v float64) float64 { return v * v * v }))
+}
+
+func rectToPolar(lab [3]float64) [3]float64 {
+	hue := math.Atan2(lab[2], lab[1]) * 180 / math.Pi
+	return [3]float64{lab[0], math.Sqrt(lab[1]*lab[1] + lab[2]*lab[2]), normalizeHue(hue)}
+}
+
+func polarToRect(lch [3]float64) [3]float64 {
+	hue := lch[2] * math.Pi / 180
+	return [3]float64{lch[0], lch[1] * math.Cos(hue), lch[1] * math.Sin(hue)}
+}
+
+// normalizeHue maps a hue angle in degrees to [0, 360).
+func normalizeHue(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// ===== HSL and HWB =====
+
+func hslToSRGB(hsl [3]float64) [3]float64 {
+	h := normalizeHue(hsl[0])
+	s := hsl[1] / 100
+	l := hsl[2] / 100
+
+	f := func(n float64) float64 {
+		k := math.Mod(n+h/30, 12)
+		a := s * math.Min(l, 1-l)
+		return l - a*math.Max(-1, math.Min(math.Min(k-3, 9-k), 1))
+	}
+	return [3]float64{f(0), f(8), f(4)}
+}
+
+func srgbToHSL(rgb [3]float64) [3]float64 {
+	max := math.Max(rgb[0], math.Max(rgb[1], rgb[2]))
+	min := math.Min(rgb[0], math.Min(rgb[1], rgb[2]))
+	d := max - min
+	l := (min + max) / 2
+
+	var h, s float64
+	if d != 0 {
+		if l != 0 && l != 1 {
+			s = (max - l) / math.Min(l, 1-l)
+		}
+		switch max {
+		case rgb[0]:
+			h = (rgb[1]-rgb[2])/d + 6
+			if rgb[1] >= rgb[2] {
+				h -= 6
+			}
+		case rgb[1]:
+			h = (rgb[2]-rgb[0])/d + 2
+		default:
+			h = (rgb[0]-rgb[1])/d + 4
+		}
+		h *= 60
+	}
+
+	// Very out of gamut colors can produce negative saturation.
+	if s < 0 {
+		h += 180
+		s = -s
+	}
+
+	return [3]float64{normalizeHue(h), s * 100, l * 100}
+}
+
+func hwbToSRGB(hwb [3]float64) [3]float64 {
+	w := hwb[1] / 100
+	b := hwb[2] / 100
+	if w+b >= 1 {
+		gray := w / (w + b)
+		return [3]float64{gray, gray, gray}
+	}
+
+	rgb := hslToSRGB([3]float64{hwb[0], 100, 50})
+	for i := range rgb {
+		rgb[i] = rgb[i]*(1-w-b) + w
+	}
+	return rgb
+}
+
+func srgbToHWB(rgb [3]float64) [3]float64 {
+	hsl := srgbToHSL(rgb)
+	w := math.Min(rgb[0], math.Min(rgb[1], rgb[2]))
+	b := 1 - math.Max(rgb[0], math.Max(rgb[1], rgb[2]))
+	return [3]float64{hsl[0], w * 100, b * 100}
+}
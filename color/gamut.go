@@ -0,0 +1,124 @@
+package color
+
+import (
+	"math"
+)
+
+// gamutEpsilon is the tolerance used when checking channels against the
+// bounds of an RGB gamut.
+const gamutEpsilon = 1e-6
+
+// gamutSpace returns the RGB space whose gamut bounds colors in s, and
+// whether s is bounded at all.
+func gamutSpace(s Space) (Space, bool) {
+	if s.isRGB() {
+		return s, true
+	}
+	if s == HSL || s == HWB {
+		return SRGB, true
+	}
+	return 0, false
+}
+
+// InGamut reports whether the color can be represented in the given color
+// space without clipping. Spaces without gamut limits, such as lab or
+// xyz, contain every color.
+func (c Color) InGamut(s Space) bool {
+	if c.IsKeyword() {
+		return true
+	}
+	bound, ok := gamutSpace(s)
+	if !ok {
+		return true
+	}
+	ch := c.Convert(bound).Channels
+	for _, v := range ch {
+		v = orZero(v)
+		if v < -gamutEpsilon || v > 1+gamutEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// ToGamut converts the color to the given color space, mapping it into
+// the gamut of that space with the CSS gamut mapping algorithm, which
+// reduces the OKLCH chroma until the color is within a just noticeable
+// difference of its clipped version.
+//
+// https://www.w3.org/TR/css-color-4/#css-gamut-mapping
+func (c Color) ToGamut(s Space) Color {
+	if c.IsKeyword() {
+		return c
+	}
+	bound, ok := gamutSpace(s)
+	if !ok || c.InGamut(s) {
+		return c.Convert(s)
+	}
+
+	const (
+		jnd     = 0.02
+		epsilon = 0.0001
+	)
+
+	origin := c.Convert(OKLCH)
+	origin.Channels[0] = orZero(origin.Channels[0])
+	origin.Channels[1] = orZero(origin.Channels[1])
+
+	if origin.Channels[0] >= 1 {
+		return Color{Space: SRGB, Channels: [3]float64{1, 1, 1}, Alpha: c.Alpha}.Convert(s)
+	}
+	if origin.Channels[0] <= 0 {
+		return Color{Space: SRGB, Channels: [3]float64{0, 0, 0}, Alpha: c.Alpha}.Convert(s)
+	}
+
+	current := origin
+	clipped := clip(current.Convert(bound))
+	if deltaEOK(clipped, current) < jnd {
+		return clipped.Convert(s)
+	}
+
+	min, max := 0.0, origin.Channels[1]
+	minInGamut := true
+	for max-min > epsilon {
+		chroma := (min + max) / 2
+		current.Channels[1] = chroma
+
+		if minInGamut && current.InGamut(bound) {
+			min = chroma
+			continue
+		}
+
+		clipped = clip(current.Convert(bound))
+		e := deltaEOK(clipped, current)
+		if e < jnd {
+			if jnd-e < epsilon {
+				break
+			}
+			minInGamut = false
+			min = chroma
+		} else {
+			max = chroma
+		}
+	}
+
+	return clipped.Convert(s)
+}
+
+// clip clamps the channels of a color in an RGB space to [0, 1].
+func clip(c Color) Color {
+	for i, v := range c.Channels {
+		c.Channels[i] = math.Max(0, math.Min(1, orZero(v)))
+	}
+	return c
+}
+
+// deltaEOK returns the Euclidean distance of two colors in OKLab.
+func deltaEOK(a, b Color) float64 {
+	x := a.Convert(OKLab).Channels
+	y := b.Convert(OKLab).Channels
+	dl := orZero(x[0]) - orZero(y[0])
+	da := orZero(x[1]) - orZero(y[1])
+	db := orZero(x[2]) - orZero(y[2])
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
@@ -0,0 +1,108 @@
+package color
+
+import (
+	"math"
+)
+
+// Mix interpolates between two colors in the given color space, as
+// color-mix() does with the shorter hue interpolation method. The weight t
+// is the proportion of b in the result, in the range [0, 1]. Keyword
+// colors cannot be mixed, a is returned unchanged if either color is one.
+//
+// https://www.w3.org/TR/css-color-5/#color-mix
+func Mix(a, b Color, t float64, space Space) Color {
+	if a.IsKeyword() || b.IsKeyword() {
+		return a
+	}
+	return mix(a, b, t, space, hueShorter)
+}
+
+func mix(a, b Color, t float64, space Space, method hueMethod) Color {
+	a = a.Convert(space)
+	b = b.Convert(space)
+
+	// Missing components take the value of the other color.
+	for i := 0; i < 3; i++ {
+		if isNone(a.Channels[i]) {
+			a.Channels[i] = b.Channels[i]
+		} else if isNone(b.Channels[i]) {
+			b.Channels[i] = a.Channels[i]
+		}
+	}
+	if isNone(a.Alpha) {
+		a.Alpha = b.Alpha
+	} else if isNone(b.Alpha) {
+		b.Alpha = a.Alpha
+	}
+
+	alphaA, alphaB := a.Alpha, b.Alpha
+	if isNone(alphaA) {
+		alphaA, alphaB = 1, 1
+	}
+
+	hue := space.hueIndex()
+
+	// Interpolate with premultiplied alpha.
+	// https://www.w3.org/TR/css-color-4/#interpolation-alpha
+	for i := 0; i < 3; i++ {
+		if i == hue {
+			continue
+		}
+		a.Channels[i] *= alphaA
+		b.Channels[i] *= alphaB
+	}
+	if hue >= 0 && !isNone(a.Channels[hue]) {
+		a.Channels[hue], b.Channels[hue] = fixupHues(a.Channels[hue], b.Channels[hue], method)
+	}
+
+	result := Color{Space: space, Alpha: alphaA*(1-t) + alphaB*t}
+	for i := 0; i < 3; i++ {
+		v := a.Channels[i]*(1-t) + b.Channels[i]*t
+		if i != hue && result.Alpha != 0 {
+			v /= result.Alpha
+		}
+		result.Channels[i] = v
+	}
+	if hue >= 0 && !isNone(result.Channels[hue]) {
+		result.Channels[hue] = normalizeHue(result.Channels[hue])
+	}
+	if isNone(a.Alpha) {
+		result.Alpha = math.NaN()
+	}
+
+	return result
+}
+
+// fixupHues adjusts two hue angles for interpolation with the given method.
+//
+// https://www.w3.org/TR/css-color-4/#hue-interpolation
+func fixupHues(a, b float64, method hueMethod) (float64, float64) {
+	a = normalizeHue(a)
+	b = normalizeHue(b)
+	d := b - a
+
+	switch method {
+	case hueShorter:
+		if d > 180 {
+			a += 360
+		} else if d < -180 {
+			b += 360
+		}
+	case hueLonger:
+		if d > 0 && d < 180 {
+			a += 360
+		} else if d > -180 && d <= 0 {
+			b += 360
+		}
+	case hueIncreasing:
+		if d < 0 {
+			b += 360
+		}
+	case hueDecreasing:
+		if d > 0 {
+			a += 360
+		}
+	}
+
+	return a, b
+}
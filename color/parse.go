@@ -0,0 +1,751 @@
+package color
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+var (
+	// ErrInvalid is returned when the tokens do not form a valid color.
+	ErrInvalid = errors.New("color: invalid color")
+
+	// ErrUnsupported is returned for valid colors whose value depends on
+	// information not available to the parser, such as math functions or
+	// color-mix() with keyword colors.
+	ErrUnsupported = errors.New("color: unsupported color value")
+)
+
+// ParseString parses a color from a CSS source string.
+func ParseString(s string) (Color, error) {
+	lexer := csslexer.NewLexer(csslexer.NewInput(s))
+
+	var tokens []csslexer.Token
+	for {
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+
+	return Parse(tokens)
+}
+
+// Parse parses a color from the tokens of a single component value, such as
+// a HashToken, an IdentToken or a FunctionToken followed by its arguments
+// and the closing parenthesis. Whitespace and comments around the value are
+// ignored.
+func Parse(tokens []csslexer.Token) (Color, error) {
+	values, err := parseComponentValues(tokens)
+	if err != nil {
+		return Color{}, err
+	}
+	if len(values) != 1 {
+		return Color{}, ErrInvalid
+	}
+	return parseColor(values[0])
+}
+
+// ===== Component values =====
+
+// componentValue is a preserved token, or a function together with its
+// arguments.
+type componentValue struct {
+	token csslexer.Token
+	args  []componentValue // arguments of a function, or contents of a block
+}
+
+// parseComponentValues groups tokens into component values, dropping
+// whitespace and comments, which are never significant in color syntax.
+func parseComponentValues(tokens []csslexer.Token) ([]componentValue, error) {
+	values, rest, err := consumeComponentValues(tokens, csslexer.EOFToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalid
+	}
+	return values, nil
+}
+
+func consumeComponentValues(tokens []csslexer.Token, until csslexer.TokenType) ([]componentValue, []csslexer.Token, error) {
+	var values []componentValue
+
+	for len(tokens) > 0 {
+		token := tokens[0]
+		tokens = tokens[1:]
+
+		switch token.Type {
+		case csslexer.WhitespaceToken, csslexer.CommentToken:
+			continue
+
+		case until:
+			return values, tokens, nil
+
+		case csslexer.EOFToken:
+			return values, nil, nil
+
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken, csslexer.RightBraceToken:
+			return nil, nil, ErrInvalid
+
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken:
+			args, rest, err := consumeComponentValues(tokens, csslexer.RightParenthesisToken)
+			if err != nil {
+				return nil, nil, err
+			}
+			values = append(values, componentValue{token: token, args: args})
+			tokens = rest
+
+		case csslexer.LeftBracketToken:
+			args, rest, err := consumeComponentValues(tokens, csslexer.RightBracketToken)
+			if err != nil {
+				return nil, nil, err
+			}
+			values = append(values, componentValue{token: token, args: args})
+			tokens = rest
+
+		case csslexer.LeftBraceToken:
+			args, rest, err := consumeComponentValues(tokens, csslexer.RightBraceToken)
+			if err != nil {
+				return nil, nil, err
+			}
+			values = append(values, componentValue{token: token, args: args})
+			tokens = rest
+
+		default:
+			values = append(values, componentValue{token: token})
+		}
+	}
+
+	return values, nil, nil
+}
+
+func (cv componentValue) isIdent(name string) bool {
+	return cv.token.Type == csslexer.IdentToken && strings.EqualFold(cv.token.Value, name)
+}
+
+func (cv componentValue) isComma() bool {
+	return cv.token.Type == csslexer.CommaToken
+}
+
+func (cv componentValue) isSlash() bool {
+	return cv.token.Type == csslexer.DelimiterToken && cv.token.Value == "/"
+}
+
+// splitCommas splits arguments at top-level commas.
+func splitCommas(args []componentValue) [][]componentValue {
+	groups := [][]componentValue{nil}
+	for _, arg := range args {
+		if arg.isComma() {
+			groups = append(groups, nil)
+			continue
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], arg)
+	}
+	return groups
+}
+
+// ===== Colors =====
+
+func parseColor(cv componentValue) (Color, error) {
+	switch cv.token.Type {
+	case csslexer.HashToken:
+		return parseHex(cv.token.Value)
+
+	case csslexer.IdentToken:
+		return parseKeyword(strings.ToLower(cv.token.Value))
+
+	case csslexer.FunctionToken:
+		return parseFunction(strings.ToLower(cv.token.Value), cv.args)
+	}
+
+	return Color{}, ErrInvalid
+}
+
+// https://www.w3.org/TR/css-color-4/#hex-notation
+func parseHex(hex string) (Color, error) {
+	var digits [8]uint8
+	if len(hex) != 3 && len(hex) != 4 && len(hex) != 6 && len(hex) != 8 {
+		return Color{}, ErrInvalid
+	}
+	for i := 0; i < len(hex); i++ {
+		v, ok := hexValue(hex[i])
+		if !ok {
+			return Color{}, ErrInvalid
+		}
+		digits[i] = v
+	}
+
+	switch len(hex) {
+	case 3:
+		return RGBA(digits[0]*17, digits[1]*17, digits[2]*17, 255), nil
+	case 4:
+		return RGBA(digits[0]*17, digits[1]*17, digits[2]*17, digits[3]*17), nil
+	case 6:
+		return RGBA(digits[0]<<4|digits[1], digits[2]<<4|digits[3], digits[4]<<4|digits[5], 255), nil
+	default:
+		return RGBA(digits[0]<<4|digits[1], digits[2]<<4|digits[3], digits[4]<<4|digits[5], digits[6]<<4|digits[7]), nil
+	}
+}
+
+func hexValue(c byte) (uint8, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func parseKeyword(name string) (Color, error) {
+	if name == "transparent" {
+		return RGBA(0, 0, 0, 0), nil
+	}
+	if name == "currentcolor" || systemColors[name] {
+		return Color{Keyword: name}, nil
+	}
+	if rgb, ok := namedColors[name]; ok {
+		return RGBA(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb), 255), nil
+	}
+	return Color{}, ErrInvalid
+}
+
+func parseFunction(name string, args []componentValue) (Color, error) {
+	switch name {
+	case "rgb", "rgba":
+		if hasComma(args) {
+			return parseLegacy(SRGB, args)
+		}
+		return parseModern(SRGB, args)
+
+	case "hsl", "hsla":
+		if hasComma(args) {
+			return parseLegacy(HSL, args)
+		}
+		return parseModern(HSL, args)
+
+	case "hwb":
+		return parseModern(HWB, args)
+
+	case "lab":
+		return parseModern(Lab, args)
+
+	case "lch":
+		return parseModern(LCH, args)
+
+	case "oklab":
+		return parseModern(OKLab, args)
+
+	case "oklch":
+		return parseModern(OKLCH, args)
+
+	case "color":
+		return parseColorFunction(args)
+
+	case "color-mix":
+		return parseColorMix(args)
+	}
+
+	return Color{}, ErrInvalid
+}
+
+func hasComma(args []componentValue) bool {
+	for _, arg := range args {
+		if arg.isComma() {
+			return true
+		}
+	}
+	return false
+}
+
+// ===== Channels =====
+
+// channel describes how a channel of a color function is written.
+type channel struct {
+	keyword     string  // name of the channel in relative color syntax
+	numberScale float64 // multiplier from a <number> to the stored value
+	percentRef  float64 // stored value of 100%, zero if not allowed
+	hue         bool    // whether the channel is a <hue>
+}
+
+var (
+	rgbChannels = [3]channel{
+		{keyword: "r", numberScale: 1.0 / 255, percentRef: 1},
+		{keyword: "g", numberScale: 1.0 / 255, percentRef: 1},
+		{keyword: "b", numberScale: 1.0 / 255, percentRef: 1},
+	}
+	hslChannels = [3]channel{
+		{keyword: "h", hue: true},
+		{keyword: "s", numberScale: 1, percentRef: 100},
+		{keyword: "l", numberScale: 1, percentRef: 100},
+	}
+	hwbChannels = [3]channel{
+		{keyword: "h", hue: true},
+		{keyword: "w", numberScale: 1, percentRef: 100},
+		{keyword: "b", numberScale: 1, percentRef: 100},
+	}
+	labChannels = [3]channel{
+		{keyword: "l", numberScale: 1, percentRef: 100},
+		{keyword: "a", numberScale: 1, percentRef: 125},
+		{keyword: "b", numberScale: 1, percentRef: 125},
+	}
+	lchChannels = [3]channel{
+		{keyword: "l", numberScale: 1, percentRef: 100},
+		{keyword: "c", numberScale: 1, percentRef: 150},
+		{keyword: "h", hue: true},
+	}
+	okLabChannels = [3]channel{
+		{keyword: "l", numberScale: 1, percentRef: 1},
+		{keyword: "a", numberScale: 1, percentRef: 0.4},
+		{keyword: "b", numberScale: 1, percentRef: 0.4},
+	}
+	okLCHChannels = [3]channel{
+		{keyword: "l", numberScale: 1, percentRef: 1},
+		{keyword: "c", numberScale: 1, percentRef: 0.4},
+		{keyword: "h", hue: true},
+	}
+	predefinedRGBChannels = [3]channel{
+		{keyword: "r", numberScale: 1, percentRef: 1},
+		{keyword: "g", numberScale: 1, percentRef: 1},
+		{keyword: "b", numberScale: 1, percentRef: 1},
+	}
+	xyzChannels = [3]channel{
+		{keyword: "x", numberScale: 1, percentRef: 1},
+		{keyword: "y", numberScale: 1, percentRef: 1},
+		{keyword: "z", numberScale: 1, percentRef: 1},
+	}
+	alphaChannel = channel{keyword: "alpha", numberScale: 1, percentRef: 1}
+)
+
+// channelsOf returns the channels of the color function for a space. The
+// rgb() function differs from color(srgb) in taking numbers in [0, 255].
+func channelsOf(s Space, colorFunction bool) [3]channel {
+	switch s {
+	case SRGB:
+		if colorFunction {
+			return predefinedRGBChannels
+		}
+		return rgbChannels
+	case HSL:
+		return hslChannels
+	case HWB:
+		return hwbChannels
+	case Lab:
+		return labChannels
+	case LCH:
+		return lchChannels
+	case OKLab:
+		return okLabChannels
+	case OKLCH:
+		return okLCHChannels
+	case XYZD50, XYZD65:
+		return xyzChannels
+	}
+	return predefinedRGBChannels
+}
+
+// parseChannel parses a single channel value. When origin is not nil,
+// channel keywords of the relative color syntax resolve against it.
+func parseChannel(cv componentValue, ch channel, origin *Color, keywords [3]channel) (float64, error) {
+	switch cv.token.Type {
+	case csslexer.NumberToken:
+		v, err := strconv.ParseFloat(cv.token.Value, 64)
+		if err != nil {
+			return 0, ErrInvalid
+		}
+		if ch.hue {
+			return v, nil
+		}
+		return v * ch.numberScale, nil
+
+	case csslexer.PercentageToken:
+		if ch.hue || ch.percentRef == 0 {
+			return 0, ErrInvalid
+		}
+		v, err := parsePercentage(cv.token)
+		if err != nil {
+			return 0, err
+		}
+		return v / 100 * ch.percentRef, nil
+
+	case csslexer.DimensionToken:
+		if !ch.hue {
+			return 0, ErrInvalid
+		}
+		return parseAngle(cv.token.Value)
+
+	case csslexer.IdentToken:
+		if cv.isIdent("none") {
+			return math.NaN(), nil
+		}
+		if origin != nil {
+			if cv.isIdent(alphaChannel.keyword) {
+				return origin.Alpha, nil
+			}
+			for i, k := range keywords {
+				if cv.isIdent(k.keyword) {
+					// The keyword resolves to the origin channel expressed
+					// as a number, which is then read as this channel.
+					v := origin.Channels[i]
+					if !k.hue && !ch.hue {
+						v = v / k.numberScale * ch.numberScale
+					}
+					return v, nil
+				}
+			}
+		}
+
+	case csslexer.FunctionToken:
+		return 0, ErrUnsupported
+	}
+
+	return 0, ErrInvalid
+}
+
+// parsePercentage parses the number of a PercentageToken, whose value
+// includes the percent sign.
+func parsePercentage(token csslexer.Token) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(token.Value, "%"), 64)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	return v, nil
+}
+
+// parseAngle parses the value of a DimensionToken holding an <angle> and
+// returns it in degrees.
+func parseAngle(dimension string) (float64, error) {
+	number, unit := tokenutil.SplitNumeric(dimension)
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+
+	switch strings.ToLower(unit) {
+	case "deg":
+		return v, nil
+	case "grad":
+		return v * 360 / 400, nil
+	case "rad":
+		return v * 180 / math.Pi, nil
+	case "turn":
+		return v * 360, nil
+	}
+	return 0, ErrInvalid
+}
+
+// ===== Color functions =====
+
+// parseLegacy parses the comma separated syntax of rgb() and hsl().
+//
+// https://www.w3.org/TR/css-color-4/#typedef-legacy-rgb-syntax
+// https://www.w3.org/TR/css-color-4/#typedef-legacy-hsl-syntax
+func parseLegacy(space Space, args []componentValue) (Color, error) {
+	groups := splitCommas(args)
+	if len(groups) != 3 && len(groups) != 4 {
+		return Color{}, ErrInvalid
+	}
+	for _, group := range groups {
+		if len(group) != 1 || group[0].isIdent("none") {
+			return Color{}, ErrInvalid
+		}
+	}
+
+	channels := channelsOf(space, false)
+	color := Color{Space: space, Alpha: 1, legacy: true}
+
+	for i := 0; i < 3; i++ {
+		token := groups[i][0].token
+		switch {
+		case space == SRGB && token.Type != groups[0][0].token.Type:
+			// rgb() takes either all numbers or all percentages.
+			return Color{}, ErrInvalid
+		case space == HSL && i > 0 && token.Type != csslexer.PercentageToken:
+			return Color{}, ErrInvalid
+		}
+
+		v, err := parseChannel(groups[i][0], channels[i], nil, channels)
+		if err != nil {
+			return Color{}, err
+		}
+		color.Channels[i] = v
+	}
+
+	if len(groups) == 4 {
+		v, err := parseChannel(groups[3][0], alphaChannel, nil, channels)
+		if err != nil {
+			return Color{}, err
+		}
+		color.Alpha = v
+	}
+
+	return normalize(color), nil
+}
+
+// parseModern parses the space separated syntax shared by the color
+// functions, including the relative color syntax.
+func parseModern(space Space, args []componentValue) (Color, error) {
+	var origin *Color
+	if len(args) > 0 && args[0].isIdent("from") {
+		if len(args) < 2 {
+			return Color{}, ErrInvalid
+		}
+		o, err := resolveOrigin(args[1], space)
+		if err != nil {
+			return Color{}, err
+		}
+		origin = &o
+		args = args[2:]
+	}
+
+	color, err := parseChannels(space, channelsOf(space, false), args, origin)
+	if err != nil {
+		return Color{}, err
+	}
+	color.legacy = isSRGBFamily(space)
+	return normalize(color), nil
+}
+
+// resolveOrigin parses the origin color of the relative color syntax and
+// converts it to the space of the color function.
+func resolveOrigin(cv componentValue, space Space) (Color, error) {
+	origin, err := parseColor(cv)
+	if err != nil {
+		return Color{}, err
+	}
+	if origin.IsKeyword() {
+		return Color{}, ErrUnsupported
+	}
+	origin = origin.Convert(space)
+	for i, v := range origin.Channels {
+		origin.Channels[i] = orZero(v)
+	}
+	origin.Alpha = orZero(origin.Alpha)
+	return origin, nil
+}
+
+// parseChannels parses three channels optionally followed by a slash and
+// an alpha value.
+func parseChannels(space Space, channels [3]channel, args []componentValue, origin *Color) (Color, error) {
+	if len(args) != 3 && len(args) != 5 {
+		return Color{}, ErrInvalid
+	}
+	if len(args) == 5 && !args[3].isSlash() {
+		return Color{}, ErrInvalid
+	}
+
+	color := Color{Space: space, Alpha: 1}
+	if origin != nil {
+		color.Alpha = origin.Alpha
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := parseChannel(args[i], channels[i], origin, channels)
+		if err != nil {
+			return Color{}, err
+		}
+		color.Channels[i] = v
+	}
+
+	if len(args) == 5 {
+		v, err := parseChannel(args[4], alphaChannel, origin, channels)
+		if err != nil {
+			return Color{}, err
+		}
+		color.Alpha = v
+	}
+
+	return color, nil
+}
+
+// https://www.w3.org/TR/css-color-4/#color-function
+func parseColorFunction(args []componentValue) (Color, error) {
+	var origin *Color
+	var originArg componentValue
+	if len(args) > 0 && args[0].isIdent("from") {
+		if len(args) < 2 {
+			return Color{}, ErrInvalid
+		}
+		originArg = args[1]
+		origin = &Color{}
+		args = args[2:]
+	}
+
+	if len(args) == 0 || args[0].token.Type != csslexer.IdentToken {
+		return Color{}, ErrInvalid
+	}
+	space, ok := spaceByName(strings.ToLower(args[0].token.Value))
+	if !ok || !space.isPredefined() {
+		return Color{}, ErrInvalid
+	}
+
+	if origin != nil {
+		o, err := resolveOrigin(originArg, space)
+		if err != nil {
+			return Color{}, err
+		}
+		origin = &o
+	}
+
+	color, err := parseChannels(space, channelsOf(space, true), args[1:], origin)
+	if err != nil {
+		return Color{}, err
+	}
+	return normalize(color), nil
+}
+
+// hueMethod is a hue interpolation method of color-mix().
+type hueMethod int
+
+const (
+	hueShorter hueMethod = iota
+	hueLonger
+	hueIncreasing
+	hueDecreasing
+)
+
+// https://www.w3.org/TR/css-color-5/#color-mix
+func parseColorMix(args []componentValue) (Color, error) {
+	groups := splitCommas(args)
+	if len(groups) != 3 {
+		return Color{}, ErrInvalid
+	}
+
+	// Interpolation method: in <space> [<hue-method> hue]?
+	method := groups[0]
+	if len(method) < 2 || !method[0].isIdent("in") || method[1].token.Type != csslexer.IdentToken {
+		return Color{}, ErrInvalid
+	}
+	space, ok := spaceByName(strings.ToLower(method[1].token.Value))
+	if !ok {
+		return Color{}, ErrInvalid
+	}
+	hue := hueShorter
+	switch len(method) {
+	case 2:
+	case 4:
+		if space.hueIndex() < 0 || !method[3].isIdent("hue") {
+			return Color{}, ErrInvalid
+		}
+		switch {
+		case method[2].isIdent("shorter"):
+			hue = hueShorter
+		case method[2].isIdent("longer"):
+			hue = hueLonger
+		case method[2].isIdent("increasing"):
+			hue = hueIncreasing
+		case method[2].isIdent("decreasing"):
+			hue = hueDecreasing
+		default:
+			return Color{}, ErrInvalid
+		}
+	default:
+		return Color{}, ErrInvalid
+	}
+
+	var colors [2]Color
+	var percents [2]float64
+	var hasColor, hasPercent [2]bool
+	for i, group := range groups[1:] {
+		for _, cv := range group {
+			if cv.token.Type == csslexer.PercentageToken && !hasPercent[i] {
+				v, err := parsePercentage(cv.token)
+				if err != nil || v < 0 || v > 100 {
+					return Color{}, ErrInvalid
+				}
+				percents[i] = v
+				hasPercent[i] = true
+				continue
+			}
+			if hasColor[i] {
+				return Color{}, ErrInvalid
+			}
+			color, err := parseColor(cv)
+			if err != nil {
+				return Color{}, err
+			}
+			colors[i] = color
+			hasColor[i] = true
+		}
+		if !hasColor[i] {
+			return Color{}, ErrInvalid
+		}
+	}
+
+	switch {
+	case !hasPercent[0] && !hasPercent[1]:
+		percents = [2]float64{50, 50}
+	case !hasPercent[1]:
+		percents[1] = 100 - percents[0]
+	case !hasPercent[0]:
+		percents[0] = 100 - percents[1]
+	}
+
+	sum := percents[0] + percents[1]
+	if sum == 0 {
+		return Color{}, ErrInvalid
+	}
+	if colors[0].IsKeyword() || colors[1].IsKeyword() {
+		return Color{}, ErrUnsupported
+	}
+
+	color := mix(colors[0], colors[1], percents[1]/sum, space, hue)
+	if sum < 100 {
+		color.Alpha *= sum / 100
+	}
+	return color, nil
+}
+
+// normalize clamps the channels of a parsed color to their valid ranges.
+func normalize(c Color) Color {
+	if !isNone(c.Alpha) {
+		c.Alpha = math.Max(0, math.Min(1, c.Alpha))
+	}
+
+	clampMin := func(i int, min float64) {
+		if !isNone(c.Channels[i]) {
+			c.Channels[i] = math.Max(min, c.Channels[i])
+		}
+	}
+	clampRange := func(i int, min, max float64) {
+		if !isNone(c.Channels[i]) {
+			c.Channels[i] = math.Max(min, math.Min(max, c.Channels[i]))
+		}
+	}
+
+	switch c.Space {
+	case SRGB:
+		if c.legacy {
+			for i := range c.Channels {
+				clampRange(i, 0, 1)
+			}
+		}
+	case HSL:
+		clampMin(1, 0)
+		clampRange(2, 0, 100)
+	case Lab:
+		clampRange(0, 0, 100)
+	case LCH:
+		clampRange(0, 0, 100)
+		clampMin(1, 0)
+	case OKLab:
+		clampRange(0, 0, 1)
+	case OKLCH:
+		clampRange(0, 0, 1)
+		clampMin(1, 0)
+	}
+
+	if i := c.Space.hueIndex(); i >= 0 && !isNone(c.Channels[i]) {
+		c.Channels[i] = normalizeHue(c.Channels[i])
+	}
+
+	return c
+}
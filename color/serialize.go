@@ -0,0 +1,188 @@
+package color
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// String returns the serialization of the color following CSSOM: legacy
+// sRGB colors serialize as rgb() or rgba() with 8-bit channels, and the
+// other colors keep their own color function.
+//
+// https://www.w3.org/TR/css-color-4/#serializing-color-values
+func (c Color) String() string {
+	if c.IsKeyword() {
+		return c.Keyword
+	}
+
+	if c.legacy {
+		r, g, b, a := c.rgba8()
+		var sb strings.Builder
+		if a == 255 {
+			sb.WriteString("rgb(")
+		} else {
+			sb.WriteString("rgba(")
+		}
+		sb.WriteString(strconv.Itoa(int(r)))
+		sb.WriteString(", ")
+		sb.WriteString(strconv.Itoa(int(g)))
+		sb.WriteString(", ")
+		sb.WriteString(strconv.Itoa(int(b)))
+		if a != 255 {
+			sb.WriteString(", ")
+			sb.WriteString(formatAlpha8(a))
+		}
+		sb.WriteByte(')')
+		return sb.String()
+	}
+
+	return c.serializeFunction(formatNumber, " / ")
+}
+
+// Minify returns the shortest serialization that is equivalent to the
+// color. sRGB colors with 8-bit precision become the shortest of hex
+// notation and named colors; other colors keep their color function with
+// numbers written as short as possible.
+func (c Color) Minify() string {
+	if c.IsKeyword() {
+		return c.Keyword
+	}
+
+	if c.legacy || c.isExact8Bit() {
+		return shortestRGBA(c.rgba8())
+	}
+
+	return c.serializeFunction(minifyNumber, "/")
+}
+
+// serializeFunction writes the color using the color function of its
+// space.
+func (c Color) serializeFunction(format func(float64) string, slash string) string {
+	var sb strings.Builder
+
+	if c.Space.isPredefined() {
+		sb.WriteString("color(")
+		sb.WriteString(c.Space.String())
+		sb.WriteByte(' ')
+	} else {
+		sb.WriteString(c.Space.String())
+		sb.WriteByte('(')
+	}
+
+	for i, v := range c.Channels {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(format(v))
+	}
+
+	if isNone(c.Alpha) || c.Alpha != 1 {
+		sb.WriteString(slash)
+		sb.WriteString(format(c.Alpha))
+	}
+
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// isExact8Bit reports whether an sRGB color has channels and alpha that
+// are exactly representable in hex notation.
+func (c Color) isExact8Bit() bool {
+	if c.Space != SRGB {
+		return false
+	}
+	for _, v := range append(c.Channels[:], c.Alpha) {
+		if isNone(v) || v < 0 || v > 1 {
+			return false
+		}
+		if math.Abs(v*255-math.Round(v*255)) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// rgba8 returns the color as 8-bit sRGB channels, clamping it to the sRGB
+// gamut.
+func (c Color) rgba8() (r, g, b, a uint8) {
+	rgb := c.Convert(SRGB).Channels
+	return to8Bit(rgb[0]), to8Bit(rgb[1]), to8Bit(rgb[2]), to8Bit(c.Alpha)
+}
+
+func to8Bit(v float64) uint8 {
+	v = orZero(v)
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// formatAlpha8 formats an 8-bit alpha value with the fewest decimals, up
+// to three, that round trip to the same value.
+func formatAlpha8(a uint8) string {
+	v := math.Round(float64(a)/255*100) / 100
+	if to8Bit(v) != a {
+		v = math.Round(float64(a)/255*1000) / 1000
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatNumber formats a channel value with at most six decimals.
+func formatNumber(v float64) string {
+	if isNone(v) {
+		return "none"
+	}
+	v = math.Round(v*1e6) / 1e6
+	if v == 0 {
+		v = 0 // avoid "-0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// minifyNumber formats a channel value like formatNumber, dropping the
+// leading zero of fractions.
+func minifyNumber(v float64) string {
+	s := formatNumber(v)
+	if strings.HasPrefix(s, "0.") {
+		return s[1:]
+	}
+	if strings.HasPrefix(s, "-0.") {
+		return "-" + s[2:]
+	}
+	return s
+}
+
+const hexDigits = "0123456789abcdef"
+
+// shortestRGBA returns the shortest of the hex notations and the named
+// colors for the given 8-bit channels.
+func shortestRGBA(r, g, b, a uint8) string {
+	channels := []uint8{r, g, b}
+	if a != 255 {
+		channels = append(channels, a)
+	}
+
+	short := true
+	for _, v := range channels {
+		if v>>4 != v&0xf {
+			short = false
+			break
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('#')
+	for _, v := range channels {
+		sb.WriteByte(hexDigits[v>>4])
+		if !short {
+			sb.WriteByte(hexDigits[v&0xf])
+		}
+	}
+	hex := sb.String()
+
+	if a == 255 {
+		if name, ok := shortestNames[uint32(r)<<16|uint32(g)<<8|uint32(b)]; ok && len(name) < len(hex) {
+			return name
+		}
+	}
+
+	return hex
+}
@@ -8,19 +8,14 @@ import (
 
 // https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-comment
 func (l *Lexer) consumeUntilCommentEnd() {
-	for {
-		next := l.r.Peek(0)
-
-		if next == EOF {
-			break
-		}
+	l.r.moveUntilCommentEnd()
 
-		if next == '*' && l.r.Peek(1) == '/' {
-			l.r.Move(2) // consume '*/'
-			return
-		}
-
-		l.r.Move(1) // consume the current rune
+	if l.r.Peek(0) == '*' {
+		l.r.Move(2) // consume '*/'
+		return
+	}
+	if l.r.atSlot() {
+		l.resume = '*'
 	}
 }
 
@@ -61,26 +56,38 @@ func (l *Lexer) consumeEscape() rune {
 }
 
 // https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-name
+//
+// When values are skipped, the name is only built if it contains escapes,
+// and an empty string is returned otherwise.
 func (l *Lexer) consumeName() string {
+	offset := l.r.CurrentOffset()
+	l.r.moveWhileIdent()
+	if !cssutil.TwoCodePointsStartsAValidEscape(l.r.Peek(0), l.r.Peek(1)) {
+		// The name has no escapes, so it is the text consumed.
+		if l.lazy {
+			return ""
+		}
+		return l.intern(l.r.CurrentSuffix(offset))
+	}
+
 	var result strings.Builder
-	result.Grow(32) // Preallocate some space for efficiency
+	result.WriteString(l.r.CurrentSuffixString(offset))
 
 	for {
 		next := l.r.Peek(0)
 
-		if cssutil.IsIdentCodePoint(next) {
+		if isIdentCodePoint(next) {
 			l.r.Move(1)
 			result.WriteRune(next)
 		} else if cssutil.TwoCodePointsStartsAValidEscape(next, l.r.Peek(1)) {
 			l.r.Move(1) // consume the backslash
-			escaped := l.consumeEscape()
-			result.WriteRune(escaped)
+			result.WriteRune(l.consumeEscape())
 		} else {
 			break
 		}
 	}
 
-	return result.String()
+	return l.internString(result.String())
 }
 
 // https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-number
@@ -95,13 +102,13 @@ func (l *Lexer) consumeNumber() string {
 	}
 
 	// consume the integer part of the number
-	l.r.MoveWhilePredicate(cssutil.IsDigit)
+	l.r.moveWhileClass(classDigit)
 
 	// float
 	next = l.r.Peek(0)
 	if next == '.' && cssutil.IsDigit(l.r.Peek(1)) {
 		l.r.Move(1) // consume the '.'
-		l.r.MoveWhilePredicate(cssutil.IsDigit)
+		l.r.moveWhileClass(classDigit)
 	}
 
 	// scientific notation
@@ -111,14 +118,14 @@ func (l *Lexer) consumeNumber() string {
 
 		if cssutil.IsDigit(next_next) {
 			l.r.Move(1) // consume 'e' or 'E'
-			l.r.MoveWhilePredicate(cssutil.IsDigit)
+			l.r.moveWhileClass(classDigit)
 		} else if (next_next == '+' || next_next == '-') && cssutil.IsDigit(l.r.Peek(2)) {
 			l.r.Move(2) // consume 'e' or 'E' and the sign
-			l.r.MoveWhilePredicate(cssutil.IsDigit)
+			l.r.moveWhileClass(classDigit)
 		}
 	}
 
-	return l.r.CurrentSuffixString(offset)
+	return l.currentSuffix(offset)
 }
 
 func (l *Lexer) consumeSingleWhitespace() {
@@ -131,17 +138,7 @@ func (l *Lexer) consumeSingleWhitespace() {
 }
 
 func (l *Lexer) consumeWhitespace() {
-	for {
-		next := l.r.Peek(0)
-
-		if cssutil.IsWhitespace(next) {
-			l.consumeSingleWhitespace()
-		} else if next == EOF {
-			break
-		} else {
-			break
-		}
-	}
+	l.r.moveWhileClass(classWhitespace)
 }
 
 // https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-the-remnants-of-a-bad-url
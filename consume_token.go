@@ -11,6 +11,8 @@ func (l *Lexer) consumeNumericToken() (TokenType, string) {
 	number := l.consumeNumber()
 
 	if l.nextCharsAreIdentifier() {
+		// Only the unit is interned, as the values of dimensions vary
+		// with their numbers.
 		unit := l.consumeName()
 
 		return DimensionToken, number + unit
@@ -47,22 +49,27 @@ func (l *Lexer) consumeUnicodeRangeToken() (TokenType, string) {
 		}
 	}
 
-	return UnicodeRangeToken, l.r.CurrentString()
+	return UnicodeRangeToken, l.current()
 }
 
 // https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-ident-like-token
 func (l *Lexer) consumeIdentLikeToken() (TokenType, string) {
+	offset := l.r.CurrentOffset()
 	name := l.consumeName()
 
 	if l.r.Peek(0) == '(' {
+		isURL := strings.EqualFold(name, "url")
+		if name == "" { // a name without escapes, when values are skipped
+			isURL = equalFoldRunes(l.r.CurrentSuffix(offset), "url")
+		}
 		l.r.Move(1) // consume the opening parenthesis
-		if strings.ToLower(name) == "url" {
+		if isURL {
 			// The spec is slightly different so as to avoid dropping whitespace
 			// tokens, but they wouldn't be used and this is easier.
 			l.consumeWhitespace()
 
 			next := l.r.Peek(0)
-			if next != '"' && next != '\'' {
+			if next != '"' && next != '\'' && !l.r.atSlot() {
 				return l.consumeURLToken()
 			}
 		}
@@ -75,11 +82,30 @@ func (l *Lexer) consumeIdentLikeToken() (TokenType, string) {
 
 // https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-string-token
 func (l *Lexer) consumeStringToken() (TokenType, string) {
-	var result strings.Builder
-
 	until := l.r.Peek(0) // the opening quote, already checked valid by the caller
 	l.r.Move(1)
 
+	return l.consumeStringRest(until)
+}
+
+// consumeStringRest consumes the rest of a string token after its opening
+// quote.
+func (l *Lexer) consumeStringRest(until rune) (TokenType, string) {
+	// Most strings have no escapes, and their value is the text up to
+	// the closing quote.
+	offset := l.r.CurrentOffset()
+	l.r.moveUntilStringEnd(until)
+	if l.r.Peek(0) == until {
+		value := l.currentSuffix(offset)
+		l.r.Move(1)
+		return StringToken, value
+	}
+
+	var result strings.Builder
+	if !l.lazy {
+		result.WriteString(l.r.CurrentSuffixString(offset))
+	}
+
 	for {
 		next := l.r.Peek(0)
 
@@ -89,6 +115,9 @@ func (l *Lexer) consumeStringToken() (TokenType, string) {
 		}
 
 		if next == EOF {
+			if l.r.atSlot() {
+				l.resume = until
+			}
 			return StringToken, result.String()
 		}
 
@@ -109,13 +138,20 @@ func (l *Lexer) consumeStringToken() (TokenType, string) {
 				l.consumeSingleWhitespace()
 			} else if cssutil.TwoCodePointsStartsAValidEscape(next, next_next) {
 				l.r.Move(1) // consume the backslash
-				result.WriteRune(l.consumeEscape())
+				escaped := l.consumeEscape()
+				if !l.lazy {
+					result.WriteRune(escaped)
+				}
 			} else {
-				result.WriteRune(next)
+				if !l.lazy {
+					result.WriteRune(next)
+				}
 				l.r.Move(1)
 			}
 		} else {
-			result.WriteRune(next)
+			if !l.lazy {
+				result.WriteRune(next)
+			}
 			l.r.Move(1) // consume the current rune
 		}
 	}
@@ -134,6 +170,9 @@ func (l *Lexer) consumeURLToken() (TokenType, string) {
 		}
 
 		if next == EOF {
+			if l.r.atSlot() {
+				l.resume = ')'
+			}
 			return UrlToken, result.String()
 		}
 
@@ -161,14 +200,19 @@ func (l *Lexer) consumeURLToken() (TokenType, string) {
 		if next == '\\' {
 			if cssutil.TwoCodePointsStartsAValidEscape(next, l.r.Peek(1)) {
 				l.r.Move(1) // consume the backslash
-				result.WriteRune(l.consumeEscape())
+				escaped := l.consumeEscape()
+				if !l.lazy {
+					result.WriteRune(escaped)
+				}
 				continue
 			} else {
 				break
 			}
 		}
 
-		result.WriteRune(next)
+		if !l.lazy {
+			result.WriteRune(next)
+		}
 		l.r.Move(1) // consume the current rune
 	}
 
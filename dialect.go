@@ -0,0 +1,290 @@
+package csslexer
+
+import (
+	"strings"
+)
+
+// Dialect is a syntax accepted by the lexer.
+type Dialect int
+
+const (
+	// CSS is standard CSS, as tokenized by browsers.
+	CSS Dialect = iota
+
+	// SCSS is the SCSS syntax of Sass.
+	//
+	// In addition to the CSS tokens, it produces LineCommentToken for
+	// "//" comments, VariableToken for "$name", PlaceholderToken for
+	// "%name" placeholder selectors, DirectiveToken for the Sass at-rules
+	// such as "@include" and "@if", and InterpolationStartToken and
+	// InterpolationEndToken around "#{...}" interpolations. Interpolations
+	// inside strings are part of the string token.
+	SCSS
+
+	// Less is the syntax of the Less preprocessor.
+	//
+	// In addition to the CSS tokens, it produces LineCommentToken for
+	// "//" comments, AtVariableToken for "@name" unless it is a CSS
+	// at-rule, EscapedStringToken for ~"..." strings, GuardToken for the
+	// "when" keyword of guards after a mixin or selector, JavaScriptToken
+	// for `...` evaluations, and InterpolationStartToken and
+	// InterpolationEndToken around "@{...}" interpolations. Interpolations
+	// inside strings are part of the string token.
+	Less
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case CSS:
+		return "CSS"
+	case SCSS:
+		return "SCSS"
+	case Less:
+		return "Less"
+	default:
+		return "Unknown"
+	}
+}
+
+// NewLexerDialect creates a new Lexer instance for the given dialect.
+func NewLexerDialect(r *Input, dialect Dialect) *Lexer {
+	l := NewLexer(r)
+	l.dialect = dialect
+	return l
+}
+
+// Dialect returns the dialect of the lexer.
+func (l *Lexer) Dialect() Dialect {
+	return l.dialect
+}
+
+// scssDirectives are the Sass at-rules, whose preludes are Sass
+// expressions rather than CSS.
+var scssDirectives = map[string]bool{
+	"use": true, "forward": true, "mixin": true, "include": true, "content": true,
+	"function": true, "return": true, "extend": true, "at-root": true,
+	"if": true, "else": true, "each": true, "for": true, "while": true,
+	"debug": true, "warn": true, "error": true,
+}
+
+// lessAtRules are the CSS at-rules recognized by Less. Other at-keywords
+// are variables.
+var lessAtRules = map[string]bool{
+	"charset": true, "import": true, "namespace": true, "plugin": true,
+	"media": true, "supports": true, "document": true, "container": true, "layer": true, "scope": true,
+	"page": true, "font-face": true, "keyframes": true, "viewport": true, "counter-style": true,
+	"font-feature-values": true, "font-palette-values": true, "property": true, "starting-style": true,
+}
+
+// readDialectToken reads a token specific to the dialect of the lexer. It
+// returns false without consuming anything if the next token is lexed as in
+// CSS.
+func (l *Lexer) readDialectToken() (TokenType, string, bool) {
+	switch l.r.Peek(0) {
+	case '{':
+		l.braces = append(l.braces, false)
+		return 0, "", false
+
+	case '}':
+		if n := len(l.braces); n > 0 {
+			interpolation := l.braces[n-1]
+			l.braces = l.braces[:n-1]
+			if interpolation {
+				l.r.Move(1)
+				return InterpolationEndToken, l.current(), true
+			}
+		}
+		return 0, "", false
+
+	case '/':
+		if l.r.Peek(1) == '/' {
+			return LineCommentToken, l.consumeLineComment(), true
+		}
+		return 0, "", false
+	}
+
+	switch l.dialect {
+	case SCSS:
+		return l.readSCSSToken()
+	case Less:
+		return l.readLessToken()
+	}
+	return 0, "", false
+}
+
+func (l *Lexer) readSCSSToken() (TokenType, string, bool) {
+	switch l.r.Peek(0) {
+	case '#':
+		if l.r.Peek(1) == '{' {
+			return l.consumeInterpolationStart()
+		}
+
+	case '$':
+		if l.r.Peek(1) != '=' {
+			return l.consumeSigilToken(VariableToken)
+		}
+
+	case '%':
+		return l.consumeSigilToken(PlaceholderToken)
+
+	case '@':
+		state := l.r.State()
+		l.r.Move(1) // consume '@'
+		if l.nextCharsAreIdentifier() {
+			name := l.consumeName()
+			if l.isKeyword(scssDirectives, name, 1) {
+				return DirectiveToken, name, true
+			}
+		}
+		state.Restore()
+	}
+
+	return 0, "", false
+}
+
+func (l *Lexer) readLessToken() (TokenType, string, bool) {
+	switch l.r.Peek(0) {
+	case '@':
+		if l.r.Peek(1) == '{' {
+			return l.consumeInterpolationStart()
+		}
+		state := l.r.State()
+		l.r.Move(1) // consume '@'
+		if l.nextCharsAreIdentifier() {
+			name := l.consumeName()
+			// Vendor-prefixed at-rules, such as @-webkit-keyframes.
+			vendor := strings.HasPrefix(name, "-") || name == "" && l.r.CurrentSuffix(1)[0] == '-'
+			if vendor || l.isKeyword(lessAtRules, name, 1) {
+				return AtKeywordToken, name, true
+			}
+			return AtVariableToken, name, true
+		}
+		state.Restore()
+
+	case '~':
+		switch l.r.Peek(1) {
+		case '"', '\'':
+			l.r.Move(1) // consume '~'
+			tokenType, data := l.consumeStringToken()
+			if tokenType == StringToken {
+				tokenType = EscapedStringToken
+			}
+			return tokenType, data, true
+		case '`':
+			l.r.Move(1) // consume '~'
+			return JavaScriptToken, l.consumeJavaScript(), true
+		}
+
+	case '`':
+		return JavaScriptToken, l.consumeJavaScript(), true
+
+	case 'w':
+		if l.r.Peek(1) == 'h' && l.r.Peek(2) == 'e' && l.r.Peek(3) == 'n' && l.atGuard() {
+			l.r.Move(4) // consume "when"
+			return GuardToken, l.current(), true
+		}
+	}
+
+	return 0, "", false
+}
+
+// atGuard reports whether the "when" at the current position starts a
+// guard, as in ".mixin(@a) when (@a > 0)": it follows the prelude of a
+// mixin or a selector after whitespace, and is followed by whitespace and
+// a condition. Elsewhere, such as in ".when" or "x: when", it is an
+// identifier.
+func (l *Lexer) atGuard() bool {
+	// Look behind, for the end of a prelude.
+	i := l.r.pos - 1
+	if i < 0 || !isSpaceRune(l.r.runes[i]) {
+		return false
+	}
+	for i >= 0 && isSpaceRune(l.r.runes[i]) {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	switch l.r.runes[i] {
+	case ':', ';', ',', '{', '}', '(':
+		return false
+	}
+
+	// Look ahead, for a condition: "(...)", "not (...)" or "default()".
+	n := 4
+	if !isSpaceRune(l.r.Peek(n)) {
+		return false
+	}
+	for isSpaceRune(l.r.Peek(n)) {
+		n++
+	}
+	if l.r.Peek(n) == '(' {
+		return true
+	}
+	for _, word := range []string{"not", "default"} {
+		if l.peekWord(n, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// peekWord reports whether the input at offset n is the word, not
+// followed by an identifier code point.
+func (l *Lexer) peekWord(n int, word string) bool {
+	for _, r := range word {
+		if l.r.Peek(n) != r {
+			return false
+		}
+		n++
+	}
+	return !isIdentCodePoint(l.r.Peek(n))
+}
+
+// consumeInterpolationStart consumes the two code points starting an
+// interpolation, such as "#{".
+func (l *Lexer) consumeInterpolationStart() (TokenType, string, bool) {
+	l.r.Move(2)
+	l.braces = append(l.braces, true)
+	return InterpolationStartToken, l.current(), true
+}
+
+// consumeJavaScript consumes a JavaScript evaluation between backticks, and
+// returns the code between them. An unterminated evaluation extends to the
+// end of the input.
+func (l *Lexer) consumeJavaScript() string {
+	l.r.Move(1) // consume the opening backtick
+	offset := l.r.CurrentOffset()
+	for next := l.r.Peek(0); next != EOF && next != '`'; next = l.r.Peek(0) {
+		l.r.Move(1)
+	}
+	code := l.currentSuffix(offset)
+	if l.r.Peek(0) == '`' {
+		l.r.Move(1) // consume the closing backtick
+	}
+	return code
+}
+
+// consumeLineComment consumes a comment from "//" to the end of the line,
+// not including the newline, and returns the text after "//".
+func (l *Lexer) consumeLineComment() string {
+	l.r.Move(2) // consume "//"
+	offset := l.r.CurrentOffset()
+	for next := l.r.Peek(0); next != EOF && next != '\n' && next != '\r' && next != '\f'; next = l.r.Peek(0) {
+		l.r.Move(1)
+	}
+	return l.currentSuffix(offset)
+}
+
+// consumeSigilToken consumes a name prefixed by a single code point, such
+// as "$name", as a token of the given type. It returns false without
+// consuming anything if no name follows the sigil.
+func (l *Lexer) consumeSigilToken(tokenType TokenType) (TokenType, string, bool) {
+	state := l.r.State()
+	l.r.Move(1) // consume the sigil
+	if l.nextCharsAreIdentifier() {
+		return tokenType, l.consumeName(), true
+	}
+	state.Restore()
+	return 0, "", false
+}
@@ -0,0 +1,128 @@
+package csslexer
+
+import (
+	"testing"
+)
+
+type dialectToken struct {
+	Type  TokenType
+	Value string
+	Raw   string
+}
+
+func lexDialect(input string, dialect Dialect) []dialectToken {
+	lexer := NewLexerDialect(NewInput(input), dialect)
+
+	var tokens []dialectToken
+	for {
+		token := lexer.Next()
+		if token.Type == EOFToken {
+			return tokens
+		}
+		tokens = append(tokens, dialectToken{token.Type, token.Value, string(token.Raw)})
+	}
+}
+
+func testDialect(t *testing.T, dialect Dialect, tests []struct {
+	name     string
+	input    string
+	expected []dialectToken
+}) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := lexDialect(tt.input, dialect)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("got %d tokens %v, expected %d tokens %v", len(result), result, len(tt.expected), tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("token %d = %v, expected %v", i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSCSS(t *testing.T) {
+	testDialect(t, SCSS, []struct {
+		name     string
+		input    string
+		expected []dialectToken
+	}{
+		{
+			name:  "Line comment",
+			input: "a // b */\nc",
+			expected: []dialectToken{
+				{IdentToken, "a", "a"}, {WhitespaceToken, " ", " "},
+				{LineCommentToken, " b */", "// b */"}, {WhitespaceToken, "\n", "\n"},
+				{IdentToken, "c", "c"},
+			},
+		},
+		{
+			name:  "Variables",
+			input: "$base-color:#c6538c;$=$",
+			expected: []dialectToken{
+				{VariableToken, "base-color", "$base-color"}, {ColonToken, ":", ":"}, {HashToken, "c6538c", "#c6538c"},
+				{SemicolonToken, ";", ";"}, {SuffixMatchToken, "$=", "$="}, {DelimiterToken, "$", "$"},
+			},
+		},
+		{
+			name:  "Interpolation",
+			input: ".col-#{$i}{w:#{1+{}}}",
+			expected: []dialectToken{
+				{DelimiterToken, ".", "."}, {IdentToken, "col-", "col-"},
+				{InterpolationStartToken, "#{", "#{"}, {VariableToken, "i", "$i"}, {InterpolationEndToken, "}", "}"},
+				{LeftBraceToken, "{", "{"}, {IdentToken, "w", "w"}, {ColonToken, ":", ":"},
+				{InterpolationStartToken, "#{", "#{"}, {NumberToken, "1", "1"}, {DelimiterToken, "+", "+"},
+				{LeftBraceToken, "{", "{"}, {RightBraceToken, "}", "}"}, {InterpolationEndToken, "}", "}"},
+				{RightBraceToken, "}", "}"},
+			},
+		},
+		{
+			name:  "Placeholders",
+			input: "%toolbelt{}10%;% a",
+			expected: []dialectToken{
+				{PlaceholderToken, "toolbelt", "%toolbelt"}, {LeftBraceToken, "{", "{"}, {RightBraceToken, "}", "}"},
+				{PercentageToken, "10%", "10%"}, {SemicolonToken, ";", ";"},
+				{DelimiterToken, "%", "%"}, {WhitespaceToken, " ", " "}, {IdentToken, "a", "a"},
+			},
+		},
+		{
+			name:  "Directives",
+			input: "@include m;@media x;@IF",
+			expected: []dialectToken{
+				{DirectiveToken, "include", "@include"}, {WhitespaceToken, " ", " "}, {IdentToken, "m", "m"}, {SemicolonToken, ";", ";"},
+				{AtKeywordToken, "media", "@media"}, {WhitespaceToken, " ", " "}, {IdentToken, "x", "x"}, {SemicolonToken, ";", ";"},
+				{DirectiveToken, "IF", "@IF"},
+			},
+		},
+		{
+			name:  "CSS is unchanged",
+			input: "a{b:url(//x)/**/}",
+			expected: []dialectToken{
+				{IdentToken, "a", "a"}, {LeftBraceToken, "{", "{"}, {IdentToken, "b", "b"}, {ColonToken, ":", ":"},
+				{UrlToken, "//x", "url(//x)"}, {CommentToken, "/**/", "/**/"}, {RightBraceToken, "}", "}"},
+			},
+		},
+	})
+}
+
+func TestCSSDialect(t *testing.T) {
+	// Without a dialect, the SCSS syntax is tokenized as CSS.
+	testDialect(t, CSS, []struct {
+		name     string
+		input    string
+		expected []dialectToken
+	}{
+		{
+			name:  "SCSS syntax",
+			input: "$a//#{",
+			expected: []dialectToken{
+				{DelimiterToken, "$", "$"}, {IdentToken, "a", "a"}, {DelimiterToken, "/", "/"}, {DelimiterToken, "/", "/"},
+				{DelimiterToken, "#", "#"}, {LeftBraceToken, "{", "{"},
+			},
+		},
+	})
+}
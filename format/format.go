@@ -0,0 +1,325 @@
+// Package format pretty-prints style sheets in a canonical layout.
+//
+// Rules and declarations are put on their own lines and indented by
+// nesting level, whitespace inside selectors and values is collapsed, and
+// commas and combinators get consistent spacing. Comments are kept where
+// they are, and blank lines between statements are preserved, collapsed
+// to one. Formatting only changes whitespace, unless lowercasing is
+// enabled, and formatting a formatted style sheet again does not change
+// it.
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+// Options controls the output style.
+type Options struct {
+	// Indent is the indentation of one nesting level. It defaults to two
+	// spaces.
+	Indent string
+
+	// LowercaseHex lowercases hex colors in values, as in #FFF -> #fff.
+	LowercaseHex bool
+
+	// LowercaseKeywords lowercases property names, at-rule names, function
+	// names, units, !important and keyword values. Keyword values are only
+	// lowercased when the property is known to take them: CSS-wide
+	// keywords, named colors of color properties, and common keywords of
+	// standard properties. Other identifiers may be case-sensitive names,
+	// such as animation names, and are kept, as are the identifiers of
+	// at-rule preludes other than media queries.
+	LowercaseKeywords bool
+}
+
+// Format formats a style sheet.
+func Format(src string, opts Options) string {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+
+	lexer := csslexer.NewLexer(csslexer.NewInput(src))
+	f := &formatter{opts: opts}
+	for {
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			break
+		}
+		f.tokens = append(f.tokens, token)
+	}
+
+	f.statements(0)
+	return f.out.String()
+}
+
+type formatter struct {
+	opts   Options
+	tokens []csslexer.Token
+	pos    int
+	out    bytes.Buffer
+}
+
+func (f *formatter) peek() csslexer.Token {
+	if f.pos < len(f.tokens) {
+		return f.tokens[f.pos]
+	}
+	return csslexer.Token{Type: csslexer.EOFToken}
+}
+
+// line writes a line at the given nesting depth.
+func (f *formatter) line(depth int, text string) {
+	f.out.WriteString(strings.Repeat(f.opts.Indent, depth))
+	f.out.WriteString(text)
+	f.out.WriteByte('\n')
+}
+
+// statements formats rules, declarations and comments until the end of
+// the enclosing block, which it does not consume.
+func (f *formatter) statements(depth int) {
+	first := true
+	for {
+		// Whitespace between statements is dropped, except that blank
+		// lines are kept, and a comment on the same line as the end of the
+		// previous statement stays on that line.
+		newlines := 0
+		for f.peek().Type == csslexer.WhitespaceToken {
+			newlines += countNewlines(f.peek().Raw)
+			f.pos++
+		}
+
+		token := f.peek()
+		switch token.Type {
+		case csslexer.EOFToken:
+			return
+		case csslexer.RightBraceToken:
+			if depth > 0 {
+				return
+			}
+			// A stray closing brace at the top level.
+			f.pos++
+			f.line(depth, "}")
+			first = false
+			continue
+		case csslexer.SemicolonToken:
+			// Empty statement.
+			f.pos++
+			continue
+		}
+
+		if token.Type == csslexer.CommentToken && newlines == 0 && f.out.Len() > 0 {
+			f.out.Truncate(f.out.Len() - 1) // the previous newline
+			f.out.WriteByte(' ')
+			f.out.WriteString(string(token.Raw))
+			f.out.WriteByte('\n')
+			f.pos++
+			continue
+		}
+		if newlines > 1 && !first {
+			f.out.WriteByte('\n')
+		}
+		first = false
+
+		switch {
+		case token.Type == csslexer.CommentToken, token.Type == csslexer.CDOToken, token.Type == csslexer.CDCToken:
+			f.pos++
+			f.line(depth, string(token.Raw))
+		case token.Type == csslexer.AtKeywordToken:
+			f.atRule(depth)
+		case f.startsRule():
+			f.qualifiedRule(depth)
+		default:
+			f.declaration(depth)
+		}
+	}
+}
+
+// startsRule reports whether the statement at the current position is a
+// rule rather than a declaration, i.e. a '{' comes before the end of the
+// statement. Custom property declarations are never rules, even if their
+// value contains a block.
+func (f *formatter) startsRule() bool {
+	if token := f.peek(); token.Type == csslexer.IdentToken && strings.HasPrefix(token.Value, "--") {
+		rest := tokenutil.TrimWhitespace(f.tokens[f.pos+1:])
+		if len(rest) > 0 && rest[0].Type == csslexer.ColonToken {
+			return false
+		}
+	}
+
+	depth := 0
+	for _, token := range f.tokens[f.pos:] {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.LeftBraceToken:
+			return depth <= 0
+		case csslexer.SemicolonToken, csslexer.RightBraceToken:
+			if depth <= 0 {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// collect returns the tokens from the current position up to a token of
+// one of the given types at the top level, or the end of the enclosing
+// block, without consuming that token.
+func (f *formatter) collect(stop ...csslexer.TokenType) []csslexer.Token {
+	start, depth := f.pos, 0
+	for ; f.pos < len(f.tokens); f.pos++ {
+		token := f.tokens[f.pos]
+		if depth == 0 {
+			for _, t := range stop {
+				if token.Type == t {
+					return f.tokens[start:f.pos]
+				}
+			}
+		}
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken, csslexer.LeftBraceToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.RightBraceToken:
+			if depth == 0 {
+				return f.tokens[start:f.pos]
+			}
+			depth--
+		}
+	}
+	return f.tokens[start:]
+}
+
+func (f *formatter) atRule(depth int) {
+	keyword := f.peek()
+	f.pos++
+
+	name := string(keyword.Raw)
+	if f.opts.LowercaseKeywords {
+		name = strings.ToLower(name)
+	}
+
+	if text := f.inline(f.collect(csslexer.LeftBraceToken, csslexer.SemicolonToken), prelude, strings.ToLower(keyword.Value)); text != "" {
+		name += " " + text
+	}
+
+	switch f.peek().Type {
+	case csslexer.LeftBraceToken:
+		f.block(depth, name)
+	case csslexer.SemicolonToken:
+		f.pos++
+		f.line(depth, name+";")
+	default:
+		// Unterminated at-rule at the end of a block or the input.
+		f.line(depth, name+";")
+	}
+}
+
+func (f *formatter) qualifiedRule(depth int) {
+	selectors := f.selectorList(f.collect(csslexer.LeftBraceToken))
+	for _, s := range selectors[:len(selectors)-1] {
+		f.line(depth, s+",")
+	}
+	f.block(depth, selectors[len(selectors)-1])
+}
+
+// block formats a block at the current position, following the given
+// line.
+func (f *formatter) block(depth int, head string) {
+	f.pos++ // '{'
+
+	empty := true
+	for _, token := range f.tokens[f.pos:] {
+		if token.Type == csslexer.RightBraceToken {
+			break
+		}
+		if token.Type != csslexer.WhitespaceToken && token.Type != csslexer.SemicolonToken {
+			empty = false
+			break
+		}
+	}
+	if head != "" {
+		head += " "
+	}
+	if empty {
+		for f.pos < len(f.tokens) && f.peek().Type != csslexer.RightBraceToken {
+			f.pos++
+		}
+		f.pos++
+		f.line(depth, head+"{}")
+		return
+	}
+
+	f.line(depth, head+"{")
+	f.statements(depth + 1)
+	f.pos++ // '}', or past the end of the input
+	f.line(depth, "}")
+}
+
+func (f *formatter) declaration(depth int) {
+	tokens := tokenutil.TrimWhitespace(f.collect(csslexer.SemicolonToken))
+	if f.peek().Type == csslexer.SemicolonToken {
+		f.pos++
+	}
+	if len(tokens) == 0 {
+		return
+	}
+
+	colon := -1
+	for i, token := range tokens {
+		if token.Type == csslexer.ColonToken {
+			colon = i
+			break
+		}
+	}
+	name := tokenutil.TrimWhitespace(tokens[:max(colon, 0)])
+	if colon < 0 || len(name) != 1 || name[0].Type != csslexer.IdentToken {
+		// Not a declaration, keep the text as is.
+		f.line(depth, f.inline(tokens, value, "")+";")
+		return
+	}
+
+	property := string(name[0].Raw)
+	values := tokenutil.TrimWhitespace(tokens[colon+1:])
+
+	var text string
+	if strings.HasPrefix(name[0].Value, "--") {
+		// Custom property values are kept verbatim.
+		text = property + ":"
+		if len(values) > 0 {
+			text += " " + csslexer.Serialize(values)
+		}
+	} else {
+		if f.opts.LowercaseKeywords {
+			property = strings.ToLower(property)
+		}
+		text = property + ":"
+		if v := f.inline(values, value, strings.ToLower(name[0].Value)); v != "" {
+			text += " " + v
+		}
+	}
+	f.line(depth, text+";")
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func countNewlines(raw []rune) int {
+	n := 0
+	for i, r := range raw {
+		if r == '\n' || r == '\f' || r == '\r' && (i+1 == len(raw) || raw[i+1] != '\n') {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,173 @@
+package format
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "Rule",
+			input:    "a{color:red;margin:0 auto}",
+			expected: "a {\n  color: red;\n  margin: 0 auto;\n}\n",
+		},
+		{
+			name:     "Selectors and combinators",
+			input:    "a>b+c~d   e,f||g , :is(h>i,j) , [x = 'y'] {}",
+			expected: "a > b + c ~ d e,\nf || g,\n:is(h>i, j),\n[x = 'y'] {}\n",
+		},
+		{
+			name:     "Values",
+			input:    "a{font:12px/1.5 a , b;color:rgb( 0,0 ,0 )!important;b:calc(1px + 2px) ! important}",
+			expected: "a {\n  font: 12px/1.5 a, b;\n  color: rgb(0, 0, 0) !important;\n  b: calc(1px + 2px) !important;\n}\n",
+		},
+		{
+			name:     "Nesting and at-rules",
+			input:    "@import url(a.css)  screen;@media (min-width:10px){.a{b:c;&:hover{d:e}}}",
+			expected: "@import url(a.css) screen;\n@media (min-width:10px) {\n  .a {\n    b: c;\n    &:hover {\n      d: e;\n    }\n  }\n}\n",
+		},
+		{
+			name:     "Comments",
+			input:    "/* head */\na { /* open */\n  b: c; /* trailing */\n  /* own line */\n  d: e /* inline */ f;\n}",
+			expected: "/* head */\na { /* open */\n  b: c; /* trailing */\n  /* own line */\n  d: e /* inline */ f;\n}\n",
+		},
+		{
+			name:     "Blank lines are collapsed",
+			input:    "a{}\n\n\n\nb{c:d;\n\n\ne:f}",
+			expected: "a {}\n\nb {\n  c: d;\n\n  e: f;\n}\n",
+		},
+		{
+			name:     "Custom properties are verbatim",
+			input:    "a{--x:  { a:b }  ;--y:1px,2PX}",
+			opts:     Options{LowercaseKeywords: true},
+			expected: "a {\n  --x: { a:b };\n  --y: 1px,2PX;\n}\n",
+		},
+		{
+			name:     "Lowercasing",
+			input:    "A{COLOR:#ABCDEF RED FooBar;Width:CALC(10PX);x:y!IMPORTANT}@MEDIA SCREEN{}",
+			opts:     Options{LowercaseHex: true, LowercaseKeywords: true, Indent: "\t"},
+			expected: "A {\n\tcolor: #abcdef red FooBar;\n\twidth: calc(10px);\n\tx: y !important;\n}\n@media screen {}\n",
+		},
+		{
+			name:     "Names are not lowercased",
+			input:    "@keyframes Move{}a{animation:Move 1s LINEAR;grid-area:Top;font-family:Default,SERIF;display:BLOCK;float:INHERIT}",
+			opts:     Options{LowercaseKeywords: true},
+			expected: "@keyframes Move {}\na {\n  animation: Move 1s linear;\n  grid-area: Top;\n  font-family: Default, serif;\n  display: block;\n  float: inherit;\n}\n",
+		},
+		{
+			name:     "Preludes are not lowercased",
+			input:    "@layer Base,Top;@container Sidebar (MIN-WIDTH:1PX){}@MEDIA ONLY SCREEN AND (ORIENTATION:LANDSCAPE){}",
+			opts:     Options{LowercaseKeywords: true},
+			expected: "@layer Base, Top;\n@container Sidebar (MIN-WIDTH:1px) {}\n@media only screen and (ORIENTATION:LANDSCAPE) {}\n",
+		},
+		{
+			name:     "Colors only in color properties",
+			input:    "a{color:RED;border:1PX SOLID Blue;animation-name:Red}",
+			opts:     Options{LowercaseKeywords: true},
+			expected: "a {\n  color: red;\n  border: 1px solid blue;\n  animation-name: Red;\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Format(tt.input, tt.opts)
+			if result != tt.expected {
+				t.Errorf("Format(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+			if again := Format(result, tt.opts); again != result {
+				t.Errorf("Format() is not idempotent: %q, then %q", result, again)
+			}
+		})
+	}
+}
+
+// significant returns the tokens of a style sheet without whitespace and
+// semicolons, which the formatter may add or remove.
+func significant(src string) []string {
+	lexer := csslexer.NewLexer(csslexer.NewInput(src))
+
+	var tokens []string
+	for {
+		token := lexer.Next()
+		switch token.Type {
+		case csslexer.EOFToken:
+			return tokens
+		case csslexer.WhitespaceToken, csslexer.SemicolonToken:
+			continue
+		}
+		tokens = append(tokens, string(token.Raw))
+	}
+}
+
+// TestFormatTestdata formats the benchmark style sheets twice, checking that
+// formatting is idempotent and only changes whitespace.
+func TestFormatTestdata(t *testing.T) {
+	files, err := filepath.Glob("../bench/testdata/*.css.gz")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no testdata found: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src := readGzip(t, file)
+
+			once := Format(src, Options{})
+			twice := Format(once, Options{})
+			if once != twice {
+				t.Errorf("Format() is not idempotent:\n%s", firstDifference(once, twice))
+			}
+
+			a, b := significant(src), significant(once)
+			for i := 0; i < len(a) && i < len(b); i++ {
+				if a[i] != b[i] {
+					t.Fatalf("token %d changed from %q to %q", i, a[i], b[i])
+				}
+			}
+			if len(a) != len(b) {
+				t.Errorf("Format() changed the number of tokens from %d to %d", len(a), len(b))
+			}
+		})
+	}
+}
+
+func readGzip(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
+
+func firstDifference(a, b string) string {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; i < len(linesA) && i < len(linesB); i++ {
+		if linesA[i] != linesB[i] {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, linesA[i], linesB[i])
+		}
+	}
+	return "different number of lines"
+}
@@ -0,0 +1,160 @@
+package format
+
+import (
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+// context is the kind of component values being formatted on one line.
+type context int
+
+const (
+	value    context = iota // declaration values
+	prelude                 // at-rule preludes
+	selector                // selectors of qualified rules
+)
+
+// selectorList formats the prelude of a qualified rule, returning one
+// string per selector of the list.
+func (f *formatter) selectorList(tokens []csslexer.Token) []string {
+	var selectors []string
+
+	depth, start := 0, 0
+	for i, token := range tokens {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.CommaToken:
+			if depth == 0 {
+				selectors = append(selectors, f.inline(tokens[start:i], selector, ""))
+				start = i + 1
+			}
+		}
+	}
+	return append(selectors, f.inline(tokens[start:], selector, ""))
+}
+
+// inline formats component values on a single line: whitespace is
+// collapsed to single spaces and removed inside parentheses and brackets,
+// commas are followed by a space, and combinators in selectors are
+// surrounded by spaces. name is the lowercase property name of a value, or
+// the at-rule name of a prelude, deciding which keywords are lowercased.
+func (f *formatter) inline(tokens []csslexer.Token, ctx context, name string) string {
+	var sb strings.Builder
+
+	space := false // whether whitespace precedes the next token
+	depth := 0
+	important := false // whether the previous token is the '!' of !important
+	var prev csslexer.Token
+
+	for i, token := range tokens {
+		if token.Type == csslexer.WhitespaceToken {
+			if prev.Type == csslexer.BadStringToken && countNewlines(token.Raw) > 0 {
+				// The newline ends the bad string, and must be kept for
+				// the next token to be read the same way.
+				sb.WriteByte('\n')
+				space = false
+				prev = token
+				continue
+			}
+			space = true
+			continue
+		}
+
+		combinator := ctx == selector && depth == 0 && isCombinator(token)
+		bang := ctx == value && isDelim(token, "!") && isImportant(tokens[i+1:])
+
+		switch {
+		case sb.Len() == 0, important:
+		case combinator, bang:
+			sb.WriteByte(' ')
+		case token.Type == csslexer.CommaToken, token.Type == csslexer.RightParenthesisToken, token.Type == csslexer.RightBracketToken:
+			// No space before.
+		case prev.Type == csslexer.FunctionToken, prev.Type == csslexer.LeftParenthesisToken, prev.Type == csslexer.LeftBracketToken:
+			// No space after.
+		case space, prev.Type == csslexer.CommaToken, ctx == selector && depth == 0 && isCombinator(prev):
+			sb.WriteByte(' ')
+		}
+		space = false
+
+		if important && f.opts.LowercaseKeywords {
+			sb.WriteString(strings.ToLower(string(token.Raw)))
+		} else {
+			sb.WriteString(f.text(token, ctx, name))
+		}
+		important = bang
+
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		}
+		prev = token
+	}
+
+	return sb.String()
+}
+
+// text returns the text of a token, lowercased if enabled.
+func (f *formatter) text(token csslexer.Token, ctx context, name string) string {
+	raw := string(token.Raw)
+	if ctx == selector {
+		return raw
+	}
+
+	switch token.Type {
+	case csslexer.HashToken:
+		if f.opts.LowercaseHex && isHexColor(token.Value) {
+			return strings.ToLower(raw)
+		}
+	case csslexer.FunctionToken:
+		if f.opts.LowercaseKeywords && !strings.HasPrefix(token.Value, "--") {
+			return strings.ToLower(raw)
+		}
+	case csslexer.DimensionToken:
+		if f.opts.LowercaseKeywords {
+			// The exponent of the number is case-insensitive as well.
+			return strings.ToLower(raw)
+		}
+	case csslexer.IdentToken:
+		if f.opts.LowercaseKeywords && isKeyword(token.Value, ctx, name) {
+			return strings.ToLower(raw)
+		}
+	}
+	return raw
+}
+
+func isCombinator(token csslexer.Token) bool {
+	return isDelim(token, ">") || isDelim(token, "+") || isDelim(token, "~") || token.Type == csslexer.ColumnToken
+}
+
+func isDelim(token csslexer.Token, value string) bool {
+	return token.Type == csslexer.DelimiterToken && token.Value == value
+}
+
+// isImportant reports whether the tokens following a '!' form the
+// important flag.
+func isImportant(tokens []csslexer.Token) bool {
+	tokens = tokenutil.TrimWhitespace(tokens)
+	return len(tokens) == 1 && tokens[0].Type == csslexer.IdentToken && strings.EqualFold(tokens[0].Value, "important")
+}
+
+func isHexColor(s string) bool {
+	switch len(s) {
+	case 3, 4, 6, 8:
+	default:
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package format
+
+import (
+	"strings"
+
+	"go.baoshuo.dev/csslexer/color"
+)
+
+// cssWideKeywords are the keywords valid in the value of any property.
+var cssWideKeywords = words("inherit initial unset revert revert-layer")
+
+// mediaKeywords are the keywords of media queries.
+var mediaKeywords = words("and or not only all screen print")
+
+// propertyKeywords maps the standard properties to the keywords they take.
+// Identifiers not listed may be case-sensitive names, such as animation
+// names or font families, and are never lowercased.
+var propertyKeywords = map[string]map[string]bool{}
+
+func init() {
+	for _, group := range []struct{ properties, keywords string }{
+		{"display", "none block inline inline-block flex inline-flex grid inline-grid table table-row table-cell contents list-item flow-root"},
+		{"position", "static relative absolute fixed sticky"},
+		{"float clear", "none left right both inline-start inline-end"},
+		{"text-align", "left right center justify start end"},
+		{"vertical-align", "baseline top middle bottom sub super text-top text-bottom"},
+		{"visibility", "visible hidden collapse"},
+		{"overflow overflow-x overflow-y", "visible hidden scroll auto clip"},
+		{"font font-weight", "normal bold bolder lighter"},
+		{"font font-style", "normal italic oblique"},
+		{"font font-family", "serif sans-serif monospace cursive fantasy system-ui"},
+		{"text-transform", "none uppercase lowercase capitalize"},
+		{"text-decoration text-decoration-line", "none underline overline line-through"},
+		{"text-overflow", "clip ellipsis"},
+		{"white-space", "normal nowrap pre pre-wrap pre-line break-spaces"},
+		{"word-wrap overflow-wrap", "normal break-word anywhere"},
+		{"word-break", "normal break-all keep-all break-word"},
+		{"border border-top border-right border-bottom border-left border-style border-width outline outline-style outline-width",
+			"none hidden solid dashed dotted double groove ridge inset outset thin medium thick"},
+		{"cursor", "auto default pointer text move wait help progress crosshair grab grabbing not-allowed none"},
+		{"background background-repeat", "repeat no-repeat repeat-x repeat-y space round"},
+		{"background background-size", "auto cover contain"},
+		{"background background-position", "left right top bottom center"},
+		{"background background-attachment", "scroll fixed local"},
+		{"background background-clip background-origin box-sizing", "border-box padding-box content-box"},
+		{"flex-direction flex-flow", "row row-reverse column column-reverse"},
+		{"flex-wrap flex-flow", "nowrap wrap wrap-reverse"},
+		{"flex flex-basis", "auto none content"},
+		{"justify-content justify-items justify-self align-content align-items align-self place-content place-items place-self",
+			"auto normal start end center flex-start flex-end stretch baseline space-between space-around space-evenly"},
+		{"grid-auto-flow", "row column dense"},
+		{"transition transition-timing-function animation animation-timing-function",
+			"ease ease-in ease-out ease-in-out linear step-start step-end"},
+		{"transition transition-property", "all none"},
+		{"animation animation-iteration-count", "infinite"},
+		{"animation animation-direction", "normal reverse alternate alternate-reverse"},
+		{"animation animation-fill-mode", "none forwards backwards both"},
+		{"animation animation-play-state", "running paused"},
+		{"width height inline-size block-size min-width min-height margin margin-top margin-right margin-bottom margin-left top right bottom left inset z-index",
+			"auto"},
+		{"width height min-width min-height max-width max-height", "min-content max-content fit-content"},
+		{"max-width max-height transform filter box-shadow text-shadow content", "none"},
+		{"pointer-events", "auto none"},
+		{"user-select", "auto none text all"},
+		{"list-style list-style-type", "none disc circle square decimal"},
+		{"list-style list-style-position", "inside outside"},
+		{"table-layout", "auto fixed"},
+		{"border-collapse", "collapse separate"},
+		{"resize", "none both horizontal vertical"},
+		{"object-fit", "fill contain cover none scale-down"},
+	} {
+		for _, property := range strings.Fields(group.properties) {
+			if propertyKeywords[property] == nil {
+				propertyKeywords[property] = make(map[string]bool)
+			}
+			for _, keyword := range strings.Fields(group.keywords) {
+				propertyKeywords[property][keyword] = true
+			}
+		}
+	}
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// isKeyword reports whether an identifier is a case-insensitive keyword in
+// the value of the property, or the prelude of the at-rule, name.
+func isKeyword(ident string, ctx context, name string) bool {
+	lower := strings.ToLower(ident)
+
+	if ctx == prelude {
+		return name == "media" && mediaKeywords[lower]
+	}
+
+	property := unprefixed(name)
+	if property == "" || strings.HasPrefix(property, "--") {
+		return false
+	}
+	if cssWideKeywords[lower] || propertyKeywords[property][lower] {
+		return true
+	}
+	if !takesColor(property) {
+		return false
+	}
+	if lower == "currentcolor" {
+		return true
+	}
+	_, err := color.ParseString(lower)
+	return err == nil
+}
+
+// takesColor reports whether a property takes color values.
+func takesColor(property string) bool {
+	switch property {
+	case "color", "background", "border", "border-top", "border-right", "border-bottom", "border-left",
+		"outline", "box-shadow", "text-shadow", "text-decoration", "column-rule", "fill", "stroke":
+		return true
+	}
+	return strings.HasSuffix(property, "-color")
+}
+
+// unprefixed returns a property name without its vendor prefix.
+func unprefixed(property string) string {
+	for _, prefix := range []string{"-webkit-", "-moz-", "-ms-", "-o-"} {
+		if strings.HasPrefix(property, prefix) {
+			return property[len(prefix):]
+		}
+	}
+	return property
+}
@@ -0,0 +1,345 @@
+//go:build go1.18
+// +build go1.18
+
+package csslexer
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// maxSeedLength is the length of the seeds cut from the benchmark style
+// sheets, which are too large to be mutated efficiently.
+const maxSeedLength = 2048
+
+// addSeeds adds the sources of the test cases and benchmarks to the seed
+// corpus of a fuzz target.
+func addSeeds(f *testing.F) {
+	for name, source := range testSources(f) {
+		if !strings.HasPrefix(name, filepath.Join("bench", "testdata")) {
+			f.Add(source)
+			continue
+		}
+		// Cut the style sheet after the first '}' following every
+		// maxSeedLength bytes.
+		for len(source) > maxSeedLength {
+			i := bytes.IndexByte(source[maxSeedLength:], '}')
+			if i < 0 {
+				break
+			}
+			f.Add(source[:maxSeedLength+i+1])
+			source = source[maxSeedLength+i+1:]
+		}
+		f.Add(source)
+	}
+}
+
+// fuzzTokens reads all the tokens of the data, checking that every token
+// but the last is not empty and that the last is EOFToken.
+func fuzzTokens(t *testing.T, data []byte) []Token {
+	return fuzzLexerTokens(t, NewLexer(NewInputBytes(data)), utf8.RuneCount(data))
+}
+
+// fuzzLexerTokens is like fuzzTokens, for a lexer of an input of the given
+// number of runes.
+func fuzzLexerTokens(t *testing.T, lexer *Lexer, runes int) []Token {
+	var tokens []Token
+	for {
+		token := lexer.Next()
+		if token.Type == EOFToken {
+			if len(token.Raw) != 0 {
+				t.Fatalf("EOFToken has raw data %q", string(token.Raw))
+			}
+			return tokens
+		}
+		if len(token.Raw) == 0 {
+			t.Fatalf("token %d = %s is empty", len(tokens), token)
+		}
+		tokens = append(tokens, token)
+		if len(tokens) > runes+1 {
+			t.Fatalf("read more tokens than runes in the input")
+		}
+	}
+}
+
+// checkRawTokens checks that reading all the tokens with NextRaw, then
+// their values with Value, yields the given tokens.
+func checkRawTokens(t *testing.T, lexer *Lexer, tokens []Token) {
+	var raw []RawToken
+	for {
+		token := lexer.NextRaw()
+		if token.Type == EOFToken {
+			break
+		}
+		raw = append(raw, token)
+		if len(raw) > len(tokens) {
+			t.Fatalf("NextRaw() read more than the %d tokens read by Next()", len(tokens))
+		}
+	}
+	if len(raw) != len(tokens) {
+		t.Fatalf("NextRaw() read %d tokens, expected %d", len(raw), len(tokens))
+	}
+
+	for i, token := range raw {
+		expected := tokens[i]
+		if token.Type != expected.Type || string(lexer.Raw(token)) != string(expected.Raw) {
+			t.Fatalf("raw token %d = %s %q, expected %s %q", i, token.Type, string(lexer.Raw(token)), expected.Type, string(expected.Raw))
+		}
+		if value := lexer.Value(token); value != expected.Value {
+			t.Fatalf("value of token %d = %q, expected %q", i, value, expected.Value)
+		}
+	}
+}
+
+// checkTokens checks that the tokens are the expected ones.
+func checkTokens(t *testing.T, name string, tokens, expected []Token) {
+	for i := 0; i < len(tokens) && i < len(expected); i++ {
+		if tokens[i].Type != expected[i].Type || tokens[i].Value != expected[i].Value || string(tokens[i].Raw) != string(expected[i].Raw) {
+			t.Fatalf("%s: token %d = %s %q, expected %s %q", name, i, tokens[i].Type, string(tokens[i].Raw), expected[i].Type, string(expected[i].Raw))
+		}
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("%s: %d tokens, expected %d", name, len(tokens), len(expected))
+	}
+}
+
+// FuzzRaw checks that lexing terminates, and that the raw data of the
+// tokens is the preprocessed input.
+func FuzzRaw(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var raw []rune
+		for _, token := range fuzzTokens(t, data) {
+			raw = append(raw, token.Raw...)
+		}
+
+		expected := NewInputBytes(data).runes
+		if string(raw) != string(expected) {
+			t.Errorf("raw data of the tokens = %q, expected %q", string(raw), string(expected))
+		}
+	})
+}
+
+// FuzzNextRaw checks that reading the tokens with NextRaw and Value yields
+// the tokens read with Next, in every dialect.
+func FuzzNextRaw(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, dialect := range []Dialect{CSS, SCSS, Less} {
+			t.Run(dialect.String(), func(t *testing.T) {
+				runes := utf8.RuneCount(data)
+				tokens := fuzzLexerTokens(t, NewLexerDialect(NewInputBytes(data), dialect), runes)
+				checkRawTokens(t, NewLexerDialect(NewInputBytes(data), dialect), tokens)
+			})
+		}
+	})
+}
+
+// FuzzTemplate checks that lexing a template terminates, that its tokens
+// cover the input with a SlotToken for every slot, and that NextRaw and
+// Value yield the same tokens as Next. The chunks of the template are the
+// data split at NULL bytes.
+func FuzzTemplate(f *testing.F) {
+	addSeeds(f)
+	for _, seed := range []string{
+		"a { color: \x00; }",
+		"\x00 { \x00: \x00 }",
+		"a { content: \"a\x00b\x00\" }",
+		"a { content: 'a\x00",
+		"\"\x00\r",
+		"/* a \x00 b */ c",
+		"/*\x00",
+		"a { background: url(a\x00b) }",
+		"a { background: url(\x00",
+		"\x00\x00",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		chunks := strings.Split(string(data), "\x00")
+		for _, dialect := range []Dialect{CSS, SCSS, Less} {
+			t.Run(dialect.String(), func(t *testing.T) {
+				input := NewInputTemplate(chunks)
+				runes := len(input.runes)
+				tokens := fuzzLexerTokens(t, NewLexerDialect(input, dialect), runes)
+
+				var raw []rune
+				slots := 0
+				for _, token := range tokens {
+					raw = append(raw, token.Raw...)
+					if token.Type == SlotToken {
+						if token.Value != strconv.Itoa(slots) {
+							t.Fatalf("slot %d has value %q", slots, token.Value)
+						}
+						slots++
+					}
+				}
+				if slots != len(chunks)-1 {
+					t.Errorf("%d slots, expected %d", slots, len(chunks)-1)
+				}
+				if expected := NewInputTemplate(chunks).runes; string(raw) != string(expected) {
+					t.Errorf("raw data of the tokens = %q, expected %q", string(raw), string(expected))
+				}
+
+				checkRawTokens(t, NewLexerDialect(NewInputTemplate(chunks), dialect), tokens)
+			})
+		}
+	})
+}
+
+// fuzzParallelChunk is the minimum chunk size used by FuzzTokenizeParallel,
+// small enough to split most seeds.
+const fuzzParallelChunk = 8
+
+// FuzzTokenizeParallel checks that TokenizeParallel and TokenizeParallelRaw
+// return the tokens read by Next when splitting the input.
+func FuzzTokenizeParallel(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tokens := fuzzTokens(t, data)
+		checkTokens(t, "tokenizeParallel", tokenizeParallel(NewInputBytes(data), 4, fuzzParallelChunk), tokens)
+
+		input := NewInputBytes(data)
+		raw := tokenizeParallelRaw(input, 4, fuzzParallelChunk)
+		lexer := NewLexer(input)
+		values := make([]Token, len(raw))
+		for i, token := range raw {
+			values[i] = Token{Type: token.Type, Value: lexer.Value(token), Raw: lexer.Raw(token)}
+		}
+		checkTokens(t, "tokenizeParallelRaw", values, tokens)
+	})
+}
+
+// FuzzString checks that tokenizing the serialization of the tokens yields
+// equivalent tokens. The tokens read by the lexer are serialized with
+// Serialize, and with Token.String once converted to the values it expects
+// by unlexed, as the String of percentages, comments and dimensions with
+// an escaped unit read by the lexer does not round-trip.
+func FuzzString(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tokens := fuzzTokens(t, data)
+		checkSerialization(t, Serialize(tokens), tokens)
+
+		serialized := make([]Token, len(tokens))
+		for i, token := range tokens {
+			serialized[i] = unlexed(token)
+		}
+		var expected []Token
+		for _, token := range tokens {
+			expected = append(expected, equivalentTokens(token)...)
+		}
+		checkSerialization(t, Serialize(serialized), expected)
+	})
+}
+
+// checkSerialization checks that tokenizing the text yields the types and
+// values of the expected tokens. Empty comments may be inserted by
+// Serialize, so they are ignored.
+func checkSerialization(t *testing.T, text string, expected []Token) {
+	var again []Token
+	for _, token := range fuzzTokens(t, []byte(text)) {
+		if token.Type != CommentToken || token.Value != "/**/" {
+			again = append(again, token)
+		}
+	}
+	var tokens []Token
+	for _, token := range expected {
+		if token.Type != CommentToken || token.Value != "/**/" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	for i := 0; i < len(tokens) && i < len(again); i++ {
+		if again[i].Type != tokens[i].Type || again[i].Value != tokens[i].Value {
+			t.Fatalf("token %d = %s %q, expected %s %q in %q", i, again[i].Type, again[i].Value, tokens[i].Type, tokens[i].Value, text)
+		}
+	}
+	if len(again) != len(tokens) {
+		t.Fatalf("%d tokens, expected %d in %q", len(again), len(tokens), text)
+	}
+}
+
+// unlexed returns a token read by the lexer without its raw data, with the
+// value expected by Token.String: the lexer includes the '%' of
+// percentages and the delimiters of comments in their values. Dimensions
+// with escapes keep their raw data, as the value does not separate the
+// number from the unit.
+func unlexed(token Token) Token {
+	switch token.Type {
+	case PercentageToken:
+		return Token{Type: token.Type, Value: strings.TrimSuffix(token.Value, "%")}
+	case CommentToken:
+		return Token{Type: token.Type, Value: commentText(token.Raw)}
+	case DimensionToken:
+		if string(token.Raw) != token.Value {
+			return Token{Type: token.Type, Value: token.Value, Raw: token.Raw}
+		}
+	}
+	return Token{Type: token.Type, Value: token.Value}
+}
+
+// commentText returns the text of a comment between its delimiters.
+func commentText(raw []rune) string {
+	text := string(raw[2:])
+	if len(raw) >= 4 {
+		text = strings.TrimSuffix(text, "*/")
+	}
+	return text
+}
+
+// equivalentTokens returns the tokens read from the serialization of a
+// token: bad strings and URLs are serialized as valid ones, URLs are
+// serialized with a quoted string, and comments are terminated.
+func equivalentTokens(token Token) []Token {
+	switch token.Type {
+	case CommentToken:
+		return []Token{{Type: CommentToken, Value: "/*" + commentText(token.Raw) + "*/"}}
+	case BadStringToken:
+		return []Token{{Type: StringToken, Value: token.Value}}
+	case BadUrlToken:
+		return []Token{{Type: UrlToken, Value: token.Value}}
+	case UrlToken:
+		return []Token{{Type: FunctionToken, Value: "url"}, {Type: StringToken, Value: token.Value}, {Type: RightParenthesisToken, Value: ")"}}
+	}
+	return []Token{token}
+}
+
+// FuzzPeek checks that Peek returns the token read by the next call to
+// Next, and does not change the tokens read.
+func FuzzPeek(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tokens := fuzzTokens(t, data)
+		lexer := NewLexer(NewInputBytes(data))
+
+		for i := 0; ; i++ {
+			var peeked Token
+			peek := len(data) > 0 && data[i%len(data)]&1 == 1
+			if peek {
+				peeked = lexer.Peek()
+				if again := lexer.Peek(); again.Type != peeked.Type || again.Value != peeked.Value {
+					t.Fatalf("token %d: Peek() = %s, then %s", i, peeked, again)
+				}
+			}
+			token := lexer.Next()
+			if peek && (token.Type != peeked.Type || token.Value != peeked.Value) {
+				t.Fatalf("token %d: Peek() = %s, Next() = %s", i, peeked, token)
+			}
+
+			if i == len(tokens) {
+				if token.Type != EOFToken {
+					t.Fatalf("token %d = %s, expected EOFToken", i, token)
+				}
+				return
+			}
+			if token.Type != tokens[i].Type || token.Value != tokens[i].Value || string(token.Raw) != string(tokens[i].Raw) {
+				t.Fatalf("token %d = %s, expected %s", i, token, tokens[i])
+			}
+		}
+	})
+}
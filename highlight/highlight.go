@@ -0,0 +1,286 @@
+// Package highlight classifies the tokens of a style sheet for syntax
+// highlighting and renders them as HTML or ANSI terminal output.
+//
+// Tokens are classified by their role rather than only by their type: an
+// identifier can be a property name, a selector or a keyword in a value,
+// and a hash token can be an ID selector or a color. The concatenated text
+// of the classified spans is always exactly the input.
+package highlight
+
+import (
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+// Class is the syntactic role of a span of text.
+type Class int
+
+const (
+	Text        Class = iota // Whitespace and unclassified text
+	Comment                  // /* comment */
+	Punctuation              // { } ( ) [ ] : ; ,
+	AtKeyword                // @media
+	Selector                 // Parts of a selector: a, .class, #id, :hover, >
+	Property                 // Property name in a declaration
+	Variable                 // Custom property name: --main-color
+	Keyword                  // Identifier in a value or an at-rule prelude
+	Function                 // Function name with its opening parenthesis
+	Number                   // Numbers, including the numeric part of dimensions
+	Unit                     // Unit of a dimension or percentage: px, %
+	String                   // "string"
+	URL                      // url(image.png)
+	Color                    // Hash in a value: #fff
+	Important                // !important
+	Operator                 // Delimiters in values: + - * /
+	Error                    // Bad strings and bad URLs
+)
+
+var classNames = [...]string{
+	Text:        "text",
+	Comment:     "comment",
+	Punctuation: "punctuation",
+	AtKeyword:   "at-keyword",
+	Selector:    "selector",
+	Property:    "property",
+	Variable:    "variable",
+	Keyword:     "keyword",
+	Function:    "function",
+	Number:      "number",
+	Unit:        "unit",
+	String:      "string",
+	URL:         "url",
+	Color:       "color",
+	Important:   "important",
+	Operator:    "operator",
+	Error:       "error",
+}
+
+func (c Class) String() string {
+	if c >= 0 && int(c) < len(classNames) {
+		return classNames[c]
+	}
+	return "unknown"
+}
+
+// Span is a classified part of the input.
+type Span struct {
+	Class Class
+	Text  string
+}
+
+// mode is the syntactic context of the highlighter.
+type mode int
+
+const (
+	statementStart mode = iota // before a rule or a declaration
+	selector                   // in the prelude of a qualified rule
+	atPrelude                  // in the prelude of an at-rule
+	afterProperty              // between a property name and its colon
+	value                      // in a declaration value
+)
+
+// Classify splits the input into classified spans.
+func Classify(input string) []Span {
+	lexer := csslexer.NewLexer(csslexer.NewInput(input))
+
+	var tokens []csslexer.Token
+	for {
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+
+	h := &highlighter{tokens: tokens, important: make(map[int]bool)}
+	for i := range tokens {
+		h.token(i)
+	}
+	return h.spans
+}
+
+type highlighter struct {
+	tokens    []csslexer.Token
+	spans     []Span
+	mode      mode
+	important map[int]bool // indices of the tokens of !important flags
+}
+
+func (h *highlighter) emit(class Class, text string) {
+	if text != "" {
+		h.spans = append(h.spans, Span{Class: class, Text: text})
+	}
+}
+
+func (h *highlighter) token(i int) {
+	token := h.tokens[i]
+	raw := string(token.Raw)
+
+	switch token.Type {
+	case csslexer.WhitespaceToken:
+		h.emit(Text, raw)
+		return
+	case csslexer.CommentToken:
+		h.emit(Comment, raw)
+		return
+	}
+
+	switch h.mode {
+	case statementStart:
+		switch {
+		case token.Type == csslexer.AtKeywordToken:
+			h.emit(AtKeyword, raw)
+			h.mode = atPrelude
+		case token.Type == csslexer.RightBraceToken || token.Type == csslexer.SemicolonToken:
+			h.emit(Punctuation, raw)
+		case h.startsRule(i):
+			h.mode = selector
+			h.selector(token, raw)
+		case token.Type == csslexer.IdentToken:
+			if strings.HasPrefix(token.Value, "--") {
+				h.emit(Variable, raw)
+			} else {
+				h.emit(Property, raw)
+			}
+			h.mode = afterProperty
+		default:
+			h.mode = value
+			h.value(i, token, raw)
+		}
+
+	case selector:
+		if token.Type == csslexer.LeftBraceToken {
+			h.emit(Punctuation, raw)
+			h.mode = statementStart
+			return
+		}
+		h.selector(token, raw)
+
+	case atPrelude:
+		switch token.Type {
+		case csslexer.LeftBraceToken, csslexer.SemicolonToken:
+			h.emit(Punctuation, raw)
+			h.mode = statementStart
+		default:
+			h.value(i, token, raw)
+		}
+
+	case afterProperty:
+		if token.Type == csslexer.ColonToken {
+			h.emit(Punctuation, raw)
+			h.mode = value
+			return
+		}
+		h.mode = value
+		h.value(i, token, raw)
+
+	case value:
+		switch token.Type {
+		case csslexer.SemicolonToken, csslexer.RightBraceToken:
+			h.emit(Punctuation, raw)
+			h.mode = statementStart
+		default:
+			h.value(i, token, raw)
+		}
+	}
+}
+
+// startsRule reports whether the statement starting at tokens[i] is a
+// rule rather than a declaration, i.e. a '{' comes before the end of the
+// statement.
+func (h *highlighter) startsRule(i int) bool {
+	depth := 0
+	for _, token := range h.tokens[i:] {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.LeftBraceToken:
+			return depth <= 0
+		case csslexer.SemicolonToken, csslexer.RightBraceToken:
+			if depth <= 0 {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func (h *highlighter) selector(token csslexer.Token, raw string) {
+	switch token.Type {
+	case csslexer.CommaToken, csslexer.LeftParenthesisToken, csslexer.RightParenthesisToken:
+		h.emit(Punctuation, raw)
+	case csslexer.StringToken:
+		h.emit(String, raw)
+	case csslexer.BadStringToken, csslexer.BadUrlToken:
+		h.emit(Error, raw)
+	case csslexer.NumberToken, csslexer.DimensionToken:
+		// An+B microsyntax, as in :nth-child(2n+1).
+		h.emit(Number, raw)
+	default:
+		h.emit(Selector, raw)
+	}
+}
+
+func (h *highlighter) value(i int, token csslexer.Token, raw string) {
+	if h.important[i] {
+		h.emit(Important, raw)
+		return
+	}
+
+	switch token.Type {
+	case csslexer.IdentToken:
+		if strings.HasPrefix(token.Value, "--") {
+			h.emit(Variable, raw)
+		} else {
+			h.emit(Keyword, raw)
+		}
+	case csslexer.FunctionToken:
+		h.emit(Function, raw)
+	case csslexer.HashToken:
+		h.emit(Color, raw)
+	case csslexer.NumberToken, csslexer.UnicodeRangeToken:
+		h.emit(Number, raw)
+	case csslexer.PercentageToken, csslexer.DimensionToken:
+		number, unit := tokenutil.SplitNumeric(raw)
+		h.emit(Number, number)
+		h.emit(Unit, unit)
+	case csslexer.StringToken:
+		h.emit(String, raw)
+	case csslexer.UrlToken:
+		h.emit(URL, raw)
+	case csslexer.BadStringToken, csslexer.BadUrlToken:
+		h.emit(Error, raw)
+	case csslexer.DelimiterToken:
+		if token.Value == "!" && h.markImportant(i) {
+			h.emit(Important, raw)
+			return
+		}
+		h.emit(Operator, raw)
+	case csslexer.AtKeywordToken:
+		h.emit(AtKeyword, raw)
+	default:
+		h.emit(Punctuation, raw)
+	}
+}
+
+// markImportant marks the identifier of an !important flag whose '!' is at
+// tokens[i], reporting whether there is one.
+func (h *highlighter) markImportant(i int) bool {
+	for j := i + 1; j < len(h.tokens); j++ {
+		switch token := h.tokens[j]; token.Type {
+		case csslexer.WhitespaceToken, csslexer.CommentToken:
+			continue
+		case csslexer.IdentToken:
+			if strings.EqualFold(token.Value, "important") {
+				h.important[j] = true
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
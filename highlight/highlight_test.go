@@ -0,0 +1,103 @@
+package highlight
+
+import (
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Span
+	}{
+		{
+			name:  "Rule with declarations",
+			input: "a#id.b:hover > p{color:#fff;margin:1.5em -2px 50%}",
+			expected: []Span{
+				{Selector, "a"}, {Selector, "#id"}, {Selector, "."}, {Selector, "b"}, {Selector, ":"}, {Selector, "hover"},
+				{Text, " "}, {Selector, ">"}, {Text, " "}, {Selector, "p"}, {Punctuation, "{"},
+				{Property, "color"}, {Punctuation, ":"}, {Color, "#fff"}, {Punctuation, ";"},
+				{Property, "margin"}, {Punctuation, ":"}, {Number, "1.5"}, {Unit, "em"}, {Text, " "},
+				{Number, "-2"}, {Unit, "px"}, {Text, " "}, {Number, "50"}, {Unit, "%"}, {Punctuation, "}"},
+			},
+		},
+		{
+			name:  "At-rule and nesting",
+			input: "@media screen{.a{--x:1;&:not(.b){top:var(--x)!important}}}",
+			expected: []Span{
+				{AtKeyword, "@media"}, {Text, " "}, {Keyword, "screen"}, {Punctuation, "{"},
+				{Selector, "."}, {Selector, "a"}, {Punctuation, "{"},
+				{Variable, "--x"}, {Punctuation, ":"}, {Number, "1"}, {Punctuation, ";"},
+				{Selector, "&"}, {Selector, ":"}, {Selector, "not("}, {Selector, "."}, {Selector, "b"}, {Punctuation, ")"}, {Punctuation, "{"},
+				{Property, "top"}, {Punctuation, ":"}, {Function, "var("}, {Variable, "--x"}, {Punctuation, ")"},
+				{Important, "!"}, {Important, "important"},
+				{Punctuation, "}"}, {Punctuation, "}"}, {Punctuation, "}"},
+			},
+		},
+		{
+			name:  "Strings, URLs and comments",
+			input: "@import 'a.css';/* c */b{background:url(x.png),\"s\"}",
+			expected: []Span{
+				{AtKeyword, "@import"}, {Text, " "}, {String, "'a.css'"}, {Punctuation, ";"}, {Comment, "/* c */"},
+				{Selector, "b"}, {Punctuation, "{"}, {Property, "background"}, {Punctuation, ":"},
+				{URL, "url(x.png)"}, {Punctuation, ","}, {String, "\"s\""}, {Punctuation, "}"},
+			},
+		},
+		{
+			name:  "Bad string",
+			input: "a{content:'x\n}",
+			expected: []Span{
+				{Selector, "a"}, {Punctuation, "{"}, {Property, "content"}, {Punctuation, ":"},
+				{Error, "'x"}, {Text, "\n"}, {Punctuation, "}"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Classify(tt.input); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Classify(%q) = %v, expected %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+const sample = ".a > b::before { content: \"<&>\"; color: #F00 !important }\n@media (x) {\n  /* multi\n  line */\n}\n"
+
+func TestHTML(t *testing.T) {
+	result := HTML(Classify("a{b:1px}"), HTMLOptions{Prefix: "hl-", Classes: map[Class]string{Punctuation: "", Unit: "u"}})
+	expected := `<span class="hl-selector">a</span>{<span class="hl-property">b</span>:<span class="hl-number">1</span><span class="hl-u">px</span>}`
+	if result != expected {
+		t.Errorf("HTML() = %q, expected %q", result, expected)
+	}
+
+	// Removing the tags and unescaping gives back the input.
+	rendered := HTML(Classify(sample), HTMLOptions{})
+	text := html.UnescapeString(regexp.MustCompile(`</?span[^>]*>`).ReplaceAllString(rendered, ""))
+	if text != sample {
+		t.Errorf("HTML() text = %q, expected %q", text, sample)
+	}
+	if strings.Contains(rendered, `"<&>"`) {
+		t.Errorf("HTML() = %q, expected escaped text", rendered)
+	}
+}
+
+func TestANSI(t *testing.T) {
+	result := ANSI(Classify("a{b:1px}"), Theme{Selector: "33", Unit: "1"})
+	expected := "\x1b[33ma\x1b[0m{b:1\x1b[1mpx\x1b[0m}"
+	if result != expected {
+		t.Errorf("ANSI() = %q, expected %q", result, expected)
+	}
+
+	rendered := ANSI(Classify(sample), DefaultTheme)
+	if text := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(rendered, ""); text != sample {
+		t.Errorf("ANSI() text = %q, expected %q", text, sample)
+	}
+	if !strings.Contains(rendered, "\x1b[90m/* multi\x1b[0m\n\x1b[90m  line */\x1b[0m") {
+		t.Errorf("ANSI() = %q, expected styles reset at line breaks", rendered)
+	}
+}
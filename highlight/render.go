@@ -0,0 +1,94 @@
+package highlight
+
+import (
+	"html"
+	"strings"
+)
+
+// HTMLOptions configures HTML rendering.
+type HTMLOptions struct {
+	// Prefix is prepended to the class names, which default to the names
+	// returned by Class.String. For example, properties are rendered as
+	// <span class="css-property"> with the prefix "css-".
+	Prefix string
+
+	// Classes overrides the class names of some classes, without the
+	// prefix. Classes mapped to an empty name are rendered as plain text.
+	Classes map[Class]string
+}
+
+// HTML renders spans as HTML, wrapping each classified span in a <span>
+// element. Whitespace is not wrapped, and all text is escaped. The output
+// is meant to be placed in a <pre> or <code> element.
+func HTML(spans []Span, opts HTMLOptions) string {
+	var sb strings.Builder
+	for _, span := range spans {
+		name := span.Class.String()
+		if custom, ok := opts.Classes[span.Class]; ok {
+			name = custom
+		}
+
+		text := html.EscapeString(span.Text)
+		if span.Class == Text || name == "" {
+			sb.WriteString(text)
+			continue
+		}
+
+		sb.WriteString(`<span class="`)
+		sb.WriteString(html.EscapeString(opts.Prefix + name))
+		sb.WriteString(`">`)
+		sb.WriteString(text)
+		sb.WriteString(`</span>`)
+	}
+	return sb.String()
+}
+
+// Theme maps classes to ANSI SGR parameters, such as "1;34" for bold blue.
+// Classes missing from the theme are not styled.
+type Theme map[Class]string
+
+// DefaultTheme uses the 16 basic terminal colors.
+var DefaultTheme = Theme{
+	Comment:   "90",
+	AtKeyword: "35",
+	Selector:  "33",
+	Property:  "36",
+	Variable:  "3;36",
+	Keyword:   "32",
+	Function:  "34",
+	Number:    "31",
+	Unit:      "31",
+	String:    "32",
+	URL:       "4;32",
+	Color:     "95",
+	Important: "1;31",
+	Error:     "4;91",
+}
+
+// ANSI renders spans with ANSI escape sequences. Styles are reset at line
+// breaks so that each line is styled independently, as some terminals and
+// pagers expect.
+func ANSI(spans []Span, theme Theme) string {
+	var sb strings.Builder
+	for _, span := range spans {
+		sgr, ok := theme[span.Class]
+		if !ok || sgr == "" {
+			sb.WriteString(span.Text)
+			continue
+		}
+
+		lines := strings.SplitAfter(span.Text, "\n")
+		for _, line := range lines {
+			text := strings.TrimSuffix(line, "\n")
+			if text != "" {
+				sb.WriteString("\x1b[" + sgr + "m")
+				sb.WriteString(text)
+				sb.WriteString("\x1b[0m")
+			}
+			if len(text) < len(line) {
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	return sb.String()
+}
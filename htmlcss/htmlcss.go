@@ -0,0 +1,346 @@
+// Package htmlcss finds the style sheets embedded in HTML documents, in
+// <style> elements and style attributes, and tokenizes them with positions
+// in the document.
+//
+// The HTML scanner is not a full HTML parser: it recognizes tags,
+// attributes, comments and the raw text of elements such as <script>, which
+// is enough to locate embedded CSS in well-formed documents and templates.
+package htmlcss
+
+import (
+	"bytes"
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+// Kind is where a style sheet is embedded.
+type Kind int
+
+const (
+	StyleElement   Kind = iota // the contents of a <style> element
+	StyleAttribute             // the value of a style attribute
+)
+
+func (k Kind) String() string {
+	switch k {
+	case StyleElement:
+		return "style-element"
+	case StyleAttribute:
+		return "style-attribute"
+	}
+	return "unknown"
+}
+
+// Document is a scanned HTML document.
+type Document struct {
+	Regions []*Region // The embedded style sheets, in document order
+
+	lines []int // The byte offsets of the start of each line
+}
+
+// Region is a style sheet embedded in a document.
+type Region struct {
+	Kind    Kind
+	Element string // The lowercase name of the element holding the region
+
+	// Start and End are the byte offsets in the document of the region:
+	// the contents of the element, or the attribute value without quotes.
+	Start, End int
+
+	// CSS is the style sheet text. Character references are decoded in
+	// attribute values.
+	CSS string
+
+	offsets []int // The byte offset in the document of each rune of CSS, and of its end
+	doc     *Document
+}
+
+// Position is a position in a document.
+type Position struct {
+	Offset int // Byte offset, starting at 0
+	Line   int // Line number, starting at 1
+	Column int // Column number in bytes, starting at 1
+}
+
+// Token is a token of an embedded style sheet, with its position in the
+// document.
+type Token struct {
+	csslexer.Token
+	Start, End Position
+}
+
+// rawText are the elements whose contents are not markup.
+var rawText = map[string]bool{
+	"style": true, "script": true, "textarea": true, "title": true,
+	"xmp": true, "iframe": true, "noembed": true, "noframes": true,
+}
+
+// Parse scans an HTML document for embedded style sheets.
+func Parse(src []byte) *Document {
+	d := &Document{lines: []int{0}}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' || src[i] == '\r' && (i+1 == len(src) || src[i+1] != '\n') {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	s := &scanner{src: src, doc: d}
+	s.scan()
+	return d
+}
+
+// Position returns the position of a byte offset in the document.
+func (d *Document) Position(offset int) Position {
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	return Position{Offset: offset, Line: line + 1, Column: offset - d.lines[line] + 1}
+}
+
+// Offset returns the byte offset in the document of the rune at index i of
+// CSS. An index equal to the number of runes gives the end of the region.
+func (r *Region) Offset(i int) int {
+	return r.offsets[i]
+}
+
+// Tokens tokenizes the region.
+func (r *Region) Tokens() []Token {
+	var tokens []Token
+
+	lexer := csslexer.NewLexer(csslexer.NewInput(r.CSS))
+	i := 0
+	for {
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			return tokens
+		}
+		start, end := i, i+len(token.Raw)
+		tokens = append(tokens, Token{
+			Token: token,
+			Start: r.doc.Position(r.offsets[start]),
+			End:   r.doc.Position(r.offsets[end]),
+		})
+		i = end
+	}
+}
+
+type scanner struct {
+	src []byte
+	pos int
+	doc *Document
+}
+
+func (s *scanner) scan() {
+	for s.pos < len(s.src) {
+		i := bytes.IndexByte(s.src[s.pos:], '<')
+		if i < 0 {
+			return
+		}
+		s.pos += i
+
+		rest := s.src[s.pos:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			s.skipPast("-->", 4)
+		case bytes.HasPrefix(rest, []byte("</")), bytes.HasPrefix(rest, []byte("<!")), bytes.HasPrefix(rest, []byte("<?")):
+			s.skipPast(">", 2)
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			s.tag()
+		default:
+			s.pos++
+		}
+	}
+}
+
+// skipPast moves past the next occurrence of end, searching from skip bytes
+// after the current position, or to the end of the input.
+func (s *scanner) skipPast(end string, skip int) {
+	i := bytes.Index(s.src[s.pos+skip:], []byte(end))
+	if i < 0 {
+		s.pos = len(s.src)
+		return
+	}
+	s.pos += skip + i + len(end)
+}
+
+// tag scans a start tag, and the raw text following it.
+func (s *scanner) tag() {
+	s.pos++ // '<'
+	start := s.pos
+	for s.pos < len(s.src) && !isSpace(s.src[s.pos]) && s.src[s.pos] != '/' && s.src[s.pos] != '>' {
+		s.pos++
+	}
+	name := strings.ToLower(string(s.src[start:s.pos]))
+
+	for {
+		for s.pos < len(s.src) && (isSpace(s.src[s.pos]) || s.src[s.pos] == '/') {
+			s.pos++
+		}
+		if s.pos >= len(s.src) {
+			return
+		}
+		if s.src[s.pos] == '>' {
+			s.pos++
+			break
+		}
+		s.attribute(name)
+	}
+
+	if rawText[name] {
+		s.rawText(name)
+	}
+}
+
+// attribute scans an attribute, adding a region for style attributes.
+func (s *scanner) attribute(element string) {
+	start := s.pos
+	s.pos++ // The first character may be '='.
+	for s.pos < len(s.src) && !isSpace(s.src[s.pos]) && s.src[s.pos] != '/' && s.src[s.pos] != '>' && s.src[s.pos] != '=' {
+		s.pos++
+	}
+	name := strings.ToLower(string(s.src[start:s.pos]))
+
+	s.skipSpace()
+	if s.pos >= len(s.src) || s.src[s.pos] != '=' {
+		return
+	}
+	s.pos++
+	s.skipSpace()
+	if s.pos >= len(s.src) {
+		return
+	}
+
+	var valueStart, valueEnd int
+	if quote := s.src[s.pos]; quote == '"' || quote == '\'' {
+		valueStart = s.pos + 1
+		i := bytes.IndexByte(s.src[valueStart:], quote)
+		if i < 0 {
+			valueEnd, s.pos = len(s.src), len(s.src)
+		} else {
+			valueEnd, s.pos = valueStart+i, valueStart+i+1
+		}
+	} else {
+		valueStart = s.pos
+		for s.pos < len(s.src) && !isSpace(s.src[s.pos]) && s.src[s.pos] != '>' {
+			s.pos++
+		}
+		valueEnd = s.pos
+	}
+
+	if name == "style" {
+		s.region(StyleAttribute, element, valueStart, valueEnd, true)
+	}
+}
+
+// rawText skips the contents of a raw text element up to its end tag,
+// adding a region for <style> elements.
+func (s *scanner) rawText(element string) {
+	start, end := s.pos, len(s.src)
+	for i := start; i < len(s.src); i++ {
+		if s.src[i] == '<' && isEndTag(s.src[i:], element) {
+			end = i
+			break
+		}
+	}
+	s.pos = end
+
+	if element == "style" {
+		s.region(StyleElement, element, start, end, false)
+	}
+}
+
+// isEndTag reports whether b starts with the end tag of the element.
+func isEndTag(b []byte, element string) bool {
+	n := len(element) + 2
+	if len(b) < n || b[1] != '/' || !strings.EqualFold(string(b[2:n]), element) {
+		return false
+	}
+	return len(b) == n || isSpace(b[n]) || b[n] == '/' || b[n] == '>'
+}
+
+// region adds a region, decoding character references if needed.
+func (s *scanner) region(kind Kind, element string, start, end int, decode bool) {
+	var css strings.Builder
+	var offsets []int
+
+	for i := start; i < end; {
+		if decode && s.src[i] == '&' {
+			if text, n := reference(s.src[i:end]); n > 0 {
+				for range text {
+					offsets = append(offsets, i)
+				}
+				css.WriteString(text)
+				i += n
+				continue
+			}
+		}
+		r, n := utf8.DecodeRune(s.src[i:end])
+		offsets = append(offsets, i)
+		css.WriteRune(r)
+		i += n
+	}
+
+	s.doc.Regions = append(s.doc.Regions, &Region{
+		Kind:    kind,
+		Element: element,
+		Start:   start,
+		End:     end,
+		CSS:     css.String(),
+		offsets: append(offsets, end),
+		doc:     s.doc,
+	})
+}
+
+// reference decodes the character reference at the start of b, returning
+// the decoded text and the length of the reference, or 0 if b does not
+// start with a character reference.
+//
+// As in attribute values, named references without a semicolon are only
+// decoded if the name is not followed by more alphanumeric characters.
+func reference(b []byte) (string, int) {
+	n := 1
+	if n < len(b) && b[n] == '#' {
+		n++
+		if n < len(b) && (b[n] == 'x' || b[n] == 'X') {
+			n++
+		}
+	}
+	for n < len(b) && isASCIIAlphanumeric(b[n]) {
+		n++
+	}
+	if n < len(b) && b[n] == ';' {
+		n++
+	}
+
+	text := string(b[:n])
+	decoded := html.UnescapeString(text)
+	if decoded == text {
+		return "", 0
+	}
+	if !strings.HasSuffix(text, ";") && len(text) > 1 && text[1] != '#' && html.UnescapeString(text+";") != decoded {
+		// A legacy reference followed by alphanumeric characters, such as
+		// "&ampx".
+		return "", 0
+	}
+	return decoded, n
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.src) && isSpace(s.src[s.pos]) {
+		s.pos++
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isASCIIAlphanumeric(c byte) bool {
+	return isASCIILetter(c) || c >= '0' && c <= '9'
+}
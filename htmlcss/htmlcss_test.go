@@ -0,0 +1,114 @@
+package htmlcss
+
+import (
+	"testing"
+)
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+  <!-- <style>ignored</style> -->
+  <STYLE type="text/css">
+    a { color: red }
+    b::after { content: "</styles>" }
+  </Style >
+  <script>var s = "<style>x{}</style>";</script>
+</head>
+<body style="margin:0">
+  <p class=x style='font-family: &quot;A&amp;B&quot;, serif' title="<style>">Hi</p>
+  <div style=color:blue data-x></div>
+</body>
+</html>
+`
+
+func TestParse(t *testing.T) {
+	doc := Parse([]byte(page))
+
+	expected := []struct {
+		kind    Kind
+		element string
+		css     string
+	}{
+		{StyleElement, "style", "\n    a { color: red }\n    b::after { content: \"</styles>\" }\n  "},
+		{StyleAttribute, "body", "margin:0"},
+		{StyleAttribute, "p", `font-family: "A&B", serif`},
+		{StyleAttribute, "div", "color:blue"},
+	}
+	if len(doc.Regions) != len(expected) {
+		for _, r := range doc.Regions {
+			t.Logf("%s %s %q", r.Kind, r.Element, r.CSS)
+		}
+		t.Fatalf("got %d regions, expected %d", len(doc.Regions), len(expected))
+	}
+	for i, r := range doc.Regions {
+		e := expected[i]
+		if r.Kind != e.kind || r.Element != e.element || r.CSS != e.css {
+			t.Errorf("region %d = %s %s %q, expected %s %s %q", i, r.Kind, r.Element, r.CSS, e.kind, e.element, e.css)
+		}
+		if r.Kind == StyleElement && page[r.Start:r.End] != r.CSS {
+			t.Errorf("region %d source = %q, expected %q", i, page[r.Start:r.End], r.CSS)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	doc := Parse([]byte(page))
+
+	tests := []struct {
+		region int
+		index  int
+		raw    string
+		start  Position
+		end    Position
+	}{
+		{0, 1, "a", Position{Offset: 94, Line: 6, Column: 5}, Position{Offset: 95, Line: 6, Column: 6}},
+		{0, 8, "red", Position{Offset: 105, Line: 6, Column: 16}, Position{Offset: 108, Line: 6, Column: 19}},
+		{1, 0, "margin", Position{Offset: 231, Line: 11, Column: 14}, Position{Offset: 237, Line: 11, Column: 20}},
+		// The string spans the character references.
+		{2, 3, `"A&B"`, Position{Offset: 275, Line: 12, Column: 34}, Position{Offset: 294, Line: 12, Column: 53}},
+		{2, 6, "serif", Position{Offset: 296, Line: 12, Column: 55}, Position{Offset: 301, Line: 12, Column: 60}},
+	}
+
+	for _, tt := range tests {
+		tokens := doc.Regions[tt.region].Tokens()
+		token := tokens[tt.index]
+		if string(token.Raw) != tt.raw || token.Start != tt.start || token.End != tt.end {
+			t.Errorf("region %d token %d = %q %+v-%+v, expected %q %+v-%+v",
+				tt.region, tt.index, string(token.Raw), token.Start, token.End, tt.raw, tt.start, tt.end)
+		}
+	}
+}
+
+func TestReference(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		length   int
+	}{
+		{"&amp;", "&", 5},
+		{"&amp", "&", 4},
+		{"&ampx", "", 0},
+		{"&#65;", "A", 5},
+		{"&#x42", "B", 5},
+		{"&unknown;", "", 0},
+		{"& b", "", 0},
+	}
+
+	for _, tt := range tests {
+		text, n := reference([]byte(tt.input))
+		if text != tt.expected || n != tt.length {
+			t.Errorf("reference(%q) = %q, %d, expected %q, %d", tt.input, text, n, tt.expected, tt.length)
+		}
+	}
+}
+
+func TestUnterminated(t *testing.T) {
+	doc := Parse([]byte("<style>a{}\n<div style=\"b:c"))
+	if len(doc.Regions) != 1 || doc.Regions[0].CSS != "a{}\n<div style=\"b:c" {
+		t.Fatalf("Parse() regions = %v", doc.Regions)
+	}
+	tokens := doc.Regions[0].Tokens()
+	if last := tokens[len(tokens)-1]; last.End.Offset != 26 || last.End.Line != 2 {
+		t.Errorf("last token ends at %+v, expected offset 26 on line 2", last.End)
+	}
+}
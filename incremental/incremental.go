@@ -0,0 +1,169 @@
+// Package incremental keeps the tokens of a document up to date as it is
+// edited, re-lexing only the part of the text affected by each edit.
+//
+// The CSS tokenizer carries no state from one token to the next, so
+// tokenizing from any token boundary gives the same tokens as tokenizing
+// the whole text. After an edit, lexing restarts at a token boundary just
+// before the edit, and stops as soon as a new token starts at the shifted
+// position of an old token boundary after the edit: from there on the
+// text, and therefore the tokens, are unchanged. Edits that open or close
+// a string or a comment are re-lexed as far as their effect reaches.
+package incremental
+
+import (
+	"go.baoshuo.dev/csslexer"
+)
+
+// lookahead is the number of code points the tokenizer may inspect past
+// the end of a token to decide where the token ends.
+const lookahead = 4
+
+// Edit replaces the text between the rune offsets Start and End with Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// Change describes how the token list changed after an edit: the old
+// tokens [Start, OldEnd) were replaced by the new tokens [Start, NewEnd).
+type Change struct {
+	Start, OldEnd, NewEnd int
+}
+
+// Document is a text with its tokens.
+type Document struct {
+	text   []rune
+	tokens []csslexer.Token
+	starts []int // rune offset of each token, followed by the length of the text
+}
+
+// NewDocument tokenizes text into a new Document.
+func NewDocument(text string) *Document {
+	d := &Document{}
+	d.text = []rune(text)
+	d.tokens, d.starts, _ = tokenize(d.text, 0, nil)
+	d.starts = append(d.starts, len(d.text))
+	return d
+}
+
+// FromTokens creates a Document from the complete token list of a text,
+// as returned by a lexer with raw text.
+func FromTokens(tokens []csslexer.Token) *Document {
+	d := &Document{tokens: tokens, starts: make([]int, 0, len(tokens)+1)}
+	for _, token := range tokens {
+		d.starts = append(d.starts, len(d.text))
+		d.text = append(d.text, token.Raw...)
+	}
+	d.starts = append(d.starts, len(d.text))
+	return d
+}
+
+// Relex applies an edit to the text of tokens and returns the new tokens,
+// reusing the tokens that are not affected by the edit.
+func Relex(tokens []csslexer.Token, edit Edit) ([]csslexer.Token, Change) {
+	d := FromTokens(tokens)
+	change := d.Apply(edit)
+	return d.tokens, change
+}
+
+// Text returns the current text of the document.
+func (d *Document) Text() string {
+	return string(d.text)
+}
+
+// Runes returns the current text of the document as runes. The slice must
+// not be modified, and is replaced, not changed, by Apply.
+func (d *Document) Runes() []rune {
+	return d.text
+}
+
+// Tokens returns the current tokens of the document. The slice must not
+// be modified.
+func (d *Document) Tokens() []csslexer.Token {
+	return d.tokens
+}
+
+// Offset returns the rune offset of the i-th token, or the length of the
+// text for i == len(Tokens()).
+func (d *Document) Offset(i int) int {
+	return d.starts[i]
+}
+
+// Apply applies an edit to the document and re-lexes the affected tokens.
+// It panics if the edit range is out of bounds.
+func (d *Document) Apply(edit Edit) Change {
+	if edit.Start < 0 || edit.Start > edit.End || edit.End > len(d.text) {
+		panic("incremental: edit out of range")
+	}
+
+	insert := []rune(edit.Text)
+	delta := len(insert) - (edit.End - edit.Start)
+
+	text := make([]rune, 0, len(d.text)+delta)
+	text = append(text, d.text[:edit.Start]...)
+	text = append(text, insert...)
+	text = append(text, d.text[edit.End:]...)
+
+	// Restart at the last token boundary whose preceding token could not
+	// have looked at the edited text.
+	start := 0
+	for i := len(d.tokens) - 1; i > 0; i-- {
+		if d.starts[i]+lookahead <= edit.Start {
+			start = i
+			break
+		}
+	}
+
+	// Lex until a new token starts where an old token after the edit
+	// started, shifted by the edit.
+	old := start
+	resync := func(pos int) bool {
+		for old < len(d.starts) && (d.starts[old] < edit.End || d.starts[old]+delta < pos) {
+			old++
+		}
+		return old < len(d.starts) && d.starts[old]+delta == pos
+	}
+	relexed, relexedStarts, stopped := tokenize(text, d.starts[start], resync)
+	if !stopped {
+		old = len(d.tokens)
+	}
+
+	tokens := make([]csslexer.Token, 0, start+len(relexed)+len(d.tokens)-old)
+	tokens = append(tokens, d.tokens[:start]...)
+	tokens = append(tokens, relexed...)
+	tokens = append(tokens, d.tokens[old:]...)
+
+	starts := make([]int, 0, len(tokens)+1)
+	starts = append(starts, d.starts[:start]...)
+	starts = append(starts, relexedStarts...)
+	for _, s := range d.starts[old:] {
+		starts = append(starts, s+delta)
+	}
+
+	d.text, d.tokens, d.starts = text, tokens, starts
+
+	return Change{Start: start, OldEnd: old, NewEnd: start + len(relexed)}
+}
+
+// tokenize lexes text from the rune offset from until the end, or until
+// stop reports true for the offset of the next token. It returns the
+// tokens, their offsets, and whether stop ended the lexing.
+func tokenize(text []rune, from int, stop func(pos int) bool) ([]csslexer.Token, []int, bool) {
+	lexer := csslexer.NewLexer(csslexer.NewInputRunes(text[from:]))
+
+	var tokens []csslexer.Token
+	var starts []int
+	pos := from
+	for {
+		if stop != nil && stop(pos) {
+			return tokens, starts, true
+		}
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			return tokens, starts, false
+		}
+		tokens = append(tokens, token)
+		starts = append(starts, pos)
+		pos += len(token.Raw)
+	}
+}
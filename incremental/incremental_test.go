@@ -0,0 +1,138 @@
+package incremental
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+func lex(text string) []csslexer.Token {
+	lexer := csslexer.NewLexer(csslexer.NewInput(text))
+
+	var tokens []csslexer.Token
+	for token := lexer.Next(); token.Type != csslexer.EOFToken; token = lexer.Next() {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func checkTokens(t *testing.T, d *Document) {
+	t.Helper()
+
+	expected := lex(d.Text())
+	if len(d.Tokens()) != len(expected) || len(expected) > 0 && !reflect.DeepEqual(d.Tokens(), expected) {
+		t.Fatalf("Tokens() after edits of %q = %v, expected %v", d.Text(), d.Tokens(), expected)
+	}
+	offset := 0
+	for i, token := range d.Tokens() {
+		if d.Offset(i) != offset {
+			t.Fatalf("Offset(%d) = %d, expected %d", i, d.Offset(i), offset)
+		}
+		offset += len(token.Raw)
+	}
+	if d.Offset(len(d.Tokens())) != offset {
+		t.Fatalf("Offset(%d) = %d, expected %d", len(d.Tokens()), d.Offset(len(d.Tokens())), offset)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		edit     Edit
+		expected Change
+	}{
+		{
+			name:     "Edit inside an identifier",
+			text:     "a { color: red; margin: 0 }",
+			edit:     Edit{Start: 12, End: 15, Text: "blue"},
+			expected: Change{Start: 4, OldEnd: 9, NewEnd: 8},
+		},
+		{
+			name:     "Merging tokens",
+			text:     "a { margin: 1 px }",
+			edit:     Edit{Start: 13, End: 14},
+			expected: Change{Start: 4, OldEnd: 10, NewEnd: 8},
+		},
+		{
+			name:     "Opening a comment",
+			text:     "a { b: c } d { e: f }",
+			edit:     Edit{Start: 4, End: 4, Text: "/*"},
+			expected: Change{Start: 0, OldEnd: 21, NewEnd: 5},
+		},
+		{
+			name:     "Closing a comment",
+			text:     "a { /* b: c } d { e: f }",
+			edit:     Edit{Start: 12, End: 12, Text: "*/"},
+			expected: Change{Start: 4, OldEnd: 5, NewEnd: 17},
+		},
+		{
+			name:     "Opening a string",
+			text:     "a { content: x; b: c }",
+			edit:     Edit{Start: 13, End: 13, Text: "'"},
+			expected: Change{Start: 4, OldEnd: 16, NewEnd: 8},
+		},
+		{
+			name:     "Edit at the end",
+			text:     "a { b: c }",
+			edit:     Edit{Start: 10, End: 10, Text: " d"},
+			expected: Change{Start: 6, OldEnd: 10, NewEnd: 12},
+		},
+		{
+			name:     "Deleting everything",
+			text:     "a { b: c }",
+			edit:     Edit{Start: 0, End: 10},
+			expected: Change{Start: 0, OldEnd: 10, NewEnd: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDocument(tt.text)
+			change := d.Apply(tt.edit)
+			checkTokens(t, d)
+			if change != tt.expected {
+				t.Errorf("Apply(%v) = %v, expected %v", tt.edit, change, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRelex(t *testing.T) {
+	tokens, change := Relex(lex("a{b:c}"), Edit{Start: 4, End: 5, Text: "d e"})
+	if expected := lex("a{b:d e}"); !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Relex() = %v, expected %v", tokens, expected)
+	}
+	if expected := (Change{Start: 0, OldEnd: 5, NewEnd: 7}); change != expected {
+		t.Errorf("Relex() change = %v, expected %v", change, expected)
+	}
+}
+
+// TestRandomEdits compares incremental updates with a full re-tokenization
+// after each of a series of random edits.
+func TestRandomEdits(t *testing.T) {
+	fragments := []string{
+		"a", "-", "--", "1", ".5", "e", "e-", "%", "px", " ", "\n", "{", "}", "(", ")", "[", "]",
+		":", ";", ",", "'", "\"", "\\", "/*", "*/", "/", "*", "url(", "u+", "<!--", "-->", "#", "@", "é",
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < 200; round++ {
+		d := NewDocument("a { color: red; background: url(x.png) } /* c */ @media (x) { 'y' }")
+		for step := 0; step < 20; step++ {
+			length := len([]rune(d.Text()))
+			start := r.Intn(length + 1)
+			end := start + r.Intn(length-start+1)%4
+
+			text := ""
+			for n := r.Intn(3); n > 0; n-- {
+				text += fragments[r.Intn(len(fragments))]
+			}
+
+			d.Apply(Edit{Start: start, End: end, Text: text})
+			checkTokens(t, d)
+		}
+	}
+}
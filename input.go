@@ -18,6 +18,9 @@ type Input struct {
 	pos   int    // The current position in the input stream.
 	start int    // The start position of the current token being read.
 	err   error  // Any error encountered while reading the input.
+	slots []int  // The positions of the template slots, nil if not a template.
+
+	release func() error // Releases the mapped runes of a file, see NewInputFile.
 }
 
 // NewInput creates a new Input instance from the given string.
@@ -67,6 +70,43 @@ func NewInputReader(r io.Reader) *Input {
 	return NewInputBytes(b)
 }
 
+// Reset resets the Input to read the given string, reusing the memory of
+// its runes, including a slice given to NewInputRunes. Tokens read from
+// the Input share that memory, so they must not be used after Reset. The
+// memory of an Input created by NewInputFile is not reused, and is still
+// released by Close.
+func (z *Input) Reset(input string) {
+	runes := z.reusableRunes()
+	for _, r := range input {
+		if r == 0x00 { // U+0000 NULL CHARACTER
+			r = '\uFFFD' // Replace with U+FFFD REPLACEMENT CHARACTER
+		}
+		runes = append(runes, r)
+	}
+	*z = Input{runes: runes, release: z.release}
+}
+
+// ResetBytes is like Reset, but reads the given byte slice.
+func (z *Input) ResetBytes(input []byte) {
+	runes := z.reusableRunes()
+	for _, r := range string(input) {
+		if r == 0x00 { // U+0000 NULL CHARACTER
+			r = '\uFFFD' // Replace with U+FFFD REPLACEMENT CHARACTER
+		}
+		runes = append(runes, r)
+	}
+	*z = Input{runes: runes, release: z.release}
+}
+
+// reusableRunes returns the runes of the Input emptied, or nil if they
+// are released by Close.
+func (z *Input) reusableRunes() []rune {
+	if z.release != nil {
+		return nil
+	}
+	return z.runes[:0]
+}
+
 // PeekErr checks if there is an error at the current position plus the specified offset.
 func (z *Input) PeekErr(pos int) error {
 	if z.err != nil {
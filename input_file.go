@@ -0,0 +1,63 @@
+package csslexer
+
+import (
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// NewInputFile creates a new Input instance reading the named file.
+//
+// On Linux, the file is memory-mapped and decoded straight into a rune
+// buffer that is mapped as well, so neither the file nor its runes are
+// copied through the Go heap. On other systems, or when mapping fails, the
+// file is read into memory as with NewInputBytes.
+//
+// The Input must be closed with Close to release the mapping. The Raw
+// slices of the tokens share the memory of the Input, so they must not be
+// used after Close unless they are copied with Token.Detach. Token values
+// are always independent of the Input.
+func NewInputFile(name string) (*Input, error) {
+	return openInputFile(name)
+}
+
+// Close releases the memory of an Input created by NewInputFile, after
+// which the Input is empty. It does nothing for other inputs.
+func (z *Input) Close() error {
+	if z.release == nil {
+		return nil
+	}
+	err := z.release()
+	*z = Input{runes: nullRune}
+	return err
+}
+
+// readInputFile reads a file into a new Input, when it cannot be mapped.
+func readInputFile(f *os.File) (*Input, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := make([]rune, utf8.RuneCount(data))
+	decodeRunes(runes, data)
+	return &Input{runes: runes}, nil
+}
+
+// decodeRunes decodes UTF-8 data into runes, which must have room for
+// utf8.RuneCount(data) runes, replacing U+0000 NULL and invalid bytes with
+// U+FFFD as NewInputBytes does.
+func decodeRunes(runes []rune, data []byte) {
+	i := 0
+	for len(data) > 0 {
+		r, size := rune(data[0]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(data)
+		} else if r == 0x00 { // U+0000 NULL CHARACTER
+			r = '\uFFFD' // Replace with U+FFFD REPLACEMENT CHARACTER
+		}
+		runes[i] = r
+		i++
+		data = data[size:]
+	}
+}
@@ -0,0 +1,57 @@
+//go:build linux
+// +build linux
+
+package csslexer
+
+import (
+	"os"
+	"syscall"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// maxMappedRunes is the maximum number of runes of a mapped Input.
+const maxMappedRunes = 1 << 28
+
+// openInputFile maps the file and decodes it into an anonymous mapping.
+func openInputFile(name string) (*Input, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 || size != int64(int(size)) || !info.Mode().IsRegular() {
+		return readInputFile(f)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return readInputFile(f)
+	}
+	defer syscall.Munmap(data)
+
+	n := utf8.RuneCount(data)
+	if n > maxMappedRunes {
+		return readInputFile(f)
+	}
+	mem, err := syscall.Mmap(-1, 0, n*4, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return readInputFile(f)
+	}
+
+	runes := (*[maxMappedRunes]rune)(unsafe.Pointer(&mem[0]))[:n:n]
+	decodeRunes(runes, data)
+
+	return &Input{
+		runes: runes,
+		release: func() error {
+			return syscall.Munmap(mem)
+		},
+	}, nil
+}
@@ -0,0 +1,54 @@
+//go:build linux
+// +build linux
+
+package csslexer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// isMapped reports whether the address is in a mapping of the process.
+func isMapped(t *testing.T, addr uintptr) bool {
+	data, err := os.ReadFile("/proc/self/maps")
+	if err != nil {
+		t.Skipf("cannot read the mappings: %v", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var start, end uintptr
+		if _, err := fmt.Sscanf(line, "%x-%x", &start, &end); err == nil && start <= addr && addr < end {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInputFileClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.css")
+	if err := os.WriteFile(name, []byte("a { color: red }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := NewInputFile(name)
+	if err != nil {
+		t.Fatalf("NewInputFile() error: %v", err)
+	}
+	if input.release == nil {
+		t.Fatalf("NewInputFile() did not map the file")
+	}
+	addr := uintptr(unsafe.Pointer(&input.runes[0]))
+	if !isMapped(t, addr) {
+		t.Fatalf("the runes at %#x are not mapped", addr)
+	}
+
+	if err := input.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if isMapped(t, addr) {
+		t.Errorf("the runes at %#x are still mapped after Close()", addr)
+	}
+}
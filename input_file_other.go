@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+package csslexer
+
+import (
+	"os"
+)
+
+// openInputFile reads the file, as memory mapping is only supported on
+// Linux.
+func openInputFile(name string) (*Input, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readInputFile(f)
+}
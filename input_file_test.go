@@ -0,0 +1,77 @@
+package csslexer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestNewInputFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Empty", ""},
+		{"ASCII", "a { color: red; background: url(a.png) }"},
+		{"Non-ASCII", "a::before { content: \"é\U0001F600\" } .日本 {}"},
+		{"NULs and invalid UTF-8", "a\x00b { c: \"\xff\xfe\" } \xe2\x82"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(dir, tt.name+".css")
+			if err := os.WriteFile(name, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			input, err := NewInputFile(name)
+			if err != nil {
+				t.Fatalf("NewInputFile() error: %v", err)
+			}
+			if mapped := input.release != nil; mapped != (runtime.GOOS == "linux" && tt.content != "") {
+				t.Errorf("NewInputFile() mapped the file: %v", mapped)
+			}
+			expected := NewInputBytes([]byte(tt.content))
+			if string(input.runes) != string(expected.runes) {
+				t.Fatalf("NewInputFile() runes = %q, expected %q", string(input.runes), string(expected.runes))
+			}
+
+			lexer := NewLexer(input)
+			var tokens []Token
+			for token := lexer.Next(); token.Type != EOFToken; token = lexer.Next() {
+				tokens = append(tokens, token.Detach())
+			}
+			if err := input.Close(); err != nil {
+				t.Fatalf("Close() error: %v", err)
+			}
+			if input.Peek(0) != EOF || input.Close() != nil {
+				t.Errorf("Input is not empty after Close()")
+			}
+
+			var raw []rune
+			for _, token := range tokens {
+				raw = append(raw, token.Raw...)
+			}
+			if string(raw) != string(expected.runes) {
+				t.Errorf("detached tokens = %q, expected %q", string(raw), string(expected.runes))
+			}
+		})
+	}
+
+	if _, err := NewInputFile(filepath.Join(dir, "missing.css")); err == nil {
+		t.Errorf("NewInputFile() of a missing file returned no error")
+	}
+}
+
+func TestTokenDetach(t *testing.T) {
+	input := NewInput("abc")
+	token := NewLexer(input).Next()
+	detached := token.Detach()
+
+	input.Reset("xyz")
+	if string(token.Raw) != "xyz" || string(detached.Raw) != "abc" || detached.Value != "abc" {
+		t.Errorf("Detach() = %q, shared the input", string(detached.Raw))
+	}
+}
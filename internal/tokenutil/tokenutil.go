@@ -0,0 +1,97 @@
+// Package tokenutil has the helpers on token sequences shared by the
+// packages of the module.
+package tokenutil
+
+import (
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+// IsCustomPropertyName reports whether the name is a custom property name.
+func IsCustomPropertyName(name string) bool {
+	return len(name) > 2 && strings.HasPrefix(name, "--")
+}
+
+// IsBlank reports whether the token is whitespace or a comment.
+func IsBlank(token csslexer.Token) bool {
+	return token.Type == csslexer.WhitespaceToken || token.Type == csslexer.CommentToken
+}
+
+// TrimWhitespace removes the leading and trailing whitespace tokens.
+func TrimWhitespace(tokens []csslexer.Token) []csslexer.Token {
+	for len(tokens) > 0 && tokens[0].Type == csslexer.WhitespaceToken {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].Type == csslexer.WhitespaceToken {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+// TrimBlank removes the leading and trailing whitespace and comment
+// tokens.
+func TrimBlank(tokens []csslexer.Token) []csslexer.Token {
+	for len(tokens) > 0 && IsBlank(tokens[0]) {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && IsBlank(tokens[len(tokens)-1]) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+// TrimImportant removes a trailing `!important` from a trimmed value,
+// reporting whether it was present. Comments before, between and after
+// the `!` and `important` are ignored, as by browsers.
+func TrimImportant(tokens []csslexer.Token) ([]csslexer.Token, bool) {
+	i := len(tokens) - 1
+	for i >= 0 && IsBlank(tokens[i]) {
+		i--
+	}
+	if i < 0 || tokens[i].Type != csslexer.IdentToken || !strings.EqualFold(tokens[i].Value, "important") {
+		return tokens, false
+	}
+	i--
+	for i >= 0 && IsBlank(tokens[i]) {
+		i--
+	}
+	if i < 0 || tokens[i].Type != csslexer.DelimiterToken || tokens[i].Value != "!" {
+		return tokens, false
+	}
+	return TrimWhitespace(tokens[:i]), true
+}
+
+// SplitNumeric splits the text of a numeric token into its number and the
+// rest, the unit of a dimension or the '%' of a percentage, following the
+// rules of consuming a number in the lexer.
+func SplitNumeric(s string) (number, unit string) {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	if i+1 < len(s) && s[i] == '.' && isDigit(s[i+1]) {
+		i++
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		if i+1 < len(s) && isDigit(s[i+1]) {
+			i++
+		} else if i+2 < len(s) && (s[i+1] == '+' || s[i+1] == '-') && isDigit(s[i+2]) {
+			i += 2
+		}
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
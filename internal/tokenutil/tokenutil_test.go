@@ -0,0 +1,105 @@
+package tokenutil
+
+import (
+	"testing"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+func lex(source string) []csslexer.Token {
+	var tokens []csslexer.Token
+	lexer := csslexer.NewLexer(csslexer.NewInput(source))
+	for {
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			return tokens
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func serialize(tokens []csslexer.Token) string {
+	s := ""
+	for _, token := range tokens {
+		s += string(token.Raw)
+	}
+	return s
+}
+
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		whitespace string
+		blank      string
+	}{
+		{name: "Empty", input: "", whitespace: "", blank: ""},
+		{name: "Whitespace", input: " \n a b \t", whitespace: "a b", blank: "a b"},
+		{name: "Comments", input: " /* x */ a /**/ ", whitespace: "/* x */ a /**/", blank: "a"},
+		{name: "Only blank", input: " /**/ ", whitespace: "/**/", blank: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serialize(TrimWhitespace(lex(tt.input))); got != tt.whitespace {
+				t.Errorf("TrimWhitespace(%q) = %q, expected %q", tt.input, got, tt.whitespace)
+			}
+			if got := serialize(TrimBlank(lex(tt.input))); got != tt.blank {
+				t.Errorf("TrimBlank(%q) = %q, expected %q", tt.input, got, tt.blank)
+			}
+		})
+	}
+}
+
+func TestTrimImportant(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		important bool
+	}{
+		{input: "red", expected: "red"},
+		{input: "red !important", expected: "red", important: true},
+		{input: "red ! IMPORTANT", expected: "red", important: true},
+		{input: "!important", expected: "", important: true},
+		{input: "important", expected: "important"},
+		{input: "red ?important", expected: "red ?important"},
+		{input: "red !important /* c */", expected: "red", important: true},
+		{input: "red /* c */ !/**/important", expected: "red /* c */", important: true},
+		{input: "red /* c */", expected: "red /* c */"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, important := TrimImportant(lex(tt.input))
+			if got := serialize(tokens); got != tt.expected || important != tt.important {
+				t.Errorf("TrimImportant(%q) = %q, %v, expected %q, %v", tt.input, got, important, tt.expected, tt.important)
+			}
+		})
+	}
+}
+
+func TestIsCustomPropertyName(t *testing.T) {
+	for name, expected := range map[string]bool{"--a": true, "--": false, "-a": false, "color": false} {
+		if got := IsCustomPropertyName(name); got != expected {
+			t.Errorf("IsCustomPropertyName(%q) = %v, expected %v", name, got, expected)
+		}
+	}
+}
+
+func TestSplitNumeric(t *testing.T) {
+	tests := map[string][2]string{
+		"10px":   {"10", "px"},
+		"-1.5em": {"-1.5", "em"},
+		"+.5e3x": {"+.5e3", "x"},
+		"1e":     {"1", "e"},
+		"2e-x":   {"2", "e-x"},
+		"3E+2%":  {"3E+2", "%"},
+		"50%":    {"50", "%"},
+		"1.x":    {"1", ".x"},
+	}
+	for input, expected := range tests {
+		if number, unit := SplitNumeric(input); number != expected[0] || unit != expected[1] {
+			t.Errorf("SplitNumeric(%q) = %q, %q, expected %q, %q", input, number, unit, expected[0], expected[1])
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package csslexer
+
+import (
+	"sync"
+)
+
+// maxInternLength is the maximum length, in runes, of the values interned
+// by an Interner. Longer names are rarely repeated.
+const maxInternLength = 64
+
+// Interner deduplicates the values of identifier-like tokens, so that
+// tokens with the same value share the memory of a single string. It
+// makes long-lived token slices smaller, and comparisons of equal values
+// cheaper, as the strings have the same data pointer.
+//
+// An Interner is pre-seeded with the names of CSS properties, units and
+// at-rules, and grows with the other values it sees. It is not safe for
+// concurrent use, and should be discarded when the values it holds are
+// no longer needed.
+type Interner struct {
+	entries []internEntry // Open addressing table, with a power of two length.
+	count   int           // Number of used entries.
+}
+
+type internEntry struct {
+	hash  uint32
+	value string
+}
+
+// NewInterner creates a new Interner, pre-seeded with the names of CSS
+// properties, units and at-rules.
+func NewInterner() *Interner {
+	seedOnce.Do(func() {
+		seed = &Interner{entries: make([]internEntry, 2048)}
+		for _, names := range [][]string{propertyNames, unitNames, atRuleNames} {
+			for _, name := range names {
+				seed.String(name)
+			}
+		}
+	})
+
+	entries := make([]internEntry, len(seed.entries))
+	copy(entries, seed.entries)
+	return &Interner{entries: entries, count: seed.count}
+}
+
+var (
+	seed     *Interner
+	seedOnce sync.Once
+)
+
+// Len returns the number of values in the Interner.
+func (in *Interner) Len() int {
+	return in.count
+}
+
+// String returns the interned copy of s, adding s if it is not present.
+func (in *Interner) String(s string) string {
+	if len(s) > 4*maxInternLength {
+		return s
+	}
+
+	h := uint32(2166136261) // FNV-1a, over code points as in hashRunes
+	n := 0
+	for _, r := range s {
+		h = (h ^ uint32(r)) * 16777619
+		n++
+	}
+	if n > maxInternLength {
+		return s
+	}
+
+	mask := len(in.entries) - 1
+	for i := int(h) & mask; ; i = (i + 1) & mask {
+		e := &in.entries[i]
+		if e.value == "" {
+			in.insert(i, h, s)
+			return s
+		}
+		if e.hash == h && e.value == s {
+			return e.value
+		}
+	}
+}
+
+// runes returns the interned string of the runes, adding it if it is not
+// present, without allocating when it is.
+func (in *Interner) runes(runes []rune) string {
+	if len(runes) > maxInternLength || len(runes) == 0 {
+		return runesString(runes)
+	}
+
+	h := hashRunes(runes)
+	mask := len(in.entries) - 1
+	for i := int(h) & mask; ; i = (i + 1) & mask {
+		e := &in.entries[i]
+		if e.value == "" {
+			s := runesString(runes)
+			in.insert(i, h, s)
+			return s
+		}
+		if e.hash == h && equalRunesString(runes, e.value) {
+			return e.value
+		}
+	}
+}
+
+// insert adds a value at the given empty entry, growing the table when it
+// is half full.
+func (in *Interner) insert(i int, h uint32, s string) {
+	if s == "" {
+		return
+	}
+	in.entries[i] = internEntry{hash: h, value: s}
+	in.count++
+
+	if 2*in.count <= len(in.entries) {
+		return
+	}
+	entries := make([]internEntry, 2*len(in.entries))
+	mask := len(entries) - 1
+	for _, e := range in.entries {
+		if e.value == "" {
+			continue
+		}
+		j := int(e.hash) & mask
+		for entries[j].value != "" {
+			j = (j + 1) & mask
+		}
+		entries[j] = e
+	}
+	in.entries = entries
+}
+
+// hashRunes returns the FNV-1a hash of the code points.
+func hashRunes(runes []rune) uint32 {
+	h := uint32(2166136261)
+	for _, r := range runes {
+		h = (h ^ uint32(r)) * 16777619
+	}
+	return h
+}
+
+// equalRunesString reports whether the runes are the code points of s.
+func equalRunesString(runes []rune, s string) bool {
+	i := 0
+	for _, r := range s {
+		if i >= len(runes) || runes[i] != r {
+			return false
+		}
+		i++
+	}
+	return i == len(runes)
+}
+
+// SetInterner sets the Interner used for the values of identifier-like
+// tokens: identifiers, functions, at-keywords, hashes, the units of
+// dimensions, and the names of dialect tokens. A nil Interner disables interning, which
+// is the default. The Interner is kept by Reset.
+func (l *Lexer) SetInterner(in *Interner) {
+	l.interner = in
+}
+
+// intern returns the runes as a string, interned if the lexer has an
+// Interner.
+func (l *Lexer) intern(runes []rune) string {
+	if l.interner == nil {
+		return runesString(runes)
+	}
+	return l.interner.runes(runes)
+}
+
+// internString returns s, interned if the lexer has an Interner.
+func (l *Lexer) internString(s string) string {
+	if l.interner == nil {
+		return s
+	}
+	return l.interner.String(s)
+}
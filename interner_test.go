@@ -0,0 +1,116 @@
+package csslexer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// stringData returns the address of the bytes of a string.
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+	seeded := in.Len()
+
+	for _, name := range []string{"background-color", "px", "media"} {
+		if in.String(string([]rune(name))) != name || in.Len() != seeded {
+			t.Errorf("String(%q) added a seeded name", name)
+		}
+	}
+
+	a := in.String(fmt.Sprintf("%s-%d", "foo", 1))
+	b := in.String(fmt.Sprintf("%s-%d", "foo", 1))
+	c := in.runes([]rune("foo-1"))
+	if stringData(a) != stringData(b) || stringData(a) != stringData(c) || in.Len() != seeded+1 {
+		t.Errorf("String() and runes() returned different copies of the same value")
+	}
+
+	for i := 0; i < 5000; i++ {
+		in.String(fmt.Sprint("name-", i))
+	}
+	if in.Len() != seeded+5001 {
+		t.Errorf("Len() = %d, expected %d", in.Len(), seeded+5001)
+	}
+	for i := 0; i < 5000; i++ {
+		if name := fmt.Sprint("name-", i); in.runes([]rune(name)) != name {
+			t.Errorf("runes(%q) = %q after growing", name, in.runes([]rune(name)))
+		}
+	}
+	if in.Len() != seeded+5001 {
+		t.Errorf("runes() added existing names: Len() = %d", in.Len())
+	}
+
+	if NewInterner().Len() != seeded {
+		t.Errorf("NewInterner() shares its table with other interners")
+	}
+}
+
+// TestInternerLexer checks that interning does not change the tokens, and
+// that equal values share their memory.
+func TestInternerLexer(t *testing.T) {
+	for name, source := range testSources(t) {
+		in := NewInterner()
+		plain := NewLexer(NewInputBytes(source))
+		lexer := NewLexer(NewInputBytes(source))
+		lexer.SetInterner(in)
+		raw := NewLexer(NewInputBytes(source))
+		raw.SetInterner(in)
+
+		seen := make(map[string]uintptr)
+		for i := 0; ; i++ {
+			expected := plain.Next()
+			token := lexer.Next()
+			rawToken := raw.NextRaw()
+			if token.Type != expected.Type || token.Value != expected.Value || raw.Value(rawToken) != expected.Value {
+				t.Errorf("%s: token %d = %s, %q, expected %s", name, i, token, raw.Value(rawToken), expected)
+				break
+			}
+			if expected.Type == EOFToken {
+				break
+			}
+
+			switch token.Type {
+			case IdentToken, FunctionToken, AtKeywordToken, HashToken:
+				if p, ok := seen[token.Value]; ok && p != stringData(token.Value) && len(token.Value) > 1 {
+					t.Errorf("%s: token %d = %s was not interned", name, i, token)
+					return
+				}
+				seen[token.Value] = stringData(token.Value)
+			}
+		}
+	}
+}
+
+func TestInternerAllocs(t *testing.T) {
+	lexer := NewLexer(NewInput(""))
+	lexer.SetInterner(NewInterner())
+	src := []rune(`@media print { .a { color: red; background-color: #fff; margin: 0 1px } }`)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		lexer.Reset(&Input{runes: src})
+		for lexer.Next().Type != EOFToken {
+		}
+	})
+	// Only the Input and the value of the dimension are allocated.
+	if allocs > 2 {
+		t.Errorf("Next() with an Interner allocated %v times, expected 2", allocs)
+	}
+}
+
+func TestInternerDimensions(t *testing.T) {
+	in := NewInterner()
+	n := in.Len()
+
+	lexer := NewLexer(NewInput("1px 2.5px -3px 4px 1e3px"))
+	lexer.SetInterner(in)
+	for token := lexer.Next(); token.Type != EOFToken; token = lexer.Next() {
+	}
+	// The units are pre-seeded, and the dimensions are not interned.
+	if in.Len() != n {
+		t.Errorf("Interner has %d values after lexing dimensions, expected %d", in.Len(), n)
+	}
+}
@@ -0,0 +1,87 @@
+package csslexer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// sourceLessFile is the source of a Less test case, in place of
+// sourceCssFile.
+const sourceLessFile = "source.less"
+
+// isLessCategory reports whether the test cases of a category of the test
+// data directory are for the Less dialect.
+func isLessCategory(category string) bool {
+	files, _ := filepath.Glob(filepath.Join(testDataDir, category, "*", sourceLessFile))
+	return len(files) > 0
+}
+
+// TestLess runs the Less test cases, laid out as the CSS test cases with a
+// source.less file in place of source.css.
+func TestLess(t *testing.T) {
+	categoryDirs, err := os.ReadDir(testDataDir)
+	if err != nil {
+		t.Fatalf("failed to read test data directory: %v", err)
+	}
+
+	for _, categoryDir := range categoryDirs {
+		testCategory := categoryDir.Name()
+		if !categoryDir.IsDir() || !isLessCategory(testCategory) {
+			continue
+		}
+		t.Run(testCategory, func(t *testing.T) {
+			categoryIdDirs, err := os.ReadDir(filepath.Join(testDataDir, testCategory))
+			if err != nil {
+				t.Fatalf("failed to read test category directory: %v", err)
+			}
+
+			for _, categoryIdDir := range categoryIdDirs {
+				testId := categoryIdDir.Name()
+				t.Run(testId, func(t *testing.T) {
+					testPath := filepath.Join(testDataDir, testCategory, testId)
+
+					source, err := os.ReadFile(filepath.Join(testPath, sourceLessFile))
+					if err != nil {
+						t.Fatalf("failed to read test source file: %v", err)
+					}
+					if *update {
+						writeGoldenTokens(t, filepath.Join(testPath, tokensJsonFile), NewLexerDialect(NewInputBytes(source), Less))
+					}
+					tokensRaw, err := os.ReadFile(filepath.Join(testPath, tokensJsonFile))
+					if err != nil {
+						t.Fatalf("failed to read test tokens file: %v", err)
+					}
+					var tokens []testToken
+					if err := json.Unmarshal(tokensRaw, &tokens); err != nil {
+						t.Fatalf("failed to unmarshal tokens: %v", err)
+					}
+
+					// Offsets are in UTF-16 code units, as written by
+					// goldenTokens.
+					lexer := NewLexerDialect(NewInputBytes(source), Less)
+					offset := 0
+					for i, expected := range tokens {
+						token := lexer.Next()
+						if token.Type != convertTestTokenName(expected.Type) || token.Value != expected.Value || string(token.Raw) != expected.Raw {
+							t.Errorf("expected '%s' token (value: %q, raw: %q), got '%s' (value: %q, raw: %q) at index %d",
+								expected.Type, expected.Value, expected.Raw, token.Type, token.Value, string(token.Raw), i)
+						}
+						end := offset + len(utf16.Encode(token.Raw))
+						if offset != expected.StartIndex || end != expected.EndIndex {
+							t.Errorf("expected token at %d-%d, got %d-%d at index %d",
+								expected.StartIndex, expected.EndIndex, offset, end, i)
+						}
+						offset = end
+					}
+
+					if token := lexer.Next(); token.Type != EOFToken {
+						t.Errorf("expected EOF token, got '%s' at the end of test", token.Type)
+					}
+				})
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package csslexer
 
 import (
+	"unicode/utf8"
+
 	"go.baoshuo.dev/cssutil"
 )
 
@@ -8,6 +10,15 @@ import (
 type Lexer struct {
 	r *Input // The input stream of runes to be lexed.
 	p *Token // The peeked token, nil if no token is peeked.
+
+	dialect Dialect        // The syntax being lexed.
+	braces  []bool         // Open braces in a dialect, true for interpolations.
+	resume  rune           // The token interrupted by a template slot, see readTemplateToken.
+	resumed []resumedToken // The tokens continuing after a template slot, see Value.
+	lazy    bool           // Whether token values are skipped, see NextRaw.
+
+	interner *Interner // The interner of identifier-like values, nil if disabled.
+	guard    *guard    // The limits and context of the lexer, nil if none.
 }
 
 // NewLexer creates a new Lexer instance with the given Input.
@@ -18,6 +29,44 @@ func NewLexer(r *Input) *Lexer {
 	}
 }
 
+// Reset resets the Lexer to read from the given Input, keeping its
+// dialect, limits and context. Together with Input.Reset, it allows reusing lexers for many
+// small inputs without allocating, for example with a sync.Pool:
+//
+//	type tokenizer struct {
+//		input *csslexer.Input
+//		lexer *csslexer.Lexer
+//	}
+//
+//	var tokenizers = sync.Pool{New: func() interface{} {
+//		input := csslexer.NewInput("")
+//		return &tokenizer{input: input, lexer: csslexer.NewLexer(input)}
+//	}}
+//
+//	t := tokenizers.Get().(*tokenizer)
+//	t.input.Reset(style)
+//	t.lexer.Reset(t.input)
+//	for token := t.lexer.NextRaw(); token.Type != csslexer.EOFToken; token = t.lexer.NextRaw() {
+//		// ...
+//	}
+//	tokenizers.Put(t)
+func (l *Lexer) Reset(r *Input) {
+	if l.p != nil {
+		tokenPool.Put(l.p)
+		l.p = nil
+	}
+	l.r = r
+	l.braces = l.braces[:0]
+	l.resume = 0
+	l.resumed = l.resumed[:0]
+	l.lazy = false
+	if l.guard != nil {
+		l.guard.err = nil
+		l.guard.tokens = 0
+		l.guard.open = l.guard.open[:0]
+	}
+}
+
 // Peek returns the next token without advancing the position.
 // It returns a copy of the token.
 func (l *Lexer) Peek() Token {
@@ -48,16 +97,104 @@ func (l *Lexer) Next() Token {
 	return Token{Type: tokenType, Value: data, Raw: rawRunes}
 }
 
+// current returns the current token as a string, or an empty string when
+// values are skipped.
+func (l *Lexer) current() string {
+	if l.lazy {
+		return ""
+	}
+	return runesString(l.r.Current())
+}
+
+// currentSuffix returns the current token after the offset as a string, or
+// an empty string when values are skipped.
+func (l *Lexer) currentSuffix(offset int) string {
+	if l.lazy {
+		return ""
+	}
+	return runesString(l.r.CurrentSuffix(offset))
+}
+
+// maxKeywordLength is the length of the longest name compared by isKeyword.
+const maxKeywordLength = 32
+
+// isKeyword reports whether a name returned by consumeName, or, when
+// values are skipped and the name has no escapes, the current token after
+// the offset, is one of the keywords, in ASCII lowercase. The name is
+// compared in place, without allocating.
+func (l *Lexer) isKeyword(keywords map[string]bool, name string, offset int) bool {
+	var key [maxKeywordLength]byte
+	n := 0
+	if name != "" {
+		if len(name) > len(key) {
+			return false
+		}
+		for ; n < len(name); n++ {
+			key[n] = toLowerASCII(name[n])
+		}
+	} else {
+		runes := l.r.CurrentSuffix(offset)
+		if len(runes) > len(key) {
+			return false
+		}
+		for ; n < len(runes); n++ {
+			if runes[n] >= utf8.RuneSelf {
+				return false
+			}
+			key[n] = toLowerASCII(byte(runes[n]))
+		}
+	}
+	return keywords[string(key[:n])]
+}
+
+func toLowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
 // readNextToken reads the next token from the input stream.
 // This is the internal method that actually parses tokens.
 func (l *Lexer) readNextToken() (TokenType, string) {
-	switch l.r.Peek(0) {
+	if l.guard != nil {
+		return l.readGuardedToken()
+	}
+	return l.readUnguardedToken()
+}
+
+// readUnguardedToken reads the next token regardless of the limits of the
+// lexer.
+func (l *Lexer) readUnguardedToken() (TokenType, string) {
+	if l.r.slots != nil {
+		return l.readTemplateToken()
+	}
+	return l.readToken()
+}
+
+// readToken reads the next token of the dialect of the lexer.
+func (l *Lexer) readToken() (TokenType, string) {
+	if l.dialect != CSS {
+		if tokenType, data, ok := l.readDialectToken(); ok {
+			return tokenType, data
+		}
+	}
+
+	next := l.r.Peek(0)
+	if uint32(next) < 128 && asciiToken[next] != DefaultToken {
+		// Punctuation and delimiters that always form a token by
+		// themselves.
+		l.r.Move(1)
+		return asciiToken[next], l.current()
+	}
+
+	switch next {
 	case EOF:
 		return EOFToken, ""
 
 	case '\t', '\n', '\r', '\f', ' ':
 		l.consumeWhitespace()
-		return WhitespaceToken, l.r.CurrentString()
+		return WhitespaceToken, l.current()
 
 	case '\'', '"':
 		return l.consumeStringToken()
@@ -67,36 +204,12 @@ func (l *Lexer) readNextToken() (TokenType, string) {
 		// so we don't handle them here.
 		return l.consumeNumericToken()
 
-	case '(':
-		l.r.Move(1)
-		return LeftParenthesisToken, l.r.CurrentString()
-
-	case ')':
-		l.r.Move(1)
-		return RightParenthesisToken, l.r.CurrentString()
-
-	case '[':
-		l.r.Move(1)
-		return LeftBracketToken, l.r.CurrentString()
-
-	case ']':
-		l.r.Move(1)
-		return RightBracketToken, l.r.CurrentString()
-
-	case '{':
-		l.r.Move(1)
-		return LeftBraceToken, l.r.CurrentString()
-
-	case '}':
-		l.r.Move(1)
-		return RightBraceToken, l.r.CurrentString()
-
 	case '+', '.':
 		if l.nextCharsAreNumber() {
 			return l.consumeNumericToken()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '-':
 		if l.nextCharsAreNumber() {
@@ -104,101 +217,90 @@ func (l *Lexer) readNextToken() (TokenType, string) {
 		}
 		if l.r.Peek(1) == '-' && l.r.Peek(2) == '>' {
 			l.r.Move(3) // consume "-->"
-			return CDCToken, l.r.CurrentString()
+			return CDCToken, l.current()
 		}
 		if l.nextCharsAreIdentifier() {
 			return l.consumeIdentLikeToken()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '*':
 		if l.r.Peek(1) == '=' {
 			l.r.Move(2) // consume "*="
-			return SubstringMatchToken, l.r.CurrentString()
+			return SubstringMatchToken, l.current()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '<':
 		if l.r.Peek(1) == '!' && l.r.Peek(2) == '-' && l.r.Peek(3) == '-' {
 			l.r.Move(4) // consume "<!--"
-			return CDOToken, l.r.CurrentString()
+			return CDOToken, l.current()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
-
-	case ',':
-		l.r.Move(1)
-		return CommaToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '/':
 		if l.r.Peek(1) == '*' {
+			l.r.Move(2) // consume "/*"
 			l.consumeUntilCommentEnd()
-			return CommentToken, l.r.CurrentString()
+			return CommentToken, l.current()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '\\':
 		if cssutil.TwoCodePointsStartsAValidEscape(l.r.Peek(0), l.r.Peek(1)) {
 			return l.consumeIdentLikeToken()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
-
-	case ':':
-		l.r.Move(1)
-		return ColonToken, l.r.CurrentString()
-
-	case ';':
-		l.r.Move(1)
-		return SemicolonToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '#':
 		l.r.Move(1)
-		if cssutil.IsIdentCodePoint(l.r.Peek(0)) || cssutil.TwoCodePointsStartsAValidEscape(l.r.Peek(0), l.r.Peek(1)) {
+		if isIdentCodePoint(l.r.Peek(0)) || cssutil.TwoCodePointsStartsAValidEscape(l.r.Peek(0), l.r.Peek(1)) {
 			name := l.consumeName()
 			return HashToken, name
 		}
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '^':
 		if l.r.Peek(1) == '=' {
 			l.r.Move(2) // consume "^="
-			return PrefixMatchToken, l.r.CurrentString()
+			return PrefixMatchToken, l.current()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '$':
 		if l.r.Peek(1) == '=' {
 			l.r.Move(2) // consume "$="
-			return SuffixMatchToken, l.r.CurrentString()
+			return SuffixMatchToken, l.current()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '|':
 		if l.r.Peek(1) == '=' {
 			l.r.Move(2) // consume "|="
-			return DashMatchToken, l.r.CurrentString()
+			return DashMatchToken, l.current()
 		}
 		// https://www.w3.org/TR/2022/WD-selectors-4-20221111/#the-column-combinator
 		if l.r.Peek(1) == '|' {
 			l.r.Move(2) // consume "||"
-			return ColumnToken, l.r.CurrentString()
+			return ColumnToken, l.current()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '~':
 		if l.r.Peek(1) == '=' {
 			l.r.Move(2) // consume "~="
-			return IncludeMatchToken, l.r.CurrentString()
+			return IncludeMatchToken, l.current()
 		}
 		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case '@':
 		l.r.Move(1)
@@ -206,7 +308,7 @@ func (l *Lexer) readNextToken() (TokenType, string) {
 			name := l.consumeName()
 			return AtKeywordToken, name
 		}
-		return DelimiterToken, l.r.CurrentString()
+		return DelimiterToken, l.current()
 
 	case 'u', 'U':
 		if l.r.Peek(1) == '+' &&
@@ -216,11 +318,6 @@ func (l *Lexer) readNextToken() (TokenType, string) {
 		}
 		return l.consumeIdentLikeToken()
 
-	case 1, 2, 3, 4, 5, 6, 7, 8, 11, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24,
-		25, 26, 27, 28, 29, 30, 31, '!', '%', '&', '=', '>', '?', '`', 127:
-		l.r.Move(1)
-		return DelimiterToken, l.r.CurrentString()
-
 	default:
 		return l.consumeIdentLikeToken()
 	}
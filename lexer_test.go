@@ -1,20 +1,34 @@
 package csslexer
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf16"
 )
 
+// update writes the tokens.json files of the test cases without one from
+// the output of the lexer, for example:
+//
+//	go test -run 'TestLexer|TestLess' -update
+//
+// Existing files are never rewritten, as they are the expected output.
+var update = flag.Bool("update", false, "write the missing tokens.json files of the test cases")
+
 const (
 	testDataDir    = "tests"
 	sourceCssFile  = "source.css"
 	tokensJsonFile = "tokens.json"
+	reportMdFile   = "test_result.md"
+	reportJsonFile = "test_result.json"
 )
 
 type testToken struct {
@@ -54,7 +68,7 @@ func TestLexer(t *testing.T) {
 		}
 
 		testCategory := categoryDir.Name()
-		if testCategory == "fuzz" { // Skip fuzz test directory
+		if testCategory == "fuzz" || testCategory == browserTestDir || isLessCategory(testCategory) { // Skip fuzz, browser and Less test directories
 			continue
 		}
 
@@ -90,6 +104,9 @@ func TestLexer(t *testing.T) {
 						t.Fatalf("failed to read test source file: %v", err)
 					}
 
+					if *update {
+						writeGoldenTokens(t, tokensFile, NewLexer(NewInputBytes(sources)))
+					}
 					tokensRaw, err := os.ReadFile(tokensFile)
 					if err != nil {
 						t.Fatalf("failed to read test tokens file: %v", err)
@@ -134,10 +151,11 @@ func TestLexer(t *testing.T) {
 	}
 
 	generateTestReport(totalTests, totalPassed, categoryStats)
+	generateJSONReport(totalTests, totalPassed, categoryStats)
 
 	fmt.Println("===== Test Results Summary =====")
 	fmt.Printf("Total tests: %d, Passed: %d, Pass rate: %.2f%%\n", totalTests, totalPassed, float64(totalPassed)/float64(totalTests)*100)
-	fmt.Println("Results have been written to test_result.md")
+	fmt.Printf("Results have been written to %s and %s\n", reportMdFile, reportJsonFile)
 }
 
 func generateTestReport(totalTests, totalPassed int, categoryStats map[string]*CategoryStats) {
@@ -192,12 +210,63 @@ func generateTestReport(totalTests, totalPassed int, categoryStats map[string]*C
 		content.WriteString("\n")
 	}
 
-	err := os.WriteFile("test_result.md", []byte(content.String()), 0644)
+	err := os.WriteFile(reportMdFile, []byte(content.String()), 0644)
 	if err != nil {
 		fmt.Printf("Error writing test results: %v\n", err)
 	}
 }
 
+// testReport is the machine-readable form of test_result.md. It has no
+// date, and its maps are written with sorted keys, so that the reports of
+// two runs can be diffed.
+type testReport struct {
+	Total      int                       `json:"total"`
+	Passed     int                       `json:"passed"`
+	PassRate   float64                   `json:"passRate"` // Percentage, rounded to two decimals
+	Categories map[string]categoryReport `json:"categories"`
+}
+
+type categoryReport struct {
+	Total    int             `json:"total"`
+	Passed   int             `json:"passed"`
+	PassRate float64         `json:"passRate"`
+	Tests    map[string]bool `json:"tests"` // Map of test IDs to pass/fail status
+}
+
+func generateJSONReport(totalTests, totalPassed int, categoryStats map[string]*CategoryStats) {
+	report := testReport{
+		Total:      totalTests,
+		Passed:     totalPassed,
+		PassRate:   percentage(totalPassed, totalTests),
+		Categories: make(map[string]categoryReport, len(categoryStats)),
+	}
+	for category, stats := range categoryStats {
+		report.Categories[category] = categoryReport{
+			Total:    stats.Total,
+			Passed:   stats.Passed,
+			PassRate: percentage(stats.Passed, stats.Total),
+			Tests:    stats.Tests,
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err == nil {
+		err = os.WriteFile(reportJsonFile, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf("Error writing test results: %v\n", err)
+	}
+}
+
+// percentage returns passed out of total as a percentage rounded to two
+// decimals, or 0 if total is 0.
+func percentage(passed, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(passed)/float64(total)*10000) / 100
+}
+
 func convertTestTokenName(name string) TokenType {
 	switch name {
 	case "ident-token":
@@ -269,7 +338,157 @@ func convertTestTokenName(name string) TokenType {
 	// case "":
 	// 	return UnicodeRangeToken
 
+	// Dialect token types
+	case "line-comment":
+		return LineCommentToken
+	case "variable-token":
+		return VariableToken
+	case "interpolation-start-token":
+		return InterpolationStartToken
+	case "interpolation-end-token":
+		return InterpolationEndToken
+	case "placeholder-token":
+		return PlaceholderToken
+	case "directive-token":
+		return DirectiveToken
+	case "at-variable-token":
+		return AtVariableToken
+	case "escaped-string-token":
+		return EscapedStringToken
+	case "guard-token":
+		return GuardToken
+	case "javascript-token":
+		return JavaScriptToken
+
 	default:
 		return DefaultToken
 	}
 }
+
+// testTokenNames are the token names of the test cases, see
+// convertTestTokenName.
+var testTokenNames = []string{
+	"ident-token", "function-token", "at-keyword-token", "hash-token",
+	"string-token", "bad-string-token", "url-token", "bad-url-token",
+	"delim-token", "number-token", "percentage-token", "dimension-token",
+	"whitespace-token", "CDO-token", "CDC-token", "colon-token",
+	"semicolon-token", "comma-token", "(-token", ")-token", "[-token",
+	"]-token", "{-token", "}-token", "comment",
+	"line-comment", "variable-token", "interpolation-start-token",
+	"interpolation-end-token", "placeholder-token", "directive-token",
+	"at-variable-token", "escaped-string-token", "guard-token",
+	"javascript-token",
+}
+
+// testTokenName returns the test case name of a token type. Types without
+// one, which the test cases do not expect, keep their Go name, so that the
+// written case fails until it is corrected by hand.
+func testTokenName(tokenType TokenType) string {
+	for _, name := range testTokenNames {
+		if convertTestTokenName(name) == tokenType {
+			return name
+		}
+	}
+	return tokenType.String()
+}
+
+// goldenTokens returns the tokens of the lexer in the format of the test
+// cases, with offsets in UTF-16 code units as in the upstream test cases.
+func goldenTokens(lexer *Lexer) []testToken {
+	tokens := []testToken{}
+	offset := 0
+	for {
+		token := lexer.Next()
+		if token.Type == EOFToken {
+			return tokens
+		}
+		end := offset + len(utf16.Encode(token.Raw))
+		tokens = append(tokens, testToken{
+			Type:       testTokenName(token.Type),
+			Raw:        string(token.Raw),
+			StartIndex: offset,
+			EndIndex:   end,
+			Value:      token.Value,
+		})
+		offset = end
+	}
+}
+
+// writeGoldenTokens writes the tokens of the lexer to the tokens.json file
+// at path, unless it exists.
+func writeGoldenTokens(t *testing.T, path string, lexer *Lexer) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(goldenTokens(lexer)); err != nil {
+		t.Fatalf("failed to marshal tokens: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write test tokens file: %v", err)
+	}
+	t.Logf("wrote %s", path)
+}
+
+func TestTestTokenName(t *testing.T) {
+	for _, name := range testTokenNames {
+		if got := testTokenName(convertTestTokenName(name)); got != name {
+			t.Errorf("testTokenName(convertTestTokenName(%q)) = %q", name, got)
+		}
+	}
+}
+
+func TestWriteGoldenTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), tokensJsonFile)
+	writeGoldenTokens(t, path, NewLexer(NewInput("a&\U0001F600 ")))
+
+	expected := "[\n" +
+		"\t{\n\t\t\"type\": \"ident-token\",\n\t\t\"raw\": \"a\",\n\t\t\"startIndex\": 0,\n\t\t\"endIndex\": 1,\n\t\t\"value\": \"a\"\n\t},\n" +
+		"\t{\n\t\t\"type\": \"delim-token\",\n\t\t\"raw\": \"&\",\n\t\t\"startIndex\": 1,\n\t\t\"endIndex\": 2,\n\t\t\"value\": \"&\"\n\t},\n" +
+		"\t{\n\t\t\"type\": \"ident-token\",\n\t\t\"raw\": \"\U0001F600\",\n\t\t\"startIndex\": 2,\n\t\t\"endIndex\": 4,\n\t\t\"value\": \"\U0001F600\"\n\t},\n" +
+		"\t{\n\t\t\"type\": \"whitespace-token\",\n\t\t\"raw\": \" \",\n\t\t\"startIndex\": 4,\n\t\t\"endIndex\": 5,\n\t\t\"value\": \" \"\n\t}\n" +
+		"]\n"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Errorf("writeGoldenTokens() wrote\n%s\nexpected\n%s", data, expected)
+	}
+
+	// Existing files are kept.
+	writeGoldenTokens(t, path, NewLexer(NewInput("b")))
+	if data, _ := os.ReadFile(path); string(data) != expected {
+		t.Errorf("writeGoldenTokens() rewrote an existing file")
+	}
+}
+
+func TestCommentEnd(t *testing.T) {
+	// The '*' of the opening "/*" does not start the closing "*/".
+	testDialect(t, CSS, []struct {
+		name     string
+		input    string
+		expected []dialectToken
+	}{
+		{
+			name:     "Slash after the opening",
+			input:    "/*/ a */b",
+			expected: []dialectToken{{CommentToken, "/*/ a */", "/*/ a */"}, {IdentToken, "b", "b"}},
+		},
+		{
+			name:     "Unterminated",
+			input:    "/*/",
+			expected: []dialectToken{{CommentToken, "/*/", "/*/"}},
+		},
+		{
+			name:     "Empty",
+			input:    "/**/",
+			expected: []dialectToken{{CommentToken, "/**/", "/**/"}},
+		},
+	})
+}
@@ -0,0 +1,218 @@
+package csslexer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Limits bound the work of a lexer on untrusted input. A zero field means
+// no limit.
+//
+// MaxInputSize is checked by the lexer on the Input, which is already
+// decoded into runes. To bound the memory and time spent decoding a large
+// input as well, create the Input with NewInputBytesLimit or
+// NewInputReaderLimit.
+type Limits struct {
+	MaxInputSize   int // Maximum length of the input, in runes
+	MaxTokenLength int // Maximum length of a token, in runes
+	MaxTokens      int // Maximum number of tokens, not including EOFToken
+	MaxDepth       int // Maximum nesting of blocks, functions and interpolations
+}
+
+// Limit identifies one of the Limits.
+type Limit int
+
+const (
+	LimitInputSize Limit = iota
+	LimitTokenLength
+	LimitTokens
+	LimitDepth
+)
+
+func (l Limit) String() string {
+	switch l {
+	case LimitInputSize:
+		return "input size"
+	case LimitTokenLength:
+		return "token length"
+	case LimitTokens:
+		return "token count"
+	case LimitDepth:
+		return "nesting depth"
+	default:
+		return fmt.Sprintf("Limit(%d)", int(l))
+	}
+}
+
+// LimitError is returned by Lexer.Err when the input exceeds one of the
+// limits of the lexer.
+type LimitError struct {
+	Limit  Limit // The limit exceeded
+	Max    int   // The value of the limit
+	Offset int   // Offset in the input, in runes, of the token exceeding the limit
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("csslexer: %s limit of %d exceeded at offset %d", e.Limit, e.Max, e.Offset)
+}
+
+// NewInputBytesLimit is like NewInputBytes, but returns a *LimitError
+// without decoding the input if it is longer than max runes, as
+// MaxInputSize. A max of zero means no limit.
+func NewInputBytesLimit(input []byte, max int) (*Input, error) {
+	// A rune is at least one byte, so only a longer input needs counting.
+	if max > 0 && len(input) > max && utf8.RuneCount(input) > max {
+		return nil, &LimitError{Limit: LimitInputSize, Max: max, Offset: max}
+	}
+	return NewInputBytes(input), nil
+}
+
+// NewInputReaderLimit is like NewInputReader, but reads at most as many
+// bytes as max runes may take, and returns a *LimitError without decoding
+// the input if it is longer than max runes. A max of zero means no limit.
+func NewInputReaderLimit(r io.Reader, max int) (*Input, error) {
+	if max <= 0 || r == nil {
+		return NewInputReader(r), nil
+	}
+
+	// A rune is at most utf8.UTFMax bytes.
+	n := int64(max) * utf8.UTFMax
+	b, err := io.ReadAll(io.LimitReader(r, n+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > n {
+		return nil, &LimitError{Limit: LimitInputSize, Max: max, Offset: max}
+	}
+	return NewInputBytesLimit(b, max)
+}
+
+// lookaheadMargin is the number of runes the lexer may peek past the end
+// of a token to find where it ends.
+const lookaheadMargin = 4
+
+// contextCheckInterval is the number of tokens read between checks of the
+// context of the lexer.
+const contextCheckInterval = 256
+
+// guard is the state of a lexer with limits or a context.
+type guard struct {
+	limits Limits
+	ctx    context.Context
+	err    error
+
+	tokens int         // Number of tokens read
+	open   []TokenType // Closing tokens of the open blocks, see MaxDepth
+}
+
+// SetLimits sets the limits of the lexer. Once a limit is exceeded, the
+// lexer stops reading and only returns EOFToken, and Err returns a
+// *LimitError.
+//
+// A token is never read past MaxTokenLength runes, so that a long comment
+// or string is not consumed in full before the limit trips. The depth
+// counts blocks, functions and interpolations that are not closed yet,
+// with the same matching of brackets as the parser, so that parsers of the
+// tokens do not nest deeper than MaxDepth.
+func (l *Lexer) SetLimits(limits Limits) {
+	l.guarded().limits = limits
+}
+
+// SetContext sets a context whose cancellation stops the lexer, which then
+// only returns EOFToken, and Err returns the error of the context. The
+// context is checked periodically between tokens, so it should be combined
+// with MaxTokenLength to bound the work done after it is canceled.
+func (l *Lexer) SetContext(ctx context.Context) {
+	l.guarded().ctx = ctx
+}
+
+// Err returns the error that stopped the lexer: a *LimitError, or the
+// error of its context. It returns nil if the lexer was not stopped.
+func (l *Lexer) Err() error {
+	if l.guard == nil {
+		return nil
+	}
+	return l.guard.err
+}
+
+func (l *Lexer) guarded() *guard {
+	if l.guard == nil {
+		l.guard = &guard{}
+	}
+	return l.guard
+}
+
+// readGuardedToken reads the next token within the limits of the lexer,
+// returning EOFToken once the lexer is stopped.
+func (l *Lexer) readGuardedToken() (TokenType, string) {
+	g := l.guard
+	if g.err == nil {
+		g.err = l.checkLimits()
+	}
+	if g.err != nil {
+		l.r.pos = l.r.start
+		return EOFToken, ""
+	}
+
+	runes, err := l.r.runes, l.r.err
+	max := g.limits.MaxTokenLength
+	if max > 0 && len(runes)-l.r.start > max+lookaheadMargin {
+		// Hide the rest of the input, as if it ended after the token.
+		l.r.runes = runes[:l.r.start+max+lookaheadMargin]
+	}
+	tokenType, data := l.readUnguardedToken()
+	l.r.runes, l.r.err = runes, err
+	if l.r.pos >= len(runes) {
+		l.r.err = io.EOF
+	}
+
+	if max > 0 && l.r.pos-l.r.start > max {
+		g.err = &LimitError{Limit: LimitTokenLength, Max: max, Offset: l.r.start}
+		l.r.pos = l.r.start
+		return EOFToken, ""
+	}
+	if tokenType == EOFToken {
+		return tokenType, data
+	}
+	g.tokens++
+
+	switch tokenType {
+	case FunctionToken, LeftParenthesisToken:
+		g.open = append(g.open, RightParenthesisToken)
+	case LeftBracketToken:
+		g.open = append(g.open, RightBracketToken)
+	case LeftBraceToken:
+		g.open = append(g.open, RightBraceToken)
+	case InterpolationStartToken:
+		g.open = append(g.open, InterpolationEndToken)
+	case RightParenthesisToken, RightBracketToken, RightBraceToken, InterpolationEndToken:
+		if n := len(g.open); n > 0 && g.open[n-1] == tokenType {
+			g.open = g.open[:n-1]
+		}
+	}
+	if g.limits.MaxDepth > 0 && len(g.open) > g.limits.MaxDepth {
+		g.err = &LimitError{Limit: LimitDepth, Max: g.limits.MaxDepth, Offset: l.r.start}
+		l.r.pos = l.r.start
+		return EOFToken, ""
+	}
+
+	return tokenType, data
+}
+
+// checkLimits returns the error stopping the lexer before the next token,
+// if any.
+func (l *Lexer) checkLimits() error {
+	g := l.guard
+	if max := g.limits.MaxInputSize; max > 0 && len(l.r.runes) > max {
+		return &LimitError{Limit: LimitInputSize, Max: max, Offset: max}
+	}
+	if max := g.limits.MaxTokens; max > 0 && g.tokens >= max && l.r.pos < len(l.r.runes) {
+		return &LimitError{Limit: LimitTokens, Max: max, Offset: l.r.start}
+	}
+	if g.ctx != nil && g.tokens%contextCheckInterval == 0 {
+		return g.ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package csslexer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// lexLimited reads all the tokens of the source with the given limits.
+func lexLimited(source string, dialect Dialect, limits Limits) ([]Token, error) {
+	lexer := NewLexerDialect(NewInput(source), dialect)
+	lexer.SetLimits(limits)
+
+	var tokens []Token
+	for token := lexer.Next(); token.Type != EOFToken; token = lexer.Next() {
+		tokens = append(tokens, token)
+	}
+	return tokens, lexer.Err()
+}
+
+// TestMaxTokenLength checks that the limit trips exactly at the longest
+// token of each source, and that tokens are unchanged below the limit.
+func TestMaxTokenLength(t *testing.T) {
+	for name, source := range testSources(t) {
+		for _, dialect := range []Dialect{CSS, SCSS, Less} {
+			expected, _ := lexLimited(string(source), dialect, Limits{})
+
+			longest, offset := 0, 0
+			pos := 0
+			for _, token := range expected {
+				if len(token.Raw) > longest {
+					longest, offset = len(token.Raw), pos
+				}
+				pos += len(token.Raw)
+			}
+
+			tokens, err := lexLimited(string(source), dialect, Limits{MaxTokenLength: longest})
+			if err != nil {
+				t.Errorf("%s (%s): error %v at the length of the longest token", name, dialect, err)
+			}
+			if len(tokens) != len(expected) {
+				t.Errorf("%s (%s): %d tokens, expected %d", name, dialect, len(tokens), len(expected))
+			}
+			for i := 0; i < len(tokens) && i < len(expected); i++ {
+				if tokens[i].Type != expected[i].Type || tokens[i].Value != expected[i].Value || string(tokens[i].Raw) != string(expected[i].Raw) {
+					t.Errorf("%s (%s): token %d = %s, expected %s", name, dialect, i, tokens[i], expected[i])
+					break
+				}
+			}
+
+			if longest < 2 {
+				continue
+			}
+			_, err = lexLimited(string(source), dialect, Limits{MaxTokenLength: longest - 1})
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != LimitTokenLength || limitErr.Offset > offset {
+				t.Errorf("%s (%s): error %v, expected a token length error at offset %d at most", name, dialect, err, offset)
+			}
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		limits   Limits
+		tokens   int   // number of tokens read before the error
+		expected error // nil for no error
+	}{
+		{
+			name:   "Within limits",
+			input:  "a{b:c(d)}",
+			limits: Limits{MaxInputSize: 9, MaxTokenLength: 2, MaxTokens: 8, MaxDepth: 2},
+			tokens: 8,
+		},
+		{
+			name:     "Input size",
+			input:    "a{b:c}",
+			limits:   Limits{MaxInputSize: 5},
+			expected: &LimitError{Limit: LimitInputSize, Max: 5, Offset: 5},
+		},
+		{
+			name:     "Token count",
+			input:    "a b c",
+			limits:   Limits{MaxTokens: 3},
+			tokens:   3,
+			expected: &LimitError{Limit: LimitTokens, Max: 3, Offset: 3},
+		},
+		{
+			name:     "Long comment",
+			input:    "a /*" + strings.Repeat(" ", 1<<20) + "*/",
+			limits:   Limits{MaxTokenLength: 1024},
+			tokens:   2,
+			expected: &LimitError{Limit: LimitTokenLength, Max: 1024, Offset: 2},
+		},
+		{
+			name:     "Unterminated string",
+			input:    "'abc",
+			limits:   Limits{MaxTokenLength: 3},
+			expected: &LimitError{Limit: LimitTokenLength, Max: 3, Offset: 0},
+		},
+		{
+			name:     "Depth",
+			input:    "a{b{c(d[e",
+			limits:   Limits{MaxDepth: 3},
+			tokens:   6,
+			expected: &LimitError{Limit: LimitDepth, Max: 3, Offset: 7},
+		},
+		{
+			name:     "Mismatched brackets do not close blocks",
+			input:    "{(]}]}{",
+			limits:   Limits{MaxDepth: 2},
+			tokens:   6,
+			expected: &LimitError{Limit: LimitDepth, Max: 2, Offset: 6},
+		},
+		{
+			name:   "Matched brackets close blocks",
+			input:  "{()}{[]}{}",
+			limits: Limits{MaxDepth: 2},
+			tokens: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := lexLimited(tt.input, CSS, tt.limits)
+			if len(tokens) != tt.tokens {
+				t.Errorf("read %d tokens, expected %d", len(tokens), tt.tokens)
+			}
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("Err() = %v, expected no error", err)
+				}
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || *limitErr != *tt.expected.(*LimitError) {
+				t.Errorf("Err() = %v, expected %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewInputLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		max      int
+		exceeded bool
+	}{
+		{name: "No limit", input: "a{b:c}"},
+		{name: "Within the limit", input: "a{b:c}", max: 6},
+		{name: "Over the limit", input: "a{b:c}", max: 5, exceeded: true},
+		{name: "Multibyte runes within the limit", input: "\u4f60\u597d\U0001F600", max: 3},
+		{name: "Multibyte runes over the limit", input: "\u4f60\u597d\U0001F600", max: 2, exceeded: true},
+		{name: "Invalid bytes count as runes", input: "\xff\xfe", max: 1, exceeded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := map[string]func() (*Input, error){
+				"NewInputBytesLimit": func() (*Input, error) {
+					return NewInputBytesLimit([]byte(tt.input), tt.max)
+				},
+				"NewInputReaderLimit": func() (*Input, error) {
+					return NewInputReaderLimit(strings.NewReader(tt.input), tt.max)
+				},
+			}
+			for name, newInput := range inputs {
+				input, err := newInput()
+				if !tt.exceeded {
+					if err != nil {
+						t.Fatalf("%s() error = %v, expected no error", name, err)
+					}
+					if got := string(input.runes); got != string([]rune(tt.input)) {
+						t.Errorf("%s() input = %q, expected %q", name, got, tt.input)
+					}
+					continue
+				}
+				expected := LimitError{Limit: LimitInputSize, Max: tt.max, Offset: tt.max}
+				var limitErr *LimitError
+				if !errors.As(err, &limitErr) || *limitErr != expected {
+					t.Errorf("%s() error = %v, expected %v", name, err, &expected)
+				}
+			}
+		})
+	}
+}
+
+// endlessReader reads an endless input of spaces, counting the bytes read.
+type endlessReader struct {
+	n int
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	r.n += len(p)
+	return len(p), nil
+}
+
+func TestNewInputReaderLimitReadsBounded(t *testing.T) {
+	r := &endlessReader{}
+	if _, err := NewInputReaderLimit(r, 10); err == nil {
+		t.Fatal("NewInputReaderLimit() error = nil, expected a *LimitError")
+	}
+	if max := 10*4 + 1; r.n > max {
+		t.Errorf("read %d bytes, expected at most %d", r.n, max)
+	}
+}
+
+func TestSetContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lexer := NewLexer(NewInput(strings.Repeat("a ", 1000)))
+	lexer.SetContext(ctx)
+
+	for i := 0; i < 10; i++ {
+		lexer.Next()
+	}
+	cancel()
+
+	n := 0
+	for lexer.Next().Type != EOFToken {
+		n++
+	}
+	if n >= contextCheckInterval || !errors.Is(lexer.Err(), context.Canceled) {
+		t.Errorf("read %d tokens after cancellation, Err() = %v", n, lexer.Err())
+	}
+	if lexer.Next().Type != EOFToken {
+		t.Errorf("Next() after cancellation did not return EOFToken")
+	}
+
+	input := NewInput("a")
+	lexer.SetContext(context.Background())
+	lexer.Reset(input)
+	if token := lexer.Next(); token.Type != IdentToken || lexer.Err() != nil {
+		t.Errorf("Next() after Reset() = %s, Err() = %v", token, lexer.Err())
+	}
+}
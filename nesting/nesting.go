@@ -0,0 +1,133 @@
+// Package nesting flattens nested style rules into plain CSS for targets
+// without support for CSS Nesting.
+//
+// https://www.w3.org/TR/css-nesting-1/
+package nesting
+
+import (
+	"strings"
+
+	"go.baoshuo.dev/csslexer/parser"
+)
+
+// Options controls how nested selectors are combined with their parents.
+type Options struct {
+	// UseIs wraps parent selectors in :is() whenever substituting them
+	// textually would change the meaning, which is the case for complex
+	// selectors and selector lists. The result then requires :is()
+	// support. By default, parent selectors are always substituted
+	// textually, which can slightly change matching and specificity for
+	// complex parent selectors, but works with older browsers.
+	UseIs bool
+}
+
+// Desugar returns a copy of the style sheet where nested style rules are
+// flattened into top-level rules.
+//
+// Nested conditional group rules such as @media and @supports are hoisted
+// out of their parent style rule, with the declarations they contain
+// wrapped in a rule using the parent selector. Declarations that follow
+// nested rules become separate rules, preserving the cascade order.
+func Desugar(sheet *parser.Stylesheet, opts Options) *parser.Stylesheet {
+	d := &desugarer{opts: opts}
+	return &parser.Stylesheet{Rules: d.topLevel(sheet.Rules)}
+}
+
+type desugarer struct {
+	opts Options
+}
+
+// topLevel flattens nodes that are not inside a style rule.
+func (d *desugarer) topLevel(nodes []parser.Node) []parser.Node {
+	var out []parser.Node
+
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *parser.QualifiedRule:
+			out = append(out, d.rule(n, nil)...)
+
+		case *parser.AtRule:
+			if !n.HasBlock {
+				out = append(out, n)
+				continue
+			}
+			rule := *n
+			rule.Block = d.topLevel(n.Block)
+			out = append(out, &rule)
+
+		default:
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
+
+// rule flattens a style rule nested in rules matching parent, or a
+// top-level style rule when parent is nil.
+func (d *desugarer) rule(r *parser.QualifiedRule, parent []selector) []parser.Node {
+	selectors := splitSelectorList(r.Prelude)
+	if parent != nil {
+		selectors = d.resolve(selectors, parent)
+	}
+
+	out := d.contents(r.Block, selectors, r.Span)
+	if len(out) == 0 {
+		// Keep empty rules, they are not ours to remove.
+		out = append(out, &parser.QualifiedRule{Prelude: joinSelectorList(selectors), Span: r.Span})
+	}
+	return out
+}
+
+// contents flattens the contents of a style rule whose resolved selector
+// list is selectors.
+func (d *desugarer) contents(nodes []parser.Node, selectors []selector, span parser.Span) []parser.Node {
+	var out []parser.Node
+	var group []parser.Node
+
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		out = append(out, &parser.QualifiedRule{
+			Prelude: joinSelectorList(selectors),
+			Block:   group,
+			Span:    span,
+		})
+		group = nil
+	}
+
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *parser.QualifiedRule:
+			flush()
+			out = append(out, d.rule(n, selectors)...)
+
+		case *parser.AtRule:
+			if !n.HasBlock || !isConditionalGroupRule(n.Name) {
+				group = append(group, n)
+				continue
+			}
+			flush()
+			rule := *n
+			rule.Block = d.contents(n.Block, selectors, n.Span)
+			out = append(out, &rule)
+
+		default:
+			group = append(group, n)
+		}
+	}
+	flush()
+
+	return out
+}
+
+// isConditionalGroupRule reports whether an at-rule can be nested in a
+// style rule, with its contents applying to the parent selector.
+func isConditionalGroupRule(name string) bool {
+	switch strings.ToLower(name) {
+	case "media", "supports", "container", "layer", "starting-style", "document", "-moz-document":
+		return true
+	}
+	return false
+}
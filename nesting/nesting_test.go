@@ -0,0 +1,108 @@
+package nesting
+
+import (
+	"testing"
+
+	"go.baoshuo.dev/csslexer/parser"
+)
+
+func TestDesugar(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "Plain rules are unchanged",
+			input:    "a { color: red } @import 'x.css';",
+			expected: "a{color:red;}\n@import 'x.css';",
+		},
+		{
+			name:     "Descendant without ampersand",
+			input:    ".card { color: red; .title { font-weight: bold } }",
+			expected: ".card{color:red;}\n.card .title{font-weight:bold;}",
+		},
+		{
+			name:     "Ampersand",
+			input:    ".btn { &:hover { color: red } & + & { margin: 0 } }",
+			expected: ".btn:hover{color:red;}\n.btn + .btn{margin:0;}",
+		},
+		{
+			name:     "Relative selector",
+			input:    "ul { > li { x: y } ~ p { x: z } }",
+			expected: "ul > li{x:y;}\nul ~ p{x:z;}",
+		},
+		{
+			name:     "Selector lists",
+			input:    ".a, .b { .c, &.d { x: y } }",
+			expected: ".a .c, .b .c, .a.d, .b.d{x:y;}",
+		},
+		{
+			name:     "Ampersand after the nested selector",
+			input:    ".a { .b & { x: y } }",
+			expected: ".b .a{x:y;}",
+		},
+		{
+			name:     "Deep nesting",
+			input:    ".a { .b { .c { x: y } } }",
+			expected: ".a .b .c{x:y;}",
+		},
+		{
+			name:     "Nested media query",
+			input:    ".card { color: red; @media (min-width: 10px) { color: blue; .t { x: y } } }",
+			expected: ".card{color:red;}\n@media (min-width: 10px){.card{color:blue;}.card .t{x:y;}}",
+		},
+		{
+			name:     "Declarations after nested rules",
+			input:    ".a { color: red; .b { x: y } color: blue; }",
+			expected: ".a{color:red;}\n.a .b{x:y;}\n.a{color:blue;}",
+		},
+		{
+			name:     "Nesting inside top-level at-rules",
+			input:    "@supports (display: grid) { .a { .b { x: y } } }",
+			expected: "@supports (display: grid){.a .b{x:y;}}",
+		},
+		{
+			name:     "Empty rule is kept",
+			input:    ".a { .b { } }",
+			expected: ".a .b{}",
+		},
+		{
+			name:     "Attached ampersand with type selector parent",
+			input:    "div { .x& { y: z } }",
+			expected: ".x:is(div){y:z;}",
+		},
+		{
+			name:     "Multiple ampersands with a selector list",
+			input:    ".a, .b { & & { x: y } }",
+			expected: ":is(.a, .b) :is(.a, .b){x:y;}",
+		},
+		{
+			name:     "UseIs with a complex parent",
+			input:    ".a .b { .c & { x: y } }",
+			opts:     Options{UseIs: true},
+			expected: ".c :is(.a .b){x:y;}",
+		},
+		{
+			name:     "UseIs with a compound parent",
+			input:    ".a.b { .c { x: y } }",
+			opts:     Options{UseIs: true},
+			expected: ".a.b .c{x:y;}",
+		},
+		{
+			name:     "Other nested at-rules stay in place",
+			input:    ".a { @apply --x; color: red; }",
+			expected: ".a{@apply --x;color:red;}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Desugar(parser.ParseString(tt.input), tt.opts).String()
+			if result != tt.expected {
+				t.Errorf("Desugar(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
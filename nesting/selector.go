@@ -0,0 +1,171 @@
+package nesting
+
+import (
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+// selector is a complex selector, as a sequence of tokens trimmed of
+// whitespace.
+type selector []csslexer.Token
+
+var (
+	ampersandToken  = csslexer.Token{Type: csslexer.DelimiterToken, Value: "&"}
+	whitespaceToken = csslexer.Token{Type: csslexer.WhitespaceToken, Value: " "}
+	commaToken      = csslexer.Token{Type: csslexer.CommaToken, Value: ","}
+	colonToken      = csslexer.Token{Type: csslexer.ColonToken, Value: ":"}
+	isFunctionToken = csslexer.Token{Type: csslexer.FunctionToken, Value: "is"}
+	rightParenToken = csslexer.Token{Type: csslexer.RightParenthesisToken, Value: ")"}
+)
+
+// splitSelectorList splits a selector list at its top-level commas.
+func splitSelectorList(tokens []csslexer.Token) []selector {
+	var selectors []selector
+
+	depth, start := 0, 0
+	for i, token := range tokens {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.CommaToken:
+			if depth == 0 {
+				selectors = append(selectors, selector(tokenutil.TrimBlank(tokens[start:i])))
+				start = i + 1
+			}
+		}
+	}
+	selectors = append(selectors, selector(tokenutil.TrimBlank(tokens[start:])))
+
+	return selectors
+}
+
+// joinSelectorList joins selectors into a selector list.
+func joinSelectorList(selectors []selector) []csslexer.Token {
+	var tokens []csslexer.Token
+	for i, s := range selectors {
+		if i > 0 {
+			tokens = append(tokens, commaToken, whitespaceToken)
+		}
+		tokens = append(tokens, s...)
+	}
+	return tokens
+}
+
+// resolve combines nested selectors with the selectors of their parent
+// rule, replacing the nesting selector `&` and making relative selectors
+// descendants of the parent.
+//
+// https://www.w3.org/TR/css-nesting-1/#nest-selector
+func (d *desugarer) resolve(nested, parent []selector) []selector {
+	useIs := d.opts.UseIs && !(len(parent) == 1 && isCompound(parent[0]))
+
+	var resolved []selector
+	for _, s := range nested {
+		count := countAmpersands(s)
+		if count == 0 {
+			// A nested selector without '&' is relative to the parent:
+			// `.b` means `& .b` and `> .b` means `& > .b`.
+			s = append(selector{ampersandToken, whitespaceToken}, s...)
+			count = 1
+		}
+
+		if useIs || (count > 1 && len(parent) > 1) || needsIs(s, parent) {
+			is := append(selector{colonToken, isFunctionToken}, joinSelectorList(parent)...)
+			resolved = append(resolved, replaceAmpersands(s, append(is, rightParenToken)))
+			continue
+		}
+
+		for _, p := range parent {
+			resolved = append(resolved, replaceAmpersands(s, p))
+		}
+	}
+
+	return resolved
+}
+
+// replaceAmpersands replaces every nesting selector in s with the tokens of
+// the replacement.
+func replaceAmpersands(s selector, replacement selector) selector {
+	result := make(selector, 0, len(s)+len(replacement))
+	for _, token := range s {
+		if isAmpersand(token) {
+			result = append(result, replacement...)
+			continue
+		}
+		result = append(result, token)
+	}
+	return result
+}
+
+// needsIs reports whether substituting a parent selector textually would
+// produce an invalid selector. This is the case when '&' is attached to a
+// preceding simple selector, like in `.a&`, and a parent selector starts
+// with a type selector, which must come first in a compound selector.
+func needsIs(s selector, parent []selector) bool {
+	attached := false
+	for i, token := range s {
+		if isAmpersand(token) && i > 0 && !isSeparator(s[i-1]) {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		return false
+	}
+
+	for _, p := range parent {
+		if len(p) > 0 && (p[0].Type == csslexer.IdentToken || isDelim(p[0], "*") || isDelim(p[0], "|")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSeparator reports whether a token ends a compound selector, so that a
+// selector following it starts a new compound selector.
+func isSeparator(token csslexer.Token) bool {
+	switch token.Type {
+	case csslexer.WhitespaceToken, csslexer.CommentToken, csslexer.CommaToken,
+		csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.ColumnToken:
+		return true
+	}
+	return isDelim(token, ">") || isDelim(token, "+") || isDelim(token, "~")
+}
+
+// isCompound reports whether a selector has no combinators.
+func isCompound(s selector) bool {
+	depth := 0
+	for _, token := range s {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		default:
+			if depth == 0 && token.Type != csslexer.CommaToken && isSeparator(token) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func countAmpersands(s selector) int {
+	count := 0
+	for _, token := range s {
+		if isAmpersand(token) {
+			count++
+		}
+	}
+	return count
+}
+
+func isAmpersand(token csslexer.Token) bool {
+	return isDelim(token, "&")
+}
+
+func isDelim(token csslexer.Token, value string) bool {
+	return token.Type == csslexer.DelimiterToken && token.Value == value
+}
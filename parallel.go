@@ -0,0 +1,259 @@
+package csslexer
+
+import (
+	"runtime"
+	"sync"
+)
+
+// minParallelChunk is the minimum number of runes lexed by one goroutine
+// of TokenizeParallel.
+const minParallelChunk = 64 * 1024
+
+// runesPerToken is the typical number of runes per token in style sheets,
+// used to size the token slices of the chunks.
+const runesPerToken = 4
+
+// TokenizeParallel reads all the tokens of the input on up to the given
+// number of goroutines, or GOMAXPROCS if workers is not positive. It
+// returns the same tokens as calling Next until EOFToken, which is not
+// included, and consumes the input.
+//
+// The input is split after '}' tokens found by a pre-scan. The lexer keeps
+// no state across a '}' token, so the chunks are lexed independently and
+// concatenated. Each split is checked against the tokens of the chunk
+// before it, and chunks are lexed together when the pre-scan was wrong, so
+// the result never depends on the pre-scan. Small inputs and templates are
+// lexed sequentially.
+func TokenizeParallel(r *Input, workers int) []Token {
+	return tokenizeParallel(r, workers, minParallelChunk)
+}
+
+func tokenizeParallel(r *Input, workers, minChunk int) []Token {
+	var results [][]Token
+	chunks := lexParallel(r, workers, minChunk, func(n int) {
+		results = make([][]Token, n)
+	}, func(i, start, end int) bool {
+		lexer := NewLexer(&Input{runes: r.runes[start:end:end]})
+		tokens := results[i][:0]
+		if tokens == nil {
+			tokens = make([]Token, 0, (end-start)/runesPerToken)
+		}
+		for {
+			token := lexer.Next()
+			if token.Type == EOFToken {
+				break
+			}
+			tokens = append(tokens, token)
+		}
+		results[i] = tokens
+		return len(tokens) > 0 && tokens[len(tokens)-1].Type == RightBraceToken
+	})
+
+	if len(chunks) == 1 {
+		return results[chunks[0]]
+	}
+	n := 0
+	for _, i := range chunks {
+		n += len(results[i])
+	}
+	tokens := make([]Token, 0, n)
+	for _, i := range chunks {
+		tokens = append(tokens, results[i]...)
+	}
+	return tokens
+}
+
+// TokenizeParallelRaw is like TokenizeParallel, but reads tokens without
+// their values, as NextRaw does. The ranges of the tokens are positions in
+// the whole input, and their values can be obtained with the Value method
+// of a Lexer of the input.
+func TokenizeParallelRaw(r *Input, workers int) []RawToken {
+	return tokenizeParallelRaw(r, workers, minParallelChunk)
+}
+
+func tokenizeParallelRaw(r *Input, workers, minChunk int) []RawToken {
+	var results [][]RawToken
+	chunks := lexParallel(r, workers, minChunk, func(n int) {
+		results = make([][]RawToken, n)
+	}, func(i, start, end int) bool {
+		lexer := NewLexer(&Input{runes: r.runes[start:end:end]})
+		tokens := results[i][:0]
+		if tokens == nil {
+			tokens = make([]RawToken, 0, (end-start)/runesPerToken)
+		}
+		for {
+			token := lexer.NextRaw()
+			if token.Type == EOFToken {
+				break
+			}
+			token.Start += start
+			token.End += start
+			tokens = append(tokens, token)
+		}
+		results[i] = tokens
+		return len(tokens) > 0 && tokens[len(tokens)-1].Type == RightBraceToken
+	})
+
+	if len(chunks) == 1 {
+		return results[chunks[0]]
+	}
+	n := 0
+	for _, i := range chunks {
+		n += len(results[i])
+	}
+	tokens := make([]RawToken, 0, n)
+	for _, i := range chunks {
+		tokens = append(tokens, results[i]...)
+	}
+	return tokens
+}
+
+// lexParallel splits the input into chunks, calls alloc with the number of
+// chunks, and then lex for each chunk concurrently, with the index of the
+// chunk and its range. lex returns whether the last token of the chunk is
+// a '}'. It returns the indices of the chunks whose tokens form the tokens
+// of the input, in order, and consumes the input.
+//
+// A chunk not ending with a '}' token was split inside a token, so it is
+// lexed again together with the next chunk, reusing the index of the
+// latter.
+func lexParallel(r *Input, workers, minChunk int, alloc func(n int), lex func(i, start, end int) bool) []int {
+	ends := splitInput(r, workers, minChunk)
+	alloc(len(ends))
+
+	ok := make([]bool, len(ends))
+	if len(ends) == 1 {
+		lex(0, r.pos, ends[0])
+	} else {
+		var wg sync.WaitGroup
+		start := r.pos
+		for i, end := range ends {
+			wg.Add(1)
+			go func(i, start, end int) {
+				defer wg.Done()
+				ok[i] = lex(i, start, end)
+			}(i, start, end)
+			start = end
+		}
+		wg.Wait()
+	}
+
+	var chunks []int
+	start, from := r.pos, r.pos // from is the end of the last accepted chunk
+	for i, end := range ends {
+		if start != from {
+			ok[i] = lex(i, from, end)
+		}
+		start = end
+		if i < len(ends)-1 && !ok[i] {
+			continue
+		}
+		chunks = append(chunks, i)
+		from = end
+	}
+
+	r.moveTo(len(r.runes))
+	r.Shift()
+	return chunks
+}
+
+// splitInput returns the ends of the chunks of the input to be lexed in
+// parallel, positions right after a '}' found by scanBraces. The last
+// chunk ends at the end of the input.
+func splitInput(r *Input, workers, minChunk int) []int {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	n := len(r.runes) - r.pos
+	if r.slots != nil || workers < 2 || n < 2*minChunk {
+		return []int{len(r.runes)}
+	}
+
+	size := n / workers
+	if size < minChunk {
+		size = minChunk
+	}
+	return append(scanBraces(r.runes, r.pos, size), len(r.runes))
+}
+
+// scanBraces returns the positions after the first '}' following every
+// size runes from the start, skipping comments, strings, escapes and
+// unquoted URLs.
+//
+// The scan only approximates the lexer: a position it returns may be
+// inside a token, such as in a function named "xurl(", but is then
+// rejected by lexParallel.
+func scanBraces(runes []rune, start, size int) []int {
+	var ends []int
+	next := start + size
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '"', '\'':
+			i = skipString(runes, i)
+		case '/':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				i = skipComment(runes, i)
+			}
+		case '(':
+			if i >= 3 && equalFoldRunes(runes[i-3:i], "url") {
+				i = skipURL(runes, i)
+			}
+		case '}':
+			if i+1 >= next && i+1 < len(runes) {
+				ends = append(ends, i+1)
+				next = i + 1 + size
+			}
+		}
+	}
+	return ends
+}
+
+// skipString returns the position of the end of the string starting at
+// the given quote: its closing quote or the newline ending a bad string.
+func skipString(runes []rune, i int) int {
+	quote := runes[i]
+	for i++; i < len(runes); i++ {
+		switch runes[i] {
+		case quote, '\n', '\r', '\f':
+			return i
+		case '\\':
+			i++
+		}
+	}
+	return i
+}
+
+// skipComment returns the position of the '/' ending the comment starting
+// at the given position.
+func skipComment(runes []rune, i int) int {
+	for i += 2; i+1 < len(runes); i++ {
+		if runes[i] == '*' && runes[i+1] == '/' {
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+// skipURL returns the position of the ')' ending the unquoted URL whose
+// '(' is at the given position, or the position of the '(' if the URL is
+// quoted.
+func skipURL(runes []rune, i int) int {
+	j := i + 1
+	for j < len(runes) && isSpaceRune(runes[j]) {
+		j++
+	}
+	if j < len(runes) && (runes[j] == '"' || runes[j] == '\'') {
+		return i
+	}
+	for ; j < len(runes); j++ {
+		switch runes[j] {
+		case ')':
+			return j
+		case '\\':
+			j++
+		}
+	}
+	return j
+}
@@ -0,0 +1,111 @@
+package csslexer
+
+import (
+	"testing"
+)
+
+// sequentialTokens returns the tokens read with Next and NextRaw.
+func sequentialTokens(source []rune) ([]Token, []RawToken) {
+	var tokens []Token
+	lexer := NewLexer(NewInputRunes(source))
+	for token := lexer.Next(); token.Type != EOFToken; token = lexer.Next() {
+		tokens = append(tokens, token)
+	}
+
+	var raw []RawToken
+	lexer = NewLexer(NewInputRunes(source))
+	for token := lexer.NextRaw(); token.Type != EOFToken; token = lexer.NextRaw() {
+		raw = append(raw, token)
+	}
+	return tokens, raw
+}
+
+// testParallel compares the parallel tokenizers with a sequential lexer.
+func testParallel(t *testing.T, name string, source []rune, workers, minChunk int) {
+	t.Helper()
+
+	expected, expectedRaw := sequentialTokens(source)
+
+	tokens := tokenizeParallel(NewInputRunes(source), workers, minChunk)
+	if len(tokens) != len(expected) {
+		t.Errorf("%s: tokenizeParallel() returned %d tokens, expected %d", name, len(tokens), len(expected))
+	}
+	for i := 0; i < len(tokens) && i < len(expected); i++ {
+		if tokens[i].Type != expected[i].Type || tokens[i].Value != expected[i].Value || string(tokens[i].Raw) != string(expected[i].Raw) {
+			t.Errorf("%s: token %d = %s, expected %s", name, i, tokens[i], expected[i])
+			break
+		}
+	}
+
+	raw := tokenizeParallelRaw(NewInputRunes(source), workers, minChunk)
+	if len(raw) != len(expectedRaw) {
+		t.Errorf("%s: tokenizeParallelRaw() returned %d tokens, expected %d", name, len(raw), len(expectedRaw))
+	}
+	for i := 0; i < len(raw) && i < len(expectedRaw); i++ {
+		if raw[i] != expectedRaw[i] {
+			t.Errorf("%s: raw token %d = %v, expected %v", name, i, raw[i], expectedRaw[i])
+			break
+		}
+	}
+}
+
+func TestTokenizeParallel(t *testing.T) {
+	for name, source := range testSources(t) {
+		runes := []rune(string(source))
+		for _, minChunk := range []int{1, 97, 4096} {
+			testParallel(t, name, runes, 4, minChunk)
+		}
+	}
+}
+
+// TestTokenizeParallelSplits checks inputs where a '}' does not end a
+// token, with a split tried after every '}'.
+func TestTokenizeParallelSplits(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Rules", "a{b:c}d{e:f}g{}"},
+		{"Strings", `a{b:"}"}c{d:'}\'}'}e{f:"}` + "\n" + `}g{}`},
+		{"Comments", "a{/* } */}b{/* }"},
+		{"Escapes", `a{b:c\}d}e\}{}f{}\`},
+		{"URLs", `a{b:url(})}c{d:url( "}" )}e{f:URL(\)})}`},
+		{"Bad URLs", `a{b:url(x"}y)}c{d:url(x }`},
+		{"Functions named like url", `a{b:xurl(}"}")}c{d:#url(}/*)*/}e{}`},
+		{"Split inside a comment", `a{b:xurl(}/*)}*/}c{}`},
+		{"Unterminated", `a{}b{"}}}c{}`},
+		{"Nested", "@media x{a{b:c}}d{}"},
+		{"Non-ASCII", "a{b:\u00e9}\U0001F600{c:\u00e9}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testParallel(t, tt.name, []rune(tt.input), len(tt.input), 1)
+		})
+	}
+}
+
+func TestSplitInput(t *testing.T) {
+	input := NewInput("a{}b{}c{}d{}e{}f{}")
+
+	if ends := splitInput(input, 1, 1); len(ends) != 1 {
+		t.Errorf("splitInput() with one worker = %v, expected a single chunk", ends)
+	}
+	if ends := splitInput(input, 4, 100); len(ends) != 1 {
+		t.Errorf("splitInput() of a small input = %v, expected a single chunk", ends)
+	}
+	if ends := splitInput(NewInputTemplate([]string{"a{}b{}", "c{}d{}"}), 4, 1); len(ends) != 1 {
+		t.Errorf("splitInput() of a template = %v, expected a single chunk", ends)
+	}
+
+	ends := splitInput(input, 3, 1)
+	expected := []int{6, 12, 18}
+	if len(ends) != len(expected) {
+		t.Fatalf("splitInput() = %v, expected %v", ends, expected)
+	}
+	for i := range ends {
+		if ends[i] != expected[i] {
+			t.Fatalf("splitInput() = %v, expected %v", ends, expected)
+		}
+	}
+}
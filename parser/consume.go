@@ -0,0 +1,379 @@
+package parser
+
+import (
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+// parser holds the tokens of the whole input, so that the parser can
+// backtrack when a declaration turns out to be a nested rule.
+type parser struct {
+	tokens []csslexer.Token
+	starts []int // start offset of each token, plus the end of the input
+	pos    int
+}
+
+func newParser(input *csslexer.Input) *parser {
+	return newParserLexer(csslexer.NewLexer(input))
+}
+
+// newParserLexer reads all the tokens of a lexer.
+func newParserLexer(lexer *csslexer.Lexer) *parser {
+	p := &parser{}
+
+	offset := 0
+	for {
+		token := lexer.Next()
+		p.starts = append(p.starts, offset)
+		if token.Type == csslexer.EOFToken {
+			break
+		}
+		p.tokens = append(p.tokens, token)
+		offset += len(token.Raw)
+	}
+
+	return p
+}
+
+var eofToken = csslexer.Token{Type: csslexer.EOFToken}
+
+func (p *parser) peek() csslexer.Token {
+	if p.pos >= len(p.tokens) {
+		return eofToken
+	}
+	return p.tokens[p.pos]
+}
+
+// span returns the range covered by the tokens in [start, end).
+func (p *parser) span(start, end int) Span {
+	return Span{Start: p.starts[start], End: p.starts[end]}
+}
+
+// https://drafts.csswg.org/css-syntax-3/#consume-stylesheet-contents
+func (p *parser) consumeStylesheetContents() []Node {
+	var rules []Node
+
+	for {
+		switch p.peek().Type {
+		case csslexer.WhitespaceToken, csslexer.CommentToken, csslexer.CDOToken, csslexer.CDCToken:
+			p.pos++
+
+		case csslexer.EOFToken:
+			return rules
+
+		case csslexer.AtKeywordToken:
+			rules = append(rules, p.consumeAtRule(false))
+
+		default:
+			if rule := p.consumeQualifiedRule(false); rule != nil {
+				rules = append(rules, rule)
+			}
+		}
+	}
+}
+
+// https://drafts.csswg.org/css-syntax-3/#consume-at-rule
+func (p *parser) consumeAtRule(nested bool) *AtRule {
+	start := p.pos
+	rule := &AtRule{Name: p.peek().Value}
+	p.pos++
+
+	preludeStart := p.pos
+	for {
+		switch p.peek().Type {
+		case csslexer.SemicolonToken:
+			rule.Prelude = tokenutil.TrimWhitespace(p.tokens[preludeStart:p.pos])
+			p.pos++
+			rule.Span = p.span(start, p.pos)
+			return rule
+
+		case csslexer.EOFToken:
+			rule.Prelude = tokenutil.TrimWhitespace(p.tokens[preludeStart:p.pos])
+			rule.Span = p.span(start, p.pos)
+			return rule
+
+		case csslexer.RightBraceToken:
+			if nested {
+				rule.Prelude = tokenutil.TrimWhitespace(p.tokens[preludeStart:p.pos])
+				rule.Span = p.span(start, p.pos)
+				return rule
+			}
+			p.pos++
+
+		case csslexer.LeftBraceToken:
+			rule.Prelude = tokenutil.TrimWhitespace(p.tokens[preludeStart:p.pos])
+			rule.Block = p.consumeBlock()
+			rule.HasBlock = true
+			rule.Span = p.span(start, p.pos)
+			return rule
+
+		default:
+			p.consumeComponentValue()
+		}
+	}
+}
+
+// https://drafts.csswg.org/css-syntax-3/#consume-qualified-rule
+func (p *parser) consumeQualifiedRule(nested bool) *QualifiedRule {
+	start := p.pos
+
+	for {
+		switch p.peek().Type {
+		case csslexer.EOFToken:
+			return nil
+
+		case csslexer.SemicolonToken:
+			if nested {
+				p.consumeBadDeclarationRemnants()
+				return nil
+			}
+			p.pos++
+
+		case csslexer.RightBraceToken:
+			if nested {
+				return nil
+			}
+			p.pos++
+
+		case csslexer.LeftBraceToken:
+			prelude := tokenutil.TrimWhitespace(p.tokens[start:p.pos])
+			block := p.consumeBlock()
+
+			// A rule looking like a custom property declaration is
+			// invalid, to keep the two unambiguous.
+			if startsLikeCustomProperty(prelude) {
+				return nil
+			}
+
+			return &QualifiedRule{
+				Prelude: prelude,
+				Block:   block,
+				Span:    p.span(start, p.pos),
+			}
+
+		default:
+			p.consumeComponentValue()
+		}
+	}
+}
+
+// consumeBlock consumes a {}-block, the current token being the '{'.
+//
+// https://drafts.csswg.org/css-syntax-3/#consume-block
+func (p *parser) consumeBlock() []Node {
+	p.pos++ // consume '{'
+	contents := p.consumeBlockContents()
+	if p.peek().Type == csslexer.RightBraceToken {
+		p.pos++ // consume '}'
+	}
+	return contents
+}
+
+// https://drafts.csswg.org/css-syntax-3/#consume-block-contents
+func (p *parser) consumeBlockContents() []Node {
+	var nodes []Node
+
+	for {
+		switch p.peek().Type {
+		case csslexer.WhitespaceToken, csslexer.CommentToken, csslexer.SemicolonToken:
+			p.pos++
+
+		case csslexer.EOFToken, csslexer.RightBraceToken:
+			return nodes
+
+		case csslexer.AtKeywordToken:
+			nodes = append(nodes, p.consumeAtRule(true))
+
+		default:
+			mark := p.pos
+			if decl := p.consumeDeclaration(); decl != nil {
+				nodes = append(nodes, decl)
+				continue
+			}
+
+			p.pos = mark
+			if rule := p.consumeQualifiedRule(true); rule != nil {
+				nodes = append(nodes, rule)
+			}
+		}
+	}
+}
+
+// https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-list-of-declarations
+func (p *parser) consumeDeclarationList() []*Declaration {
+	var decls []*Declaration
+
+	for {
+		switch p.peek().Type {
+		case csslexer.WhitespaceToken, csslexer.CommentToken, csslexer.SemicolonToken:
+			p.pos++
+
+		case csslexer.EOFToken:
+			return decls
+
+		case csslexer.AtKeywordToken:
+			p.consumeAtRule(false)
+
+		default:
+			// The declaration extends to the next semicolon, including any
+			// stray '}'.
+			start := p.pos
+			for t := p.peek().Type; t != csslexer.SemicolonToken && t != csslexer.EOFToken; t = p.peek().Type {
+				p.consumeComponentValue()
+			}
+			end := p.pos
+
+			sub := &parser{tokens: p.tokens[:end], starts: p.starts[:end+1], pos: start}
+			if decl := sub.consumeDeclaration(); decl != nil && sub.pos == end {
+				decls = append(decls, decl)
+			}
+		}
+	}
+}
+
+// consumeDeclaration consumes a declaration, returning nil when the tokens
+// do not form one. The terminating semicolon is left in the input.
+//
+// https://drafts.csswg.org/css-syntax-3/#consume-declaration
+func (p *parser) consumeDeclaration() *Declaration {
+	start := p.pos
+	if p.peek().Type != csslexer.IdentToken {
+		p.consumeBadDeclarationRemnants()
+		return nil
+	}
+	decl := &Declaration{Name: p.peek().Value}
+	p.pos++
+
+	p.skipWhitespace()
+	if p.peek().Type != csslexer.ColonToken {
+		p.consumeBadDeclarationRemnants()
+		return nil
+	}
+	p.pos++
+
+	valueStart := p.pos
+	for {
+		t := p.peek().Type
+		if t == csslexer.SemicolonToken || t == csslexer.RightBraceToken || t == csslexer.EOFToken {
+			break
+		}
+		p.consumeComponentValue()
+	}
+
+	value := tokenutil.TrimWhitespace(p.tokens[valueStart:p.pos])
+	decl.Value, decl.Important = tokenutil.TrimImportant(value)
+
+	// A value with a {}-block belongs to a nested rule such as
+	// `a:hover { ... }`, unless the property is a custom property.
+	if !tokenutil.IsCustomPropertyName(decl.Name) && containsBraceBlock(decl.Value) {
+		return nil
+	}
+
+	end := p.pos
+	for end > valueStart && p.tokens[end-1].Type == csslexer.WhitespaceToken {
+		end--
+	}
+	decl.Span = p.span(start, end)
+	return decl
+}
+
+// https://drafts.csswg.org/css-syntax-3/#consume-the-remnants-of-a-bad-declaration
+func (p *parser) consumeBadDeclarationRemnants() {
+	for {
+		switch p.peek().Type {
+		case csslexer.EOFToken, csslexer.RightBraceToken:
+			return
+
+		case csslexer.SemicolonToken:
+			p.pos++
+			return
+
+		default:
+			p.consumeComponentValue()
+		}
+	}
+}
+
+// consumeComponentValue skips over a component value: a single token, or a
+// function or simple block including its closing token.
+//
+// https://drafts.csswg.org/css-syntax-3/#consume-component-value
+func (p *parser) consumeComponentValue() {
+	var closers []csslexer.TokenType
+
+	for {
+		token := p.peek()
+		if token.Type == csslexer.EOFToken {
+			return
+		}
+		p.pos++
+
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken:
+			closers = append(closers, csslexer.RightParenthesisToken)
+		case csslexer.LeftBracketToken:
+			closers = append(closers, csslexer.RightBracketToken)
+		case csslexer.LeftBraceToken:
+			closers = append(closers, csslexer.RightBraceToken)
+		default:
+			if len(closers) > 0 && token.Type == closers[len(closers)-1] {
+				closers = closers[:len(closers)-1]
+			}
+		}
+
+		if len(closers) == 0 {
+			return
+		}
+	}
+}
+
+func (p *parser) skipWhitespace() {
+	for {
+		t := p.peek().Type
+		if t != csslexer.WhitespaceToken && t != csslexer.CommentToken {
+			return
+		}
+		p.pos++
+	}
+}
+
+// ===== Helpers =====
+
+// containsBraceBlock reports whether a value has a top-level {}-block.
+func containsBraceBlock(tokens []csslexer.Token) bool {
+	depth := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken, csslexer.LeftBracketToken:
+			depth++
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken:
+			depth--
+		case csslexer.LeftBraceToken:
+			if depth == 0 {
+				return true
+			}
+			depth++
+		case csslexer.RightBraceToken:
+			depth--
+		}
+	}
+	return false
+}
+
+// startsLikeCustomProperty reports whether a prelude starts with a custom
+// property name followed by a colon.
+func startsLikeCustomProperty(prelude []csslexer.Token) bool {
+	if len(prelude) == 0 || prelude[0].Type != csslexer.IdentToken || !tokenutil.IsCustomPropertyName(prelude[0].Value) {
+		return false
+	}
+	for _, token := range prelude[1:] {
+		switch token.Type {
+		case csslexer.WhitespaceToken, csslexer.CommentToken:
+			continue
+		case csslexer.ColonToken:
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+// Package parser implements the parsing algorithms of CSS Syntax on top of
+// the lexer, turning a token stream into rules and declarations.
+//
+// Blocks are parsed with the nesting-aware algorithm, so a style rule may
+// contain declarations, nested style rules and nested at-rules in any
+// order.
+//
+// https://drafts.csswg.org/css-syntax-3/#parsing
+// https://www.w3.org/TR/css-nesting-1/
+package parser
+
+import (
+	"context"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+// Span is a range of the input, in runes, with an exclusive end.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Node is a rule or a declaration.
+type Node interface {
+	// Pos returns the range of the input the node was parsed from.
+	Pos() Span
+}
+
+// Stylesheet is a parsed style sheet.
+type Stylesheet struct {
+	Rules []Node // QualifiedRule and AtRule nodes
+}
+
+// QualifiedRule is a rule with a prelude and a block, such as a style rule.
+type QualifiedRule struct {
+	Prelude []csslexer.Token // Tokens before the block, trimmed of whitespace
+	Block   []Node           // Contents of the block
+	Span    Span
+}
+
+// AtRule is a rule starting with an at-keyword.
+type AtRule struct {
+	Name     string           // Name of the at-keyword, without the '@'
+	Prelude  []csslexer.Token // Tokens after the name, trimmed of whitespace
+	Block    []Node           // Contents of the block
+	HasBlock bool             // Whether the rule ends with a block rather than a semicolon
+	Span     Span
+}
+
+// Declaration is a property declaration.
+type Declaration struct {
+	Name      string           // Property name, unescaped
+	Value     []csslexer.Token // Value tokens, trimmed of whitespace and !important
+	Important bool             // Whether the value ended with !important
+	Span      Span
+}
+
+func (r *QualifiedRule) Pos() Span { return r.Span }
+func (r *AtRule) Pos() Span        { return r.Span }
+func (d *Declaration) Pos() Span   { return d.Span }
+
+// Parse parses a style sheet from the given Input.
+//
+// https://drafts.csswg.org/css-syntax-3/#parse-a-stylesheet
+func Parse(input *csslexer.Input) *Stylesheet {
+	p := newParser(input)
+	return &Stylesheet{Rules: p.consumeStylesheetContents()}
+}
+
+// ParseString parses a style sheet from a string.
+func ParseString(s string) *Stylesheet {
+	return Parse(csslexer.NewInput(s))
+}
+
+// ParseContext is like Parse, for untrusted input: it stops when the input
+// exceeds the given limits or the context is canceled, returning a
+// *csslexer.LimitError or the error of the context. The nesting of the
+// returned rules is bounded by the MaxDepth limit.
+func ParseContext(ctx context.Context, input *csslexer.Input, limits csslexer.Limits) (*Stylesheet, error) {
+	p, err := newLimitedParser(ctx, input, limits)
+	if err != nil {
+		return nil, err
+	}
+	return &Stylesheet{Rules: p.consumeStylesheetContents()}, nil
+}
+
+// ParseBlockContents parses the contents of a block, such as the body of a
+// style rule, returning its declarations and rules in order.
+//
+// https://drafts.csswg.org/css-syntax-3/#parse-a-blocks-contents
+func ParseBlockContents(input *csslexer.Input) []Node {
+	p := newParser(input)
+
+	var nodes []Node
+	for {
+		nodes = append(nodes, p.consumeBlockContents()...)
+		if p.peek().Type == csslexer.EOFToken {
+			return nodes
+		}
+		p.pos++ // a stray '}' at the top level is ignored
+	}
+}
+
+// ParseDeclarationList parses a list of declarations, such as the value of
+// a style attribute or of element.style.cssText.
+//
+// As in browsers, an invalid declaration is skipped up to the next
+// semicolon, and at-rules are ignored. A '}' has no special meaning, so a
+// declaration containing a stray '}' is invalid rather than ending the
+// list.
+//
+// https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#parse-list-of-declarations
+func ParseDeclarationList(input *csslexer.Input) []*Declaration {
+	p := newParser(input)
+	return p.consumeDeclarationList()
+}
+
+// ParseDeclarationListContext is like ParseDeclarationList, with the limits
+// and context of ParseContext.
+func ParseDeclarationListContext(ctx context.Context, input *csslexer.Input, limits csslexer.Limits) ([]*Declaration, error) {
+	p, err := newLimitedParser(ctx, input, limits)
+	if err != nil {
+		return nil, err
+	}
+	return p.consumeDeclarationList(), nil
+}
+
+// ParseDeclarationListString parses a list of declarations from a string.
+func ParseDeclarationListString(s string) []*Declaration {
+	return ParseDeclarationList(csslexer.NewInput(s))
+}
+
+// newLimitedParser reads the tokens of the input within the limits, or
+// returns the error stopping the lexer.
+func newLimitedParser(ctx context.Context, input *csslexer.Input, limits csslexer.Limits) (*parser, error) {
+	lexer := csslexer.NewLexer(input)
+	lexer.SetContext(ctx)
+	lexer.SetLimits(limits)
+
+	p := newParserLexer(lexer)
+	if err := lexer.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
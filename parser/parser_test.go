@@ -0,0 +1,232 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Style rule",
+			input:    "a { color: red; margin : 0 auto }",
+			expected: "a{color:red;margin:0 auto;}",
+		},
+		{
+			name:     "Important",
+			input:    "a { color: red ! important; }",
+			expected: "a{color:red!important;}",
+		},
+		{
+			name:     "Statement and block at-rules",
+			input:    "@import url(a.css) screen;\n@media (min-width: 10px) { a { b: c } }",
+			expected: "@import url(a.css) screen;\n@media (min-width: 10px){a{b:c;}}",
+		},
+		{
+			name:     "Nested rules",
+			input:    ".card { color: red; & .title { x: y } .body { x: z } > p { } }",
+			expected: ".card{color:red;& .title{x:y;}.body{x:z;}> p{}}",
+		},
+		{
+			name:     "Nested rule that starts like a declaration",
+			input:    "a { b:hover { c: d } }",
+			expected: "a{b:hover{c:d;}}",
+		},
+		{
+			name:     "Nested at-rule",
+			input:    ".a { @media (x) { color: red; .b { c: d } } }",
+			expected: ".a{@media (x){color:red;.b{c:d;}}}",
+		},
+		{
+			name:     "Declarations after nested rules",
+			input:    ".a { color: red; .b { } color: blue; }",
+			expected: ".a{color:red;.b{}color:blue;}",
+		},
+		{
+			name:     "Custom property with braces",
+			input:    "a { --x: { a: b }; }",
+			expected: "a{--x:{ a: b };}",
+		},
+		{
+			name:     "Custom property that looks like a rule",
+			input:    "a { --x:hover { } color: red }",
+			expected: "a{--x:hover { } color: red;}",
+		},
+		{
+			name:     "Rule looking like custom property is dropped",
+			input:    "--x:hover { } a { b: c }",
+			expected: "a{b:c;}",
+		},
+		{
+			name:     "Invalid declaration is skipped",
+			input:    "a { 12px; color: red; & { } ; ! }",
+			expected: "a{color:red;&{}}",
+		},
+		{
+			name:     "Unclosed block",
+			input:    "a { b { c: d",
+			expected: "a{b{c:d;}}",
+		},
+		{
+			name:     "Top-level CDO and CDC are ignored",
+			input:    "<!-- a {} -->",
+			expected: "a{}",
+		},
+		{
+			name:     "Unfinished qualified rule is dropped",
+			input:    "a {} b",
+			expected: "a{}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseString(tt.input).String()
+			if result != tt.expected {
+				t.Errorf("ParseString(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	input := "a { color: red ; }\n@x y;"
+	sheet := ParseString(input)
+	runes := []rune(input)
+
+	if len(sheet.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(sheet.Rules))
+	}
+
+	rule := sheet.Rules[0].(*QualifiedRule)
+	if text := string(runes[rule.Span.Start:rule.Span.End]); text != "a { color: red ; }" {
+		t.Errorf("rule span covers %q", text)
+	}
+	decl := rule.Block[0].(*Declaration)
+	if text := string(runes[decl.Span.Start:decl.Span.End]); text != "color: red" {
+		t.Errorf("declaration span covers %q", text)
+	}
+	atRule := sheet.Rules[1].(*AtRule)
+	if text := string(runes[atRule.Span.Start:atRule.Span.End]); text != "@x y;" {
+		t.Errorf("at-rule span covers %q", text)
+	}
+}
+
+func TestParseBlockContents(t *testing.T) {
+	nodes := ParseBlockContents(csslexer.NewInput("color: red; } .a { b: c } width: 1px"))
+
+	var names []string
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Declaration:
+			names = append(names, n.Name)
+		case *QualifiedRule:
+			names = append(names, csslexer.Serialize(n.Prelude))
+		}
+	}
+
+	expected := []string{"color", ".a", "width"}
+	if len(names) != len(expected) {
+		t.Fatalf("ParseBlockContents() returned %q, expected %q", names, expected)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Errorf("ParseBlockContents() returned %q, expected %q", names, expected)
+			break
+		}
+	}
+}
+
+func TestParseDeclarationList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Declarations",
+			input:    " color: red ; margin:0 auto;;--x: { a } ",
+			expected: []string{"color:red", "margin:0 auto", "--x:{ a }"},
+		},
+		{
+			name:     "Important",
+			input:    "color: red !IMPORTANT; width: 1px ! important",
+			expected: []string{"color:red!important", "width:1px!important"},
+		},
+		{
+			name:     "Important with comments",
+			input:    "color: red !important /* c */; width: 1px !/**/important",
+			expected: []string{"color:red!important", "width:1px!important"},
+		},
+		{
+			name:     "Invalid declarations are skipped",
+			input:    "color red; 1px: x; width: calc(1px; 2px); height: 1px",
+			expected: []string{"width:calc(1px; 2px)", "height:1px"},
+		},
+		{
+			name:     "Stray braces",
+			input:    "color: red}; a { b: c }; width: 1px; x: {y}",
+			expected: []string{"width:1px"},
+		},
+		{
+			name:     "At-rules are ignored",
+			input:    "@import url(x.css); color: red; @media x { a: b } width: 1px",
+			expected: []string{"color:red", "width:1px"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for _, decl := range ParseDeclarationListString(tt.input) {
+				result = append(result, decl.String())
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("ParseDeclarationListString(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("ParseDeclarationListString(%q) = %q, expected %q", tt.input, result, tt.expected)
+					break
+				}
+			}
+		})
+	}
+
+	input := "a:b; color : red !important ;"
+	decls := ParseDeclarationListString(input)
+	if text := string([]rune(input)[decls[1].Span.Start:decls[1].Span.End]); text != "color : red !important" {
+		t.Errorf("declaration span covers %q", text)
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	limits := csslexer.Limits{MaxDepth: 3}
+
+	input := "@media x { a { b: c(d) } }"
+	sheet, err := ParseContext(context.Background(), csslexer.NewInput(input), limits)
+	if err != nil || sheet.String() != ParseString(input).String() {
+		t.Errorf("ParseContext(%q) = %v, %v", input, sheet, err)
+	}
+
+	input = strings.Repeat("a{", 100000)
+	_, err = ParseContext(context.Background(), csslexer.NewInput(input), limits)
+	var limitErr *csslexer.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != csslexer.LimitDepth {
+		t.Errorf("ParseContext() of deep nesting returned %v, expected a depth limit error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseDeclarationListContext(ctx, csslexer.NewInput("a: b"), csslexer.Limits{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseDeclarationListContext() with a canceled context returned %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+// String returns the CSS text of the style sheet, one top-level rule per
+// line.
+func (s *Stylesheet) String() string {
+	var sb strings.Builder
+	for i, rule := range s.Rules {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		writeNode(&sb, rule)
+	}
+	return sb.String()
+}
+
+// String returns the CSS text of the rule.
+func (r *QualifiedRule) String() string {
+	var sb strings.Builder
+	writeNode(&sb, r)
+	return sb.String()
+}
+
+// String returns the CSS text of the rule.
+func (r *AtRule) String() string {
+	var sb strings.Builder
+	writeNode(&sb, r)
+	return sb.String()
+}
+
+// String returns the CSS text of the declaration, without a trailing
+// semicolon.
+func (d *Declaration) String() string {
+	var sb strings.Builder
+	writeNode(&sb, d)
+	return sb.String()
+}
+
+func writeNode(sb *strings.Builder, node Node) {
+	switch n := node.(type) {
+	case *QualifiedRule:
+		sb.WriteString(csslexer.Serialize(n.Prelude))
+		writeBlock(sb, n.Block)
+
+	case *AtRule:
+		sb.WriteString(csslexer.Token{Type: csslexer.AtKeywordToken, Value: n.Name}.String())
+		if len(n.Prelude) > 0 {
+			sb.WriteByte(' ')
+			sb.WriteString(csslexer.Serialize(n.Prelude))
+		}
+		if n.HasBlock {
+			writeBlock(sb, n.Block)
+		} else {
+			sb.WriteByte(';')
+		}
+
+	case *Declaration:
+		sb.WriteString(csslexer.Token{Type: csslexer.IdentToken, Value: n.Name}.String())
+		sb.WriteByte(':')
+		sb.WriteString(csslexer.Serialize(n.Value))
+		if n.Important {
+			sb.WriteString("!important")
+		}
+	}
+}
+
+func writeBlock(sb *strings.Builder, nodes []Node) {
+	sb.WriteByte('{')
+	for _, node := range nodes {
+		writeNode(sb, node)
+		if _, ok := node.(*Declaration); ok {
+			sb.WriteByte(';')
+		}
+	}
+	sb.WriteByte('}')
+}
@@ -0,0 +1,165 @@
+package csslexer
+
+import (
+	"sort"
+	"strconv"
+)
+
+// RawToken is a token without its value: its type and its range in the
+// input, in runes, with an exclusive end.
+type RawToken struct {
+	Type       TokenType
+	Start, End int
+}
+
+// NextRaw reads the next token from the input stream without computing its
+// value, which can be obtained with Value when needed. Unlike Next, it
+// does not allocate when reading CSS.
+func (l *Lexer) NextRaw() RawToken {
+	if l.p != nil {
+		// The peeked token ends at the start of the next one.
+		token := RawToken{Type: l.p.Type, Start: l.r.start - len(l.p.Raw), End: l.r.start}
+		tokenPool.Put(l.p)
+		l.p = nil
+		return token
+	}
+
+	l.lazy = true
+	tokenType, _ := l.readNextToken()
+	l.lazy = false
+
+	token := RawToken{Type: tokenType, Start: l.r.start, End: l.r.pos}
+	l.r.Shift()
+	return token
+}
+
+// Raw returns the source of a token read with NextRaw. The returned slice
+// shares the memory of the input and must not be modified.
+func (l *Lexer) Raw(t RawToken) []rune {
+	return l.r.runes[t.Start:t.End:t.End]
+}
+
+// Value returns the value of a token read with NextRaw, the same as the
+// Value of the token returned by Next. Escapes are only resolved if the
+// token contains a backslash.
+func (l *Lexer) Value(t RawToken) string {
+	raw := l.Raw(t)
+	if len(l.resumed) > 0 {
+		i := sort.Search(len(l.resumed), func(i int) bool { return l.resumed[i].start >= t.Start })
+		if i < len(l.resumed) && l.resumed[i].start == t.Start {
+			return l.relexResumed(raw, l.resumed[i].resume)
+		}
+	}
+
+	switch t.Type {
+	case SlotToken:
+		return strconv.Itoa(sort.SearchInts(l.r.slots, t.Start))
+
+	case IdentToken, FunctionToken, AtKeywordToken, HashToken, DimensionToken,
+		StringToken, BadStringToken, UrlToken, EscapedStringToken,
+		VariableToken, PlaceholderToken, DirectiveToken, AtVariableToken:
+		if containsRune(raw, '\\') {
+			return l.relex(raw)
+		}
+	}
+
+	switch t.Type {
+	case FunctionToken:
+		// The raw text of a url( function includes the whitespace after it.
+		for i, r := range raw {
+			if r == '(' {
+				return l.intern(raw[:i])
+			}
+		}
+
+	case AtKeywordToken, HashToken, VariableToken, PlaceholderToken, DirectiveToken, AtVariableToken:
+		return l.intern(raw[1:])
+
+	case IdentToken, DimensionToken:
+		return l.intern(raw)
+
+	case BadStringToken:
+		if raw[0] == '~' {
+			return string(raw[2:])
+		}
+		return string(raw[1:])
+
+	case StringToken:
+		if raw[0] != '"' && raw[0] != '\'' {
+			return l.relex(raw)
+		}
+		return string(trimQuote(raw[1:], raw[0]))
+
+	case EscapedStringToken:
+		return string(trimQuote(raw[2:], raw[1]))
+
+	case UrlToken:
+		raw = raw[4:] // "url("
+		if len(raw) > 0 && raw[len(raw)-1] == ')' {
+			raw = raw[:len(raw)-1]
+		}
+		return string(trimSpace(raw))
+
+	case BadUrlToken:
+		return ""
+
+	case LineCommentToken:
+		return string(raw[2:])
+
+	case JavaScriptToken:
+		if raw[0] == '~' {
+			raw = raw[1:]
+		}
+		return string(trimQuote(raw[1:], '`'))
+	}
+
+	return string(raw)
+}
+
+// relex tokenizes the source of a single token again, computing its value.
+func (l *Lexer) relex(raw []rune) string {
+	sub := NewLexerDialect(&Input{runes: raw}, l.dialect)
+	sub.interner = l.interner
+	_, value := sub.readToken()
+	return value
+}
+
+// relexResumed tokenizes the source of a token continuing after a template
+// slot again, computing its value.
+func (l *Lexer) relexResumed(raw []rune, resume rune) string {
+	sub := NewLexerDialect(&Input{runes: raw}, l.dialect)
+	sub.resume = resume
+	_, value := sub.readTemplateToken()
+	return value
+}
+
+func containsRune(runes []rune, r rune) bool {
+	for _, c := range runes {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
+
+// trimQuote removes the closing quote of a string, if present.
+func trimQuote(runes []rune, quote rune) []rune {
+	if len(runes) > 0 && runes[len(runes)-1] == quote {
+		return runes[:len(runes)-1]
+	}
+	return runes
+}
+
+func trimSpace(runes []rune) []rune {
+	for len(runes) > 0 && isSpaceRune(runes[0]) {
+		runes = runes[1:]
+	}
+	for len(runes) > 0 && isSpaceRune(runes[len(runes)-1]) {
+		runes = runes[:len(runes)-1]
+	}
+	return runes
+}
+
+func isSpaceRune(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
@@ -0,0 +1,182 @@
+package csslexer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testSources returns the sources of the test cases and benchmarks, for
+// tests comparing the token APIs.
+func testSources(t testing.TB) map[string][]byte {
+	t.Helper()
+
+	sources := make(map[string][]byte)
+	for _, pattern := range []string{
+		filepath.Join(testDataDir, "*", "*", sourceCssFile),
+		filepath.Join(testDataDir, "*", "*", sourceLessFile),
+	} {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sources[file] = data
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join("bench", "testdata", "*.css.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sources[file], err = io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return sources
+}
+
+func TestNextRaw(t *testing.T) {
+	for name, source := range testSources(t) {
+		for _, dialect := range []Dialect{CSS, SCSS, Less} {
+			lexer := NewLexerDialect(NewInputBytes(source), dialect)
+			raw := NewLexerDialect(NewInputBytes(source), dialect)
+
+			for i := 0; ; i++ {
+				expected := lexer.Next()
+				if i%7 == 0 {
+					raw.Peek() // NextRaw returns peeked tokens as well
+				}
+				token := raw.NextRaw()
+
+				if token.Type != expected.Type || string(raw.Raw(token)) != string(expected.Raw) || raw.Value(token) != expected.Value {
+					t.Errorf("%s (%s): token %d = %s %q %q, expected %s %q %q", name, dialect, i,
+						token.Type, string(raw.Raw(token)), raw.Value(token), expected.Type, string(expected.Raw), expected.Value)
+					break
+				}
+				if expected.Type == EOFToken {
+					break
+				}
+			}
+		}
+	}
+}
+
+// testValues checks that the tokens read with NextRaw have the values of
+// the tokens read with Next.
+func testValues(t *testing.T, name string, lexer, raw *Lexer) {
+	t.Helper()
+	for i := 0; ; i++ {
+		expected := lexer.Next()
+		token := raw.NextRaw()
+		if token.Type != expected.Type || string(raw.Raw(token)) != string(expected.Raw) || raw.Value(token) != expected.Value {
+			t.Errorf("%s: token %d = %s %q %q, expected %s %q %q", name, i,
+				token.Type, string(raw.Raw(token)), raw.Value(token), expected.Type, string(expected.Raw), expected.Value)
+			return
+		}
+		if expected.Type == EOFToken {
+			return
+		}
+	}
+}
+
+func TestNextRawAtEOF(t *testing.T) {
+	for _, source := range []string{"url(", "url(  ", "url( a", "url(a ", "\"a", "'", "/* a", "#", "@", "1e", "u+"} {
+		testValues(t, source, NewLexer(NewInput(source)), NewLexer(NewInput(source)))
+	}
+}
+
+func TestNextRawAtKeywords(t *testing.T) {
+	source := "@MEDIA a; @Include b; @\\6d edia c; @-webkit-d e; @\\2d f; @\u212Aeyframes g; @font-feature-values-but-longer-than-32 h"
+	for _, dialect := range []Dialect{SCSS, Less} {
+		testValues(t, dialect.String(), NewLexerDialect(NewInput(source), dialect), NewLexerDialect(NewInput(source), dialect))
+	}
+}
+
+func TestNextRawResumed(t *testing.T) {
+	for _, chunks := range [][]string{
+		{"a{b:'x", " y z'}"},
+		{"a{b:\"x", "\\\"y\" z"},
+		{"url(", ")"},
+		{"url(x", " y )"},
+		{"url( x", "  )b"},
+		{"/* a", " b */c"},
+		{"'a", "b", "c'"},
+		{"'a", "b\nc"},
+	} {
+		name := strings.Join(chunks, "${}")
+		testValues(t, name, NewLexer(NewInputTemplate(chunks)), NewLexer(NewInputTemplate(chunks)))
+	}
+}
+
+func TestNextRawTemplate(t *testing.T) {
+	lexer := NewLexer(NewInputTemplate([]string{"a:", "px url(", ") \"x", "\""}))
+	var values []string
+	for {
+		token := lexer.NextRaw()
+		if token.Type == EOFToken {
+			break
+		}
+		values = append(values, lexer.Value(token))
+	}
+
+	expected := []string{"a", ":", "0", "px", " ", "url", "1", ")", " ", "x", "2", ""}
+	if len(values) != len(expected) {
+		t.Fatalf("values = %q, expected %q", values, expected)
+	}
+	for i := range values {
+		if values[i] != expected[i] {
+			t.Errorf("values = %q, expected %q", values, expected)
+			break
+		}
+	}
+}
+
+func TestNextRawAllocs(t *testing.T) {
+	src := `@media (min-width: 10px) { .a > b[href^="x"], #id::before { color: #fff; margin: -1.5e3px 50% !important; background: url(a.png) } } /* c */ <!-- --> u+0-7f`
+	lexer := NewLexer(NewInput(src))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		lexer.r.pos, lexer.r.start = 0, 0
+		for lexer.NextRaw().Type != EOFToken {
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("NextRaw() allocated %v times, expected no allocations", allocs)
+	}
+}
+
+func TestNextRawAllocsDialects(t *testing.T) {
+	src := `@use "a"; @Mixin m { @INCLUDE x; } @media screen { @charset "x"; @var: 1px; @-webkit-keyframes k {} }`
+	for _, dialect := range []Dialect{SCSS, Less} {
+		lexer := NewLexerDialect(NewInput(src), dialect)
+
+		allocs := testing.AllocsPerRun(10, func() {
+			lexer.r.pos, lexer.r.start = 0, 0
+			lexer.Reset(lexer.r)
+			for lexer.NextRaw().Type != EOFToken {
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("%s: NextRaw() allocated %v times, expected no allocations", dialect, allocs)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package csslexer
+
+import (
+	"testing"
+)
+
+func TestInputReset(t *testing.T) {
+	input := NewInput("a { color: red }")
+	runes := input.runes
+	input.Move(3)
+	input.err = nil
+
+	input.Reset("b\x00")
+	if string(input.runes) != "b�" || input.pos != 0 || input.start != 0 || input.Err() != nil {
+		t.Errorf("Reset() = %q at %d, %d, err %v", string(input.runes), input.pos, input.start, input.Err())
+	}
+	if &input.runes[0] != &runes[0] {
+		t.Errorf("Reset() did not reuse the runes of the input")
+	}
+
+	input.ResetBytes([]byte("é\x00"))
+	if string(input.runes) != "é�" {
+		t.Errorf("ResetBytes() = %q", string(input.runes))
+	}
+
+	template := NewInputTemplate([]string{"a", "b"})
+	template.Reset("c")
+	if template.slots != nil {
+		t.Errorf("Reset() kept the slots of a template")
+	}
+}
+
+func TestLexerReset(t *testing.T) {
+	input := NewInput("$a: #{b")
+	lexer := NewLexerDialect(input, SCSS)
+	for i := 0; i < 3; i++ {
+		lexer.Next()
+	}
+	lexer.Peek()
+
+	input.Reset("} #{c} // d")
+	lexer.Reset(input)
+
+	var tokens []string
+	for token := lexer.Next(); token.Type != EOFToken; token = lexer.Next() {
+		tokens = append(tokens, token.Type.String()+" "+token.Value)
+	}
+	// The open interpolation of the previous input is forgotten.
+	expected := []string{"RightBrace }", "Whitespace  ", "InterpolationStart #{", "Ident c", "InterpolationEnd }", "Whitespace  ", "LineComment  d"}
+	if len(tokens) != len(expected) {
+		t.Fatalf("tokens after Reset() = %q, expected %q", tokens, expected)
+	}
+	for i := range tokens {
+		if tokens[i] != expected[i] {
+			t.Errorf("tokens after Reset() = %q, expected %q", tokens, expected)
+			break
+		}
+	}
+}
+
+func TestResetAllocs(t *testing.T) {
+	styles := []string{
+		"color: red; margin: 0 auto",
+		"background: url(a.png) no-repeat; width: calc(100% - 2px) !important",
+		"font: 12px/1.5 \"Helvetica Neue\", sans-serif",
+	}
+	input := NewInput("")
+	lexer := NewLexer(input)
+
+	i := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		input.Reset(styles[i%len(styles)])
+		lexer.Reset(input)
+		for lexer.NextRaw().Type != EOFToken {
+		}
+		i++
+	})
+	if allocs != 0 {
+		t.Errorf("Reset() and NextRaw() allocated %v times, expected no allocations", allocs)
+	}
+}
@@ -0,0 +1,89 @@
+package csslexer
+
+import (
+	"strings"
+)
+
+// Serialize returns the CSS text of a token sequence.
+//
+// Tokens are written from their Raw data when available, and from
+// Token.String otherwise. An empty comment is inserted between adjacent
+// tokens that would be tokenized differently once concatenated, so that
+// tokenizing the result yields the same sequence again.
+//
+// https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#serialization
+func Serialize(tokens []Token) string {
+	var result strings.Builder
+
+	for i, token := range tokens {
+		if i > 0 && NeedsSeparator(tokens[i-1], token) {
+			result.WriteString("/**/")
+		}
+		result.WriteString(tokenText(token))
+	}
+
+	return result.String()
+}
+
+// NeedsSeparator reports whether a comment must be inserted between the
+// two tokens when serializing them next to each other.
+func NeedsSeparator(a, b Token) bool {
+	switch {
+	case a.Type == IdentToken:
+		if isIdentLike(b) || isDelim(b, '-') || isNumeric(b) || b.Type == CDCToken || b.Type == LeftParenthesisToken {
+			return true
+		}
+		// "u" followed by "+" could start a unicode-range token.
+		return (a.Value == "u" || a.Value == "U") && isDelim(b, '+')
+
+	case a.Type == AtKeywordToken, a.Type == HashToken, a.Type == DimensionToken:
+		return isIdentLike(b) || isDelim(b, '-') || isNumeric(b) || b.Type == CDCToken
+
+	case isDelim(a, '#'), isDelim(a, '-'):
+		return isIdentLike(b) || isDelim(b, '-') || isNumeric(b)
+
+	case a.Type == NumberToken:
+		return isIdentLike(b) || isNumeric(b) || isDelim(b, '%')
+
+	case isDelim(a, '@'):
+		return isIdentLike(b) || isDelim(b, '-') || b.Type == CDCToken
+
+	case isDelim(a, '.'), isDelim(a, '+'):
+		return isNumeric(b)
+
+	case isDelim(a, '/'):
+		return isDelim(b, '*') || b.Type == SubstringMatchToken
+
+	// The attribute selector and column tokens recognized by this lexer.
+	case isDelim(a, '~'), isDelim(a, '^'), isDelim(a, '$'), isDelim(a, '*'):
+		return isDelim(b, '=')
+
+	case isDelim(a, '|'):
+		return isDelim(b, '=') || isDelim(b, '|') || b.Type == DashMatchToken || b.Type == ColumnToken
+
+	case isDelim(a, '<'):
+		return isDelim(b, '!')
+	}
+
+	return false
+}
+
+// tokenText returns the source text of a token.
+func tokenText(t Token) string {
+	if t.Raw != nil {
+		return string(t.Raw)
+	}
+	return t.String()
+}
+
+func isDelim(t Token, r rune) bool {
+	return t.Type == DelimiterToken && len(t.Value) == 1 && rune(t.Value[0]) == r
+}
+
+func isIdentLike(t Token) bool {
+	return t.Type == IdentToken || t.Type == FunctionToken || t.Type == UrlToken || t.Type == BadUrlToken
+}
+
+func isNumeric(t Token) bool {
+	return t.Type == NumberToken || t.Type == PercentageToken || t.Type == DimensionToken
+}
@@ -0,0 +1,103 @@
+package csslexer
+
+import (
+	"testing"
+)
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []Token
+		expected string
+	}{
+		{
+			name: "Ident followed by ident",
+			tokens: []Token{
+				{Type: IdentToken, Value: "a"},
+				{Type: IdentToken, Value: "b"},
+			},
+			expected: "a/**/b",
+		},
+		{
+			name: "Number followed by unit",
+			tokens: []Token{
+				{Type: NumberToken, Value: "1", Raw: []rune("1")},
+				{Type: IdentToken, Value: "px", Raw: []rune("px")},
+			},
+			expected: "1/**/px",
+		},
+		{
+			name: "Number followed by percent sign",
+			tokens: []Token{
+				{Type: NumberToken, Value: "1", Raw: []rune("1")},
+				{Type: DelimiterToken, Value: "%", Raw: []rune("%")},
+			},
+			expected: "1/**/%",
+		},
+		{
+			name: "Slash followed by asterisk",
+			tokens: []Token{
+				{Type: DelimiterToken, Value: "/", Raw: []rune("/")},
+				{Type: DelimiterToken, Value: "*", Raw: []rune("*")},
+			},
+			expected: "//**/*",
+		},
+		{
+			name: "Pipe followed by pipe",
+			tokens: []Token{
+				{Type: DelimiterToken, Value: "|", Raw: []rune("|")},
+				{Type: DelimiterToken, Value: "|", Raw: []rune("|")},
+			},
+			expected: "|/**/|",
+		},
+		{
+			name: "Unicode range prefix",
+			tokens: []Token{
+				{Type: IdentToken, Value: "u", Raw: []rune("u")},
+				{Type: DelimiterToken, Value: "+", Raw: []rune("+")},
+				{Type: IdentToken, Value: "a", Raw: []rune("a")},
+			},
+			expected: "u/**/+a",
+		},
+		{
+			name: "Ident followed by colon",
+			tokens: []Token{
+				{Type: IdentToken, Value: "color", Raw: []rune("color")},
+				{Type: ColonToken, Value: ":", Raw: []rune(":")},
+				{Type: IdentToken, Value: "red", Raw: []rune("red")},
+			},
+			expected: "color:red",
+		},
+		{
+			name: "Raw data is preferred",
+			tokens: []Token{
+				{Type: StringToken, Value: "a", Raw: []rune("'a'")},
+				{Type: WhitespaceToken, Value: " ", Raw: []rune(" ")},
+				{Type: HashToken, Value: "fff", Raw: []rune("#fff")},
+			},
+			expected: "'a' #fff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Serialize(tt.tokens)
+			if result != tt.expected {
+				t.Errorf("Serialize() = %q, expected %q", result, tt.expected)
+			}
+
+			// Tokenizing the result must give back the same tokens.
+			lexer := NewLexer(NewInput(result))
+			for i := 0; i < len(tt.tokens); {
+				token := lexer.Next()
+				if token.Type == CommentToken {
+					continue
+				}
+				if token.Type != tt.tokens[i].Type {
+					t.Errorf("re-tokenized type %s at index %d, expected %s", token.Type, i, tt.tokens[i].Type)
+				}
+				i++
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Consumer looks up original positions in a decoded source map.
+type Consumer struct {
+	File     string
+	Sources  []string // Sources, with the source root applied
+	mappings []Mapping
+	contents map[string]string
+}
+
+// NewConsumer decodes a version 3 source map. Index maps with sections are
+// not supported.
+func NewConsumer(data []byte) (*Consumer, error) {
+	var raw struct {
+		rawMap
+		Sections json.RawMessage `json:"sections"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, ErrInvalid
+	}
+	if raw.Version != 3 || raw.Sections != nil {
+		return nil, ErrInvalid
+	}
+
+	c := &Consumer{
+		File:     raw.File,
+		Sources:  make([]string, len(raw.Sources)),
+		contents: make(map[string]string),
+	}
+	for i, source := range raw.Sources {
+		c.Sources[i] = joinRoot(raw.SourceRoot, source)
+		if i < len(raw.SourcesContent) && raw.SourcesContent[i] != nil {
+			c.contents[c.Sources[i]] = *raw.SourcesContent[i]
+		}
+	}
+
+	if err := c.decode(raw.Mappings, raw.Names); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// decode parses the mappings field of a source map.
+func (c *Consumer) decode(mappings string, names []string) error {
+	var fields [5]int // generated column, source, original line, original column, name
+
+	for line, group := range strings.Split(mappings, ";") {
+		fields[0] = 0
+		for _, segment := range strings.Split(group, ",") {
+			if segment == "" {
+				continue
+			}
+
+			n := 0
+			for ; segment != ""; n++ {
+				if n == len(fields) {
+					return ErrInvalid
+				}
+				v, size, ok := readVLQ(segment)
+				if !ok {
+					return ErrInvalid
+				}
+				fields[n] += v
+				segment = segment[size:]
+			}
+			if n != 1 && n != 4 && n != 5 {
+				return ErrInvalid
+			}
+
+			m := Mapping{Generated: Position{Line: line, Column: fields[0]}}
+			if n >= 4 {
+				if fields[1] < 0 || fields[1] >= len(c.Sources) {
+					return ErrInvalid
+				}
+				m.Source = c.Sources[fields[1]]
+				m.Original = Position{Line: fields[2], Column: fields[3]}
+			}
+			if n == 5 {
+				if fields[4] < 0 || fields[4] >= len(names) {
+					return ErrInvalid
+				}
+				m.Name = names[fields[4]]
+			}
+			c.mappings = append(c.mappings, m)
+		}
+	}
+
+	sort.SliceStable(c.mappings, func(i, j int) bool {
+		return less(c.mappings[i].Generated, c.mappings[j].Generated)
+	})
+
+	return nil
+}
+
+// Mappings returns the mappings of the source map, sorted by generated
+// position.
+func (c *Consumer) Mappings() []Mapping {
+	return c.mappings
+}
+
+// OriginalPositionFor returns the mapping for the given position in the
+// generated file: the closest mapping on the same line at or before it.
+func (c *Consumer) OriginalPositionFor(generated Position) (Mapping, bool) {
+	i := sort.Search(len(c.mappings), func(i int) bool {
+		return less(generated, c.mappings[i].Generated)
+	})
+	if i == 0 || c.mappings[i-1].Generated.Line != generated.Line {
+		return Mapping{}, false
+	}
+	return c.mappings[i-1], true
+}
+
+// SourceContent returns the content of a source embedded in the map.
+func (c *Consumer) SourceContent(source string) (string, bool) {
+	content, ok := c.contents[source]
+	return content, ok
+}
+
+func joinRoot(root, source string) string {
+	if root == "" || strings.Contains(source, "://") || strings.HasPrefix(source, "/") {
+		return source
+	}
+	return strings.TrimSuffix(root, "/") + "/" + source
+}
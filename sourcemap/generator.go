@@ -0,0 +1,169 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Generator collects mappings and encodes them as a source map.
+type Generator struct {
+	file     string
+	mappings []Mapping
+	contents map[string]string
+
+	// SourceRoot is prepended to the sources by consumers of the map.
+	SourceRoot string
+}
+
+// NewGenerator creates a new Generator for the generated file with the
+// given name.
+func NewGenerator(file string) *Generator {
+	return &Generator{file: file, contents: make(map[string]string)}
+}
+
+// AddMapping adds a mapping to the source map.
+func (g *Generator) AddMapping(m Mapping) {
+	g.mappings = append(g.mappings, m)
+}
+
+// SetSourceContent embeds the content of a source file in the source map,
+// so that it can be shown without fetching the original file.
+func (g *Generator) SetSourceContent(source, content string) {
+	g.contents[source] = content
+}
+
+// Mappings returns the mappings sorted by generated position.
+func (g *Generator) Mappings() []Mapping {
+	mappings := append([]Mapping{}, g.mappings...)
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return less(mappings[i].Generated, mappings[j].Generated)
+	})
+	return mappings
+}
+
+// ApplySourceMap composes the source map with the source map of one of
+// its sources, for example when the source was compiled from Sass.
+// Mappings into source are replaced by the original position found in c,
+// and are kept as is when c has no mapping for them.
+func (g *Generator) ApplySourceMap(c *Consumer, source string) {
+	for i, m := range g.mappings {
+		if m.Source != source {
+			continue
+		}
+		original, ok := c.OriginalPositionFor(m.Original)
+		if !ok || original.Source == "" {
+			continue
+		}
+		m.Source = original.Source
+		m.Original = original.Original
+		if original.Name != "" {
+			m.Name = original.Name
+		}
+		g.mappings[i] = m
+
+		if content, ok := c.SourceContent(original.Source); ok {
+			g.contents[original.Source] = content
+		}
+	}
+
+	used := false
+	for _, m := range g.mappings {
+		used = used || m.Source == source
+	}
+	if !used {
+		delete(g.contents, source)
+	}
+}
+
+// rawMap is the JSON representation of a source map.
+type rawMap struct {
+	Version        int       `json:"version"`
+	File           string    `json:"file,omitempty"`
+	SourceRoot     string    `json:"sourceRoot,omitempty"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent,omitempty"`
+	Names          []string  `json:"names"`
+	Mappings       string    `json:"mappings"`
+}
+
+// MarshalJSON encodes the source map as version 3 JSON. The sourcesContent
+// field is only present when the content of a source has been set.
+func (g *Generator) MarshalJSON() ([]byte, error) {
+	raw := rawMap{
+		Version:    3,
+		File:       g.file,
+		SourceRoot: g.SourceRoot,
+		Sources:    []string{},
+		Names:      []string{},
+	}
+
+	sources := make(map[string]int)
+	names := make(map[string]int)
+	index := func(m map[string]int, list *[]string, s string) int {
+		i, ok := m[s]
+		if !ok {
+			i = len(*list)
+			m[s] = i
+			*list = append(*list, s)
+		}
+		return i
+	}
+
+	var sb strings.Builder
+	var prev struct{ column, source, line, originalColumn, name int }
+	line, first := 0, true
+	for _, m := range g.Mappings() {
+		for ; line < m.Generated.Line; line++ {
+			sb.WriteByte(';')
+			prev.column, first = 0, true
+		}
+		if !first {
+			sb.WriteByte(',')
+		}
+		first = false
+
+		writeVLQ(&sb, m.Generated.Column-prev.column)
+		prev.column = m.Generated.Column
+		if m.Source == "" {
+			continue
+		}
+
+		source := index(sources, &raw.Sources, m.Source)
+		writeVLQ(&sb, source-prev.source)
+		writeVLQ(&sb, m.Original.Line-prev.line)
+		writeVLQ(&sb, m.Original.Column-prev.originalColumn)
+		prev.source, prev.line, prev.originalColumn = source, m.Original.Line, m.Original.Column
+
+		if m.Name != "" {
+			name := index(names, &raw.Names, m.Name)
+			writeVLQ(&sb, name-prev.name)
+			prev.name = name
+		}
+	}
+	raw.Mappings = sb.String()
+
+	if len(g.contents) > 0 {
+		raw.SourcesContent = make([]*string, len(raw.Sources))
+		for i, source := range raw.Sources {
+			if content, ok := g.contents[source]; ok {
+				raw.SourcesContent[i] = &content
+			}
+		}
+	}
+
+	return json.Marshal(raw)
+}
+
+// Comment returns the comment linking a style sheet to its source map at
+// the given URL.
+func Comment(url string) string {
+	return "/*# sourceMappingURL=" + url + " */"
+}
+
+func less(a, b Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
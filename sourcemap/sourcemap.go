@@ -0,0 +1,113 @@
+// Package sourcemap generates and consumes source maps in the version 3
+// format, mapping transformed style sheets back to their original files.
+//
+// Lines and columns are zero-based, and columns count UTF-16 code units,
+// as expected by browsers.
+//
+// https://tc39.es/source-map/
+package sourcemap
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+// ErrInvalid is returned when a source map cannot be decoded.
+var ErrInvalid = errors.New("sourcemap: invalid source map")
+
+// Position is a location in a file.
+type Position struct {
+	Line   int // Zero-based line
+	Column int // Zero-based column, in UTF-16 code units
+}
+
+// Mapping maps a position in the generated file to a position in one of
+// its sources.
+type Mapping struct {
+	Generated Position
+	Source    string   // Path or URL of the original file, empty if the mapping has none
+	Original  Position // Position in Source
+	Name      string   // Original name of the mapped text, if any
+}
+
+// advance returns the position after text starting at pos. CR LF, CR, LF
+// and FF each end a line, as in CSS.
+func advance(pos Position, text string) Position {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		switch r {
+		case '\r':
+			if i < len(text) && text[i] == '\n' {
+				i++
+			}
+			fallthrough
+		case '\n', '\f':
+			pos.Line++
+			pos.Column = 0
+		default:
+			pos.Column += utf16Len(r)
+		}
+	}
+	return pos
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// TokenPositions returns the start position of each token in the source
+// it was read from. The tokens must be the complete output of a lexer,
+// with their raw text.
+func TokenPositions(tokens []csslexer.Token) []Position {
+	positions := make([]Position, len(tokens))
+
+	var pos Position
+	for i, token := range tokens {
+		positions[i] = pos
+		pos = advance(pos, string(token.Raw))
+	}
+
+	return positions
+}
+
+// Writer builds a generated file while recording mappings for the text
+// written to it.
+type Writer struct {
+	sb  strings.Builder
+	gen *Generator
+	pos Position
+}
+
+// NewWriter creates a new Writer adding its mappings to gen.
+func NewWriter(gen *Generator) *Writer {
+	return &Writer{gen: gen}
+}
+
+// WriteString writes text without a mapping.
+func (w *Writer) WriteString(text string) {
+	w.sb.WriteString(text)
+	w.pos = advance(w.pos, text)
+}
+
+// WriteMapped writes text originating from the given position of source.
+func (w *Writer) WriteMapped(text, source string, original Position) {
+	w.gen.AddMapping(Mapping{Generated: w.pos, Source: source, Original: original})
+	w.WriteString(text)
+}
+
+// Position returns the current position in the generated file.
+func (w *Writer) Position() Position {
+	return w.pos
+}
+
+// String returns the generated text.
+func (w *Writer) String() string {
+	return w.sb.String()
+}
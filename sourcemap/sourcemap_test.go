@@ -0,0 +1,159 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+func TestVLQ(t *testing.T) {
+	tests := []struct {
+		value    int
+		expected string
+	}{
+		{0, "A"},
+		{1, "C"},
+		{-1, "D"},
+		{15, "e"},
+		{16, "gB"},
+		{-16, "hB"},
+		{1000, "w+B"},
+	}
+
+	for _, tt := range tests {
+		var sb strings.Builder
+		writeVLQ(&sb, tt.value)
+		if sb.String() != tt.expected {
+			t.Errorf("writeVLQ(%d) = %q, expected %q", tt.value, sb.String(), tt.expected)
+		}
+		if v, n, ok := readVLQ(tt.expected + "A"); !ok || v != tt.value || n != len(tt.expected) {
+			t.Errorf("readVLQ(%q) = %d, %d, %v", tt.expected, v, n, ok)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	lexer := csslexer.NewLexer(csslexer.NewInput("a{\r\n  b:'😀' c}\fd"))
+
+	var tokens []csslexer.Token
+	for token := lexer.Next(); token.Type != csslexer.EOFToken; token = lexer.Next() {
+		tokens = append(tokens, token)
+	}
+
+	expected := []Position{
+		{0, 0}, {0, 1}, {0, 2}, {1, 2}, {1, 3}, {1, 4}, {1, 8}, {1, 9}, {1, 10}, {1, 11}, {2, 0},
+	}
+	if result := TokenPositions(tokens); !reflect.DeepEqual(result, expected) {
+		t.Errorf("TokenPositions() = %v, expected %v", result, expected)
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	gen := NewGenerator("out.css")
+	w := NewWriter(gen)
+	w.WriteMapped("a", "a.css", Position{0, 0})
+	w.WriteString("{")
+	w.WriteMapped("color:red", "a.css", Position{1, 2})
+	w.WriteString("}\n")
+	w.WriteMapped("b", "b.css", Position{4, 0})
+	gen.AddMapping(Mapping{Generated: Position{1, 1}})
+	gen.AddMapping(Mapping{Generated: Position{1, 2}, Source: "b.css", Original: Position{4, 2}, Name: "x"})
+	gen.SetSourceContent("b.css", "b{}")
+
+	data, err := json.Marshal(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"version":3,"file":"out.css","sources":["a.css","b.css"],"sourcesContent":[null,"b{}"],"names":["x"],"mappings":"AAAA,EACE;ACGF,C,CAAEA"}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal() = %s, expected %s", data, expected)
+	}
+
+	c, err := NewConsumer(data)
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+	if !reflect.DeepEqual(c.Mappings(), gen.Mappings()) {
+		t.Errorf("Mappings() = %v, expected %v", c.Mappings(), gen.Mappings())
+	}
+	if content, ok := c.SourceContent("b.css"); !ok || content != "b{}" {
+		t.Errorf("SourceContent() = %q, %v", content, ok)
+	}
+}
+
+func TestOriginalPositionFor(t *testing.T) {
+	c, err := NewConsumer([]byte(`{"version":3,"sourceRoot":"src","sources":["a.scss"],"names":[],"mappings":"AAAA,IAAI;;EAEF"}`))
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+
+	tests := []struct {
+		generated Position
+		expected  Mapping
+		ok        bool
+	}{
+		{Position{0, 0}, Mapping{Generated: Position{0, 0}, Source: "src/a.scss", Original: Position{0, 0}}, true},
+		{Position{0, 3}, Mapping{Generated: Position{0, 0}, Source: "src/a.scss", Original: Position{0, 0}}, true},
+		{Position{0, 9}, Mapping{Generated: Position{0, 4}, Source: "src/a.scss", Original: Position{0, 4}}, true},
+		{Position{1, 0}, Mapping{}, false},
+		{Position{2, 1}, Mapping{}, false},
+		{Position{2, 2}, Mapping{Generated: Position{2, 2}, Source: "src/a.scss", Original: Position{2, 2}}, true},
+	}
+
+	for _, tt := range tests {
+		result, ok := c.OriginalPositionFor(tt.generated)
+		if ok != tt.ok || result != tt.expected {
+			t.Errorf("OriginalPositionFor(%v) = %v, %v, expected %v, %v", tt.generated, result, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestApplySourceMap(t *testing.T) {
+	// main.css was compiled from main.scss, and then minified to out.css.
+	input, err := NewConsumer([]byte(`{"version":3,"sources":["main.scss"],"sourcesContent":[".a { .b { c: d } }"],"names":[],"mappings":"AAAK;EAAO"}`))
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+
+	gen := NewGenerator("out.css")
+	gen.SetSourceContent("main.css", ".a .b {\n  c: d;\n}")
+	gen.AddMapping(Mapping{Generated: Position{0, 0}, Source: "main.css", Original: Position{0, 0}})
+	gen.AddMapping(Mapping{Generated: Position{0, 5}, Source: "main.css", Original: Position{1, 2}})
+	gen.ApplySourceMap(input, "main.css")
+
+	expected := []Mapping{
+		{Generated: Position{0, 0}, Source: "main.scss", Original: Position{0, 5}},
+		{Generated: Position{0, 5}, Source: "main.scss", Original: Position{0, 12}},
+	}
+	if result := gen.Mappings(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Mappings() = %v, expected %v", result, expected)
+	}
+
+	data, err := json.Marshal(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"sources":["main.scss"],"sourcesContent":[".a { .b { c: d } }"]`) {
+		t.Errorf("json.Marshal() = %s, expected the content of main.scss only", data)
+	}
+}
+
+func TestNewConsumerErrors(t *testing.T) {
+	tests := []string{
+		`not json`,
+		`{"version":2,"sources":[],"names":[],"mappings":""}`,
+		`{"version":3,"sections":[]}`,
+		`{"version":3,"sources":[],"names":[],"mappings":"AAAA"}`,
+		`{"version":3,"sources":["a"],"names":[],"mappings":"AA"}`,
+		`{"version":3,"sources":["a"],"names":[],"mappings":"A!"}`,
+	}
+
+	for _, input := range tests {
+		if _, err := NewConsumer([]byte(input)); err != ErrInvalid {
+			t.Errorf("NewConsumer(%s) error = %v, expected ErrInvalid", input, err)
+		}
+	}
+}
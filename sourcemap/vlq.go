@@ -0,0 +1,49 @@
+package sourcemap
+
+import (
+	"strings"
+)
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeVLQ appends the base64 VLQ encoding of n to sb.
+func writeVLQ(sb *strings.Builder, n int) {
+	// The sign is stored in the least significant bit.
+	v := n << 1
+	if n < 0 {
+		v = (-n << 1) | 1
+	}
+
+	for {
+		digit := v & 31
+		v >>= 5
+		if v > 0 {
+			digit |= 32 // continuation bit
+		}
+		sb.WriteByte(base64Chars[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
+
+// readVLQ decodes a base64 VLQ at the start of s, returning the value and
+// the number of bytes read.
+func readVLQ(s string) (int, int, bool) {
+	v, shift := 0, 0
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base64Chars, s[i])
+		if digit < 0 || shift > 60 {
+			return 0, 0, false
+		}
+		v |= (digit & 31) << shift
+		shift += 5
+		if digit&32 == 0 {
+			if v&1 != 0 {
+				return -(v >> 1), i + 1, true
+			}
+			return v >> 1, i + 1, true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,92 @@
+package csslexer
+
+import (
+	"sort"
+	"strconv"
+)
+
+// NewInputTemplate creates a new Input instance for a template made of
+// static chunks with a placeholder slot between each two chunks, such as
+// the strings of a tagged template literal in JavaScript.
+//
+// The lexer emits a SlotToken for each slot, whose value is the index of
+// the slot, starting at 0. A slot always ends the token before it, so that
+// placeholders standing for selectors, properties or values leave the
+// surrounding tokens intact. Strings, comments and URLs interrupted by a
+// slot continue after it: the lexer emits the part before the slot, the
+// SlotToken and the part after the slot, without the opening quote or
+// delimiter.
+//
+// Slots are represented in the input by U+0000 NULL code points, which is
+// the raw text of their SlotToken. NULL code points in the chunks are
+// replaced with U+FFFD REPLACEMENT CHARACTER as in other inputs.
+func NewInputTemplate(chunks []string) *Input {
+	var runes []rune
+	slots := []int{}
+	for i, chunk := range chunks {
+		if i > 0 {
+			slots = append(slots, len(runes))
+			runes = append(runes, 0)
+		}
+		for _, r := range chunk {
+			if r == 0x00 {
+				r = '\uFFFD' // Replace with U+FFFD REPLACEMENT CHARACTER
+			}
+			runes = append(runes, r)
+		}
+	}
+
+	return &Input{
+		runes: runes,
+		pos:   0,
+		start: 0,
+		err:   nil,
+		slots: slots,
+	}
+}
+
+// atSlot reports whether the current position is a template slot.
+func (z *Input) atSlot() bool {
+	return z.pos < len(z.runes) && z.runes[z.pos] == 0
+}
+
+// resumedToken is a token continuing after a template slot, which is
+// tokenized differently from a token starting there.
+type resumedToken struct {
+	start  int  // Offset of the token in the input
+	resume rune // The value of Lexer.resume when reading the token
+}
+
+// readTemplateToken reads the next token of a template input.
+func (l *Lexer) readTemplateToken() (TokenType, string) {
+	if l.r.atSlot() {
+		slot := sort.SearchInts(l.r.slots, l.r.pos)
+		l.r.Move(1)
+		return SlotToken, strconv.Itoa(slot)
+	}
+
+	// Continue the token interrupted by the previous slot, if any. Its
+	// rest is not a token if it is empty, when the slot ends the input or
+	// a newline ends the string after the slot.
+	resume := l.resume
+	l.resume = 0
+	if resume != 0 {
+		var tokenType TokenType
+		var value string
+		switch resume {
+		case '*':
+			l.consumeUntilCommentEnd()
+			tokenType, value = CommentToken, l.current()
+		case ')':
+			tokenType, value = l.consumeURLToken()
+		case '"', '\'':
+			tokenType, value = l.consumeStringRest(resume)
+		}
+		if l.r.pos > l.r.start {
+			l.resumed = append(l.resumed, resumedToken{start: l.r.start, resume: resume})
+			return tokenType, value
+		}
+	}
+
+	return l.readToken()
+}
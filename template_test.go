@@ -0,0 +1,113 @@
+package csslexer
+
+import (
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected []dialectToken
+	}{
+		{
+			name:   "Selector",
+			chunks: []string{"", ":hover>", "{a:b}"},
+			expected: []dialectToken{
+				{SlotToken, "0", "\x00"}, {ColonToken, ":", ":"}, {IdentToken, "hover", "hover"}, {DelimiterToken, ">", ">"},
+				{SlotToken, "1", "\x00"}, {LeftBraceToken, "{", "{"}, {IdentToken, "a", "a"}, {ColonToken, ":", ":"},
+				{IdentToken, "b", "b"}, {RightBraceToken, "}", "}"},
+			},
+		},
+		{
+			name:   "Property",
+			chunks: []string{"--", "-x:1;", ":", ";"},
+			expected: []dialectToken{
+				{IdentToken, "--", "--"}, {SlotToken, "0", "\x00"},
+				{IdentToken, "-x", "-x"}, {ColonToken, ":", ":"}, {NumberToken, "1", "1"}, {SemicolonToken, ";", ";"},
+				{SlotToken, "1", "\x00"}, {ColonToken, ":", ":"}, {SlotToken, "2", "\x00"}, {SemicolonToken, ";", ";"},
+			},
+		},
+		{
+			name:   "Values",
+			chunks: []string{"a:", "px -", " calc(1", "*2)#", ""},
+			expected: []dialectToken{
+				{IdentToken, "a", "a"}, {ColonToken, ":", ":"}, {SlotToken, "0", "\x00"}, {IdentToken, "px", "px"},
+				{WhitespaceToken, " ", " "}, {DelimiterToken, "-", "-"}, {SlotToken, "1", "\x00"},
+				{WhitespaceToken, " ", " "}, {FunctionToken, "calc", "calc("}, {NumberToken, "1", "1"}, {SlotToken, "2", "\x00"},
+				{DelimiterToken, "*", "*"}, {NumberToken, "2", "2"}, {RightParenthesisToken, ")", ")"},
+				{DelimiterToken, "#", "#"}, {SlotToken, "3", "\x00"},
+			},
+		},
+		{
+			name:   "Strings and comments",
+			chunks: []string{`"a`, `b" /*c`, `d*/'`, `'`},
+			expected: []dialectToken{
+				{StringToken, "a", `"a`}, {SlotToken, "0", "\x00"}, {StringToken, "b", `b"`}, {WhitespaceToken, " ", " "},
+				{CommentToken, "/*c", "/*c"}, {SlotToken, "1", "\x00"}, {CommentToken, "d*/", "d*/"},
+				{StringToken, "", "'"}, {SlotToken, "2", "\x00"}, {StringToken, "", "'"},
+			},
+		},
+		{
+			name:   "URLs",
+			chunks: []string{"url(", ") url(a/", ".png)"},
+			expected: []dialectToken{
+				{FunctionToken, "url", "url("}, {SlotToken, "0", "\x00"}, {RightParenthesisToken, ")", ")"},
+				{WhitespaceToken, " ", " "}, {UrlToken, "a/", "url(a/"}, {SlotToken, "1", "\x00"}, {UrlToken, ".png", ".png)"},
+			},
+		},
+		{
+			name:     "String at the end",
+			chunks:   []string{`a"b`, ``},
+			expected: []dialectToken{{IdentToken, "a", "a"}, {StringToken, "b", `"b`}, {SlotToken, "0", "\x00"}},
+		},
+		{
+			name:   "Newline after a slot in a string",
+			chunks: []string{`"`, "\r"},
+			expected: []dialectToken{
+				{StringToken, "", `"`}, {SlotToken, "0", "\x00"}, {WhitespaceToken, "\r", "\r"},
+			},
+		},
+		{
+			name:     "Comment at the end",
+			chunks:   []string{`/*c`, ``},
+			expected: []dialectToken{{CommentToken, "/*c", "/*c"}, {SlotToken, "0", "\x00"}},
+		},
+		{
+			name:     "URL at the end",
+			chunks:   []string{`url(d`, ``},
+			expected: []dialectToken{{UrlToken, "d", "url(d"}, {SlotToken, "0", "\x00"}},
+		},
+		{
+			name:   "NULL in chunks",
+			chunks: []string{"a\x00"},
+			expected: []dialectToken{
+				{IdentToken, "a�", "a�"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(NewInputTemplate(tt.chunks))
+
+			var result []dialectToken
+			for {
+				token := lexer.Next()
+				if token.Type == EOFToken {
+					break
+				}
+				result = append(result, dialectToken{token.Type, token.Value, string(token.Raw)})
+			}
+
+			if len(result) != len(tt.expected) {
+				t.Fatalf("got %d tokens %q, expected %d tokens %q", len(result), result, len(tt.expected), tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("token %d = %q, expected %q", i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
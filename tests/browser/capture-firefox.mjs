@@ -0,0 +1,133 @@
+// Records the tokens of the test cases with the CSS tokenizer of Firefox,
+// InspectorCSSParser, which is exposed to privileged code. See README.md.
+//
+// Requires Firefox 122 or later, geckodriver and selenium-webdriver:
+//
+//   npm install selenium-webdriver
+//   node tests/browser/capture-firefox.mjs
+//
+// It reads every tests/<category>/<id>/source.css and writes
+// tests/browser/firefox-<major version>.json.
+
+import { existsSync, readdirSync, readFileSync, writeFileSync } from "node:fs";
+import { dirname, join } from "node:path";
+import { fileURLToPath } from "node:url";
+import { Builder } from "selenium-webdriver";
+import firefox from "selenium-webdriver/firefox.js";
+
+const browserDir = dirname(fileURLToPath(import.meta.url));
+const testsDir = dirname(browserDir);
+
+// Directories of tests/ that are not CSS test cases. The Less test cases
+// have no source.css.
+const skippedDirs = new Set(["browser", "fuzz"]);
+
+// Token names of the test cases for the token types of Firefox, which are
+// those of the cssparser crate.
+const tokenNames = {
+  Ident: "ident-token",
+  Function: "function-token",
+  AtKeyword: "at-keyword-token",
+  Hash: "hash-token",
+  IDHash: "hash-token",
+  QuotedString: "string-token",
+  BadString: "bad-string-token",
+  UnquotedUrl: "url-token",
+  BadUrl: "bad-url-token",
+  Delim: "delim-token",
+  Number: "number-token",
+  Percentage: "percentage-token",
+  Dimension: "dimension-token",
+  WhiteSpace: "whitespace-token",
+  Comment: "comment",
+  CDO: "CDO-token",
+  CDC: "CDC-token",
+  Colon: "colon-token",
+  Semicolon: "semicolon-token",
+  Comma: "comma-token",
+  ParenthesisBlock: "(-token",
+  CloseParenthesis: ")-token",
+  SquareBracketBlock: "[-token",
+  CloseSquareBracket: "]-token",
+  CurlyBracketBlock: "{-token",
+  CloseCurlyBracket: "}-token",
+};
+
+// Types of the tokens whose value is their unescaped name or content. The
+// value of the other tokens is their source text, as in Token.Value.
+const namedTypes = new Set([
+  "Ident",
+  "Function",
+  "AtKeyword",
+  "Hash",
+  "IDHash",
+  "QuotedString",
+  "BadString",
+  "UnquotedUrl",
+  "BadUrl",
+]);
+
+// Matches such as "~=" are single tokens in cssparser and two delim-tokens
+// in CSS Syntax Level 3.
+const matchTypes = new Set(["IncludeMatch", "DashMatch", "PrefixMatch", "SuffixMatch", "SubstringMatch"]);
+
+function readCases() {
+  const cases = [];
+  for (const category of readdirSync(testsDir, { withFileTypes: true })) {
+    if (!category.isDirectory() || skippedDirs.has(category.name)) {
+      continue;
+    }
+    for (const id of readdirSync(join(testsDir, category.name)).sort()) {
+      const file = join(testsDir, category.name, id, "source.css");
+      if (existsSync(file)) {
+        cases.push({ name: `${category.name}/${id}`, source: readFileSync(file, "utf8") });
+      }
+    }
+  }
+  return cases.sort((a, b) => (a.name < b.name ? -1 : a.name > b.name ? 1 : 0));
+}
+
+function convertToken(token) {
+  if (matchTypes.has(token.tokenType)) {
+    return [...token.text].map((c) => ({ type: "delim-token", raw: c, value: c }));
+  }
+  const type = tokenNames[token.tokenType];
+  if (type === undefined) {
+    throw new Error(`unknown token type ${token.tokenType}`);
+  }
+  const value = namedTypes.has(token.tokenType) ? token.value ?? "" : token.text;
+  return [{ type, raw: token.text, value }];
+}
+
+// Runs in the privileged context of the browser window.
+const tokenize = `
+  return arguments[0].map((source) => {
+    const parser = new InspectorCSSParser(source);
+    const tokens = [];
+    for (let token; (token = parser.nextToken()); ) {
+      tokens.push({ tokenType: token.tokenType, text: token.text, value: token.value });
+    }
+    return tokens;
+  });
+`;
+
+const cases = readCases();
+const driver = await new Builder()
+  .forBrowser("firefox")
+  .setFirefoxOptions(new firefox.Options().addArguments("-headless"))
+  .build();
+try {
+  await driver.setContext(firefox.Context.CHROME);
+  const version = (await driver.getCapabilities()).get("browserVersion");
+  const streams = await driver.executeScript(tokenize, cases.map((c) => c.source));
+
+  const fixture = {
+    browser: `Firefox ${version}`,
+    cases: cases.map((c, i) => ({ ...c, tokens: streams[i].flatMap(convertToken) })),
+  };
+  const file = join(browserDir, `firefox-${version.split(".")[0]}.json`);
+  writeFileSync(file, JSON.stringify(fixture, null, 2) + "\n");
+  console.log(`wrote ${cases.length} cases to ${file}`);
+} finally {
+  await driver.quit();
+}
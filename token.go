@@ -56,6 +56,21 @@ const (
 	SubstringMatchToken // *= (contains)
 	ColumnToken         // ||
 	UnicodeRangeToken
+	SlotToken // placeholder slot of a template input
+
+	// Dialect token types, only produced by lexers for preprocessor
+	// dialects.
+
+	LineCommentToken        // // comment
+	VariableToken           // $name
+	InterpolationStartToken // #{ or @{
+	InterpolationEndToken   // } closing an interpolation
+	PlaceholderToken        // %name
+	DirectiveToken          // @name of a preprocessor directive, such as @if or @include
+	AtVariableToken         // @name of a Less variable
+	EscapedStringToken      // ~"string"
+	GuardToken              // when
+	JavaScriptToken         // `code`
 )
 
 func (tt TokenType) String() string {
@@ -130,6 +145,29 @@ func (tt TokenType) String() string {
 		return "Column"
 	case UnicodeRangeToken:
 		return "UnicodeRange"
+	case SlotToken:
+		return "Slot"
+
+	case LineCommentToken:
+		return "LineComment"
+	case VariableToken:
+		return "Variable"
+	case InterpolationStartToken:
+		return "InterpolationStart"
+	case InterpolationEndToken:
+		return "InterpolationEnd"
+	case PlaceholderToken:
+		return "Placeholder"
+	case DirectiveToken:
+		return "Directive"
+	case AtVariableToken:
+		return "AtVariable"
+	case EscapedStringToken:
+		return "EscapedString"
+	case GuardToken:
+		return "Guard"
+	case JavaScriptToken:
+		return "JavaScript"
 
 	default:
 		return fmt.Sprintf("Unknown(%d)", tt)
@@ -145,9 +183,27 @@ type Token struct {
 	Raw   []rune    // Raw rune data of the token
 }
 
+// Detach returns a copy of the token whose Raw does not share the memory
+// of the input, so that it can be used after the input is reset or closed.
+func (t Token) Detach() Token {
+	t.Raw = append([]rune(nil), t.Raw...)
+	return t
+}
+
 // String returns the serialized representation of the token.
 // It uses cssutil serialize functions to properly format the token value
 // according to CSS specifications.
+//
+// The result depends only on the type and value of the token: the value of
+// a PercentageToken is its number, and the value of a CommentToken is its
+// text without the delimiters. Tokenizing the result yields an equivalent
+// token, except for tokens read by the lexer that do not follow these
+// conventions: percentages and comments, whose values include the '%' and
+// the delimiters, and dimensions whose unit is escaped. Serialize writes
+// the tokens read by the lexer from their Raw data, which round-trips.
+//
+// A SlotToken is written as the NULL placeholder of its slot, which only
+// stands for a slot in the input of NewInputTemplate.
 func (t Token) String() string {
 	switch t.Type {
 	case StringToken, BadStringToken:
@@ -243,6 +299,36 @@ func (t Token) String() string {
 	case UnicodeRangeToken:
 		return t.Value
 
+	case SlotToken:
+		return "\x00"
+
+	case LineCommentToken:
+		return "//" + t.Value
+
+	case VariableToken:
+		return "$" + cssutil.SerializeIdentifier(t.Value)
+
+	case InterpolationStartToken:
+		return t.Value
+
+	case InterpolationEndToken:
+		return "}"
+
+	case PlaceholderToken:
+		return "%" + cssutil.SerializeIdentifier(t.Value)
+
+	case DirectiveToken, AtVariableToken:
+		return "@" + cssutil.SerializeIdentifier(t.Value)
+
+	case EscapedStringToken:
+		return "~" + cssutil.SerializeString(t.Value)
+
+	case GuardToken:
+		return "when"
+
+	case JavaScriptToken:
+		return "`" + t.Value + "`"
+
 	case EOFToken:
 		return ""
 
@@ -78,6 +78,11 @@ func TestTokenString(t *testing.T) {
 			token:    Token{Type: CommentToken, Value: " this is a comment "},
 			expected: "/* this is a comment */",
 		},
+		{
+			name:     "Comment token with delimiters in the value",
+			token:    Token{Type: CommentToken, Value: "/* x"},
+			expected: "/*/* x*/",
+		},
 		{
 			name:     "CDO token",
 			token:    Token{Type: CDOToken, Value: ""},
@@ -108,6 +113,56 @@ func TestTokenString(t *testing.T) {
 			token:    Token{Type: EOFToken, Value: ""},
 			expected: "",
 		},
+		{
+			name:     "Slot token",
+			token:    Token{Type: SlotToken, Value: "0"},
+			expected: "\x00",
+		},
+		{
+			name:     "Line comment token",
+			token:    Token{Type: LineCommentToken, Value: " comment"},
+			expected: "// comment",
+		},
+		{
+			name:     "Variable token",
+			token:    Token{Type: VariableToken, Value: "base-color"},
+			expected: "$base-color",
+		},
+		{
+			name:     "Interpolation tokens",
+			token:    Token{Type: InterpolationStartToken, Value: "#{"},
+			expected: "#{",
+		},
+		{
+			name:     "Placeholder token",
+			token:    Token{Type: PlaceholderToken, Value: "toolbelt"},
+			expected: "%toolbelt",
+		},
+		{
+			name:     "Directive token",
+			token:    Token{Type: DirectiveToken, Value: "include"},
+			expected: "@include",
+		},
+		{
+			name:     "At-variable token",
+			token:    Token{Type: AtVariableToken, Value: "color"},
+			expected: "@color",
+		},
+		{
+			name:     "Escaped string token",
+			token:    Token{Type: EscapedStringToken, Value: `calc(100% - "x")`},
+			expected: `~"calc(100% - \"x\")"`,
+		},
+		{
+			name:     "Guard token",
+			token:    Token{Type: GuardToken, Value: "when"},
+			expected: "when",
+		},
+		{
+			name:     "JavaScript token",
+			token:    Token{Type: JavaScriptToken, Value: "1 + 1"},
+			expected: "`1 + 1`",
+		},
 	}
 
 	for _, tt := range tests {
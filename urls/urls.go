@@ -0,0 +1,201 @@
+// Package urls finds and rewrites the resource references of a style
+// sheet, such as images, fonts and imported style sheets.
+//
+// References are located from tokens, so URLs inside comments or strings
+// that are not in a URL position are never reported. Rewriting only
+// replaces the tokens holding the references and leaves every other byte
+// of the source untouched.
+package urls
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/cssutil"
+)
+
+// Kind is the syntactic form of a reference.
+type Kind int
+
+const (
+	URLToken     Kind = iota // url(image.png), an unquoted url token
+	URLFunction              // url("image.png"), a url function with a string argument
+	SrcFunction              // src("image.png")
+	ImportString             // @import "style.css"
+	ImageSet                 // a string in image-set("a.png" 1x, "b.png" 2x)
+)
+
+func (k Kind) String() string {
+	switch k {
+	case URLToken:
+		return "url-token"
+	case URLFunction:
+		return "url-function"
+	case SrcFunction:
+		return "src-function"
+	case ImportString:
+		return "import-string"
+	case ImageSet:
+		return "image-set"
+	}
+	return "unknown"
+}
+
+// Reference is a resource reference in a style sheet.
+type Reference struct {
+	URL  string // The URL, with escapes resolved
+	Kind Kind   // The syntactic form of the reference
+
+	// Start and End are the byte offsets in the source of the token
+	// holding the URL: the whole url token for URLToken, and the string
+	// token otherwise.
+	Start, End int
+}
+
+// Find returns the references in source, in source order.
+func Find(source []byte) []Reference {
+	var refs []Reference
+
+	lexer := csslexer.NewLexer(csslexer.NewInputBytes(source))
+	offset := 0
+
+	// functions holds the lowercased names of the enclosing functions,
+	// with an empty name for simple blocks.
+	var functions []string
+
+	// expect is the kind of a string token that would be a reference at
+	// this point, valid while expecting is set.
+	var expect Kind
+	expecting := false
+
+	for {
+		token := lexer.Next()
+		if token.Type == csslexer.EOFToken {
+			break
+		}
+
+		start := offset
+		offset = advance(source, offset, len(token.Raw))
+
+		if token.Type == csslexer.WhitespaceToken || token.Type == csslexer.CommentToken {
+			continue
+		}
+
+		wasExpecting := expecting
+		expecting = false
+
+		switch token.Type {
+		case csslexer.UrlToken:
+			refs = append(refs, Reference{URL: token.Value, Kind: URLToken, Start: start, End: offset})
+
+		case csslexer.StringToken:
+			switch {
+			case wasExpecting:
+				refs = append(refs, Reference{URL: token.Value, Kind: expect, Start: start, End: offset})
+			case len(functions) > 0 && isImageSet(functions[len(functions)-1]):
+				refs = append(refs, Reference{URL: token.Value, Kind: ImageSet, Start: start, End: offset})
+			}
+
+		case csslexer.AtKeywordToken:
+			if len(functions) == 0 && strings.EqualFold(token.Value, "import") {
+				expect, expecting = ImportString, true
+			}
+
+		case csslexer.FunctionToken:
+			name := strings.ToLower(token.Value)
+			functions = append(functions, name)
+			switch name {
+			case "url":
+				expect, expecting = URLFunction, true
+			case "src":
+				expect, expecting = SrcFunction, true
+			}
+
+		case csslexer.LeftParenthesisToken, csslexer.LeftBracketToken, csslexer.LeftBraceToken:
+			functions = append(functions, "")
+
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken, csslexer.RightBraceToken:
+			if len(functions) > 0 {
+				functions = functions[:len(functions)-1]
+			}
+		}
+	}
+
+	return refs
+}
+
+// Rewrite returns a copy of source where each reference for which fn
+// returns true is replaced with the returned URL. All other bytes are
+// copied unchanged.
+//
+// The replacement keeps the form of the original reference when possible:
+// unquoted url tokens stay unquoted unless the new URL requires quoting,
+// and strings keep their quote character.
+func Rewrite(source []byte, fn func(Reference) (string, bool)) []byte {
+	refs := Find(source)
+
+	out := make([]byte, 0, len(source))
+	last := 0
+	for _, ref := range refs {
+		u, ok := fn(ref)
+		if !ok {
+			continue
+		}
+		out = append(out, source[last:ref.Start]...)
+		if ref.Kind == URLToken {
+			out = append(out, serializeURL(u)...)
+		} else {
+			out = append(out, serializeString(u, source[ref.Start])...)
+		}
+		last = ref.End
+	}
+	out = append(out, source[last:]...)
+
+	return out
+}
+
+// advance returns the byte offset in source after n runes starting at
+// offset. Runes are decoded the same way as by csslexer.NewInputBytes, so
+// that invalid bytes count as one rune each.
+func advance(source []byte, offset, n int) int {
+	for ; n > 0 && offset < len(source); n-- {
+		_, size := utf8.DecodeRune(source[offset:])
+		offset += size
+	}
+	return offset
+}
+
+func isImageSet(name string) bool {
+	return name == "image-set" || name == "-webkit-image-set"
+}
+
+// serializeURL serializes u as an unquoted url token if possible, and as
+// a url function with a string argument otherwise.
+func serializeURL(u string) string {
+	for _, r := range u {
+		if cssutil.IsWhitespace(r) || cssutil.IsNonPrintableCodePoint(r) ||
+			r == '"' || r == '\'' || r == '(' || r == ')' || r == '\\' {
+			return cssutil.SerializeURL(u)
+		}
+	}
+	return "url(" + u + ")"
+}
+
+// serializeString serializes u as a string token using the given quote
+// character if possible.
+func serializeString(u string, quote byte) string {
+	if quote == '\'' && !strings.ContainsAny(u, "'\\\n\r\f") && !containsNonPrintable(u) {
+		return "'" + u + "'"
+	}
+	return cssutil.SerializeString(u)
+}
+
+func containsNonPrintable(s string) bool {
+	for _, r := range s {
+		if cssutil.IsNonPrintableCodePoint(r) {
+			return true
+		}
+	}
+	return false
+}
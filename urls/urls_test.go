@@ -0,0 +1,122 @@
+package urls
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Reference
+	}{
+		{
+			name:  "Url token",
+			input: "a { background: url( img/a.png ) }",
+			expected: []Reference{
+				{URL: "img/a.png", Kind: URLToken, Start: 16, End: 32},
+			},
+		},
+		{
+			name:  "Url function with a string",
+			input: `a{b:url("a.png") URL( 'b.png' )}`,
+			expected: []Reference{
+				{URL: "a.png", Kind: URLFunction, Start: 8, End: 15},
+				{URL: "b.png", Kind: URLFunction, Start: 22, End: 29},
+			},
+		},
+		{
+			name:  "Imports",
+			input: `@import "a.css" screen; @import url(b.css); @import url("c.css") layer;`,
+			expected: []Reference{
+				{URL: "a.css", Kind: ImportString, Start: 8, End: 15},
+				{URL: "b.css", Kind: URLToken, Start: 32, End: 42},
+				{URL: "c.css", Kind: URLFunction, Start: 56, End: 63},
+			},
+		},
+		{
+			name:  "Image set and src",
+			input: `a{b:image-set("a.png" 1x, url(b.png) 2x, "c.avif" type("image/avif")) src("d.png")}`,
+			expected: []Reference{
+				{URL: "a.png", Kind: ImageSet, Start: 14, End: 21},
+				{URL: "b.png", Kind: URLToken, Start: 26, End: 36},
+				{URL: "c.avif", Kind: ImageSet, Start: 41, End: 49},
+				{URL: "d.png", Kind: SrcFunction, Start: 74, End: 81},
+			},
+		},
+		{
+			name:     "Strings and comments are not references",
+			input:    `a{content:"x.png";/* url(y.png) */b:attr(a, "z.png")}`,
+			expected: nil,
+		},
+		{
+			name:  "Offsets are in bytes",
+			input: "/* é */a{b:url(ü.png)}",
+			expected: []Reference{
+				{URL: "ü.png", Kind: URLToken, Start: 12, End: 23},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Find([]byte(tt.input))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Find(%q) = %v, expected %v", tt.input, result, tt.expected)
+			}
+			for _, ref := range result {
+				if text := tt.input[ref.Start:ref.End]; !strings.Contains(text, ref.URL) {
+					t.Errorf("source[%d:%d] = %q does not contain %q", ref.Start, ref.End, text, ref.URL)
+				}
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	cdn := func(ref Reference) (string, bool) {
+		if strings.HasPrefix(ref.URL, "data:") {
+			return "", false
+		}
+		return "https://cdn.example.com/" + ref.URL, true
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		fn       func(Reference) (string, bool)
+		expected string
+	}{
+		{
+			name:     "Keeps the original form",
+			input:    "@import 'a.css';\na { b: url( x.png ) , url(\"y.png\") ;/* url(z.png) */ }",
+			fn:       cdn,
+			expected: "@import 'https://cdn.example.com/a.css';\na { b: url(https://cdn.example.com/x.png) , url(\"https://cdn.example.com/y.png\") ;/* url(z.png) */ }",
+		},
+		{
+			name:     "Skipped references are untouched",
+			input:    "a{b:url(data:image/png;base64,AA==) url(\\61.png)}",
+			fn:       cdn,
+			expected: "a{b:url(data:image/png;base64,AA==) url(https://cdn.example.com/a.png)}",
+		},
+		{
+			name:  "Quotes when needed",
+			input: "a{b:url(x.png) url('y.png')}",
+			fn: func(ref Reference) (string, bool) {
+				return "it's (1).png", true
+			},
+			expected: `a{b:url("it's (1).png") url("it's (1).png")}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := string(Rewrite([]byte(tt.input), tt.fn))
+			if result != tt.expected {
+				t.Errorf("Rewrite(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
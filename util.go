@@ -23,3 +23,20 @@ func hexDigitToValue(c rune) rune {
 	}
 	return 0
 }
+
+// equalFoldRunes reports whether runes are equal to the lowercase ASCII
+// string s, ignoring ASCII case.
+func equalFoldRunes(runes []rune, s string) bool {
+	if len(runes) != len(s) {
+		return false
+	}
+	for i, r := range runes {
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		if r != rune(s[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,365 @@
+// Package variables implements CSS custom properties and var()
+// substitution on top of the lexer.
+//
+// Custom property values are kept as raw token sequences. Substitution
+// happens at the token level, so tokens coming from different sources are
+// never merged; use csslexer.Serialize to turn the result back into text
+// that tokenizes the same way.
+//
+// https://www.w3.org/TR/css-variables-1/
+package variables
+
+import (
+	"errors"
+	"strings"
+
+	"go.baoshuo.dev/csslexer"
+	"go.baoshuo.dev/csslexer/internal/tokenutil"
+)
+
+// ErrInvalidAtComputedTime is returned when a value references a custom
+// property that is not defined or guaranteed-invalid, without a fallback,
+// or when a var() function is malformed.
+var ErrInvalidAtComputedTime = errors.New("variables: invalid at computed-value time")
+
+// Properties maps custom property names, including the leading "--", to
+// their values. A property that is absent from the map has the
+// guaranteed-invalid value.
+type Properties map[string][]csslexer.Token
+
+// IsCustomPropertyName reports whether the name is a custom property name.
+func IsCustomPropertyName(name string) bool {
+	return tokenutil.IsCustomPropertyName(name)
+}
+
+// ConsumeValue reads a declaration value from the lexer.
+//
+// It consumes tokens up to, but not including, a semicolon or a closing
+// brace outside of any block, or the end of the input. Leading and
+// trailing whitespace is trimmed and a trailing `!important` is removed
+// and reported.
+//
+// https://www.w3.org/TR/2021/CRD-css-syntax-3-20211224/#consume-declaration
+func ConsumeValue(l *csslexer.Lexer) (value []csslexer.Token, important bool) {
+	var closers []csslexer.TokenType
+
+	for {
+		next := l.Peek()
+		if next.Type == csslexer.EOFToken {
+			break
+		}
+		if len(closers) == 0 && (next.Type == csslexer.SemicolonToken || next.Type == csslexer.RightBraceToken) {
+			break
+		}
+
+		token := l.Next()
+		switch token.Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken:
+			closers = append(closers, csslexer.RightParenthesisToken)
+		case csslexer.LeftBracketToken:
+			closers = append(closers, csslexer.RightBracketToken)
+		case csslexer.LeftBraceToken:
+			closers = append(closers, csslexer.RightBraceToken)
+		case csslexer.RightParenthesisToken, csslexer.RightBracketToken, csslexer.RightBraceToken:
+			if len(closers) > 0 && closers[len(closers)-1] == token.Type {
+				closers = closers[:len(closers)-1]
+			}
+		}
+		value = append(value, token)
+	}
+
+	value = tokenutil.TrimWhitespace(value)
+	value, important = tokenutil.TrimImportant(value)
+	return value, important
+}
+
+// ParseValue tokenizes a declaration value, as ConsumeValue does.
+func ParseValue(s string) (value []csslexer.Token, important bool) {
+	return ConsumeValue(csslexer.NewLexer(csslexer.NewInput(s)))
+}
+
+// ===== var() references =====
+
+// reference is a var() function found in a token sequence.
+type reference struct {
+	start, end  int              // token range of the function, end exclusive
+	name        string           // the referenced custom property
+	fallback    []csslexer.Token // the fallback value, nil if absent
+	hasFallback bool
+}
+
+// findReferences returns the top-level var() functions of a value. The
+// references nested in fallbacks are left in the fallback tokens.
+func findReferences(tokens []csslexer.Token) ([]reference, error) {
+	var refs []reference
+
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Type != csslexer.FunctionToken || !strings.EqualFold(tokens[i].Value, "var") {
+			continue
+		}
+
+		end := matchingParenthesis(tokens, i)
+		args := tokens[i+1 : end]
+		if end < len(tokens) {
+			end++ // include the closing parenthesis
+		}
+
+		ref, err := parseReference(args)
+		if err != nil {
+			return nil, err
+		}
+		ref.start, ref.end = i, end
+		refs = append(refs, ref)
+
+		i = end - 1
+	}
+
+	return refs, nil
+}
+
+// matchingParenthesis returns the index of the token closing the function
+// or block opened at tokens[open], or len(tokens) if it is unclosed.
+func matchingParenthesis(tokens []csslexer.Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].Type {
+		case csslexer.FunctionToken, csslexer.LeftParenthesisToken:
+			depth++
+		case csslexer.RightParenthesisToken:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// parseReference parses the arguments of var(): a custom property name,
+// optionally followed by a comma and a fallback value.
+//
+// https://www.w3.org/TR/css-variables-1/#using-variables
+func parseReference(args []csslexer.Token) (reference, error) {
+	i := 0
+	for i < len(args) && isSkippable(args[i]) {
+		i++
+	}
+	if i == len(args) || args[i].Type != csslexer.IdentToken || !IsCustomPropertyName(args[i].Value) {
+		return reference{}, ErrInvalidAtComputedTime
+	}
+	ref := reference{name: args[i].Value}
+
+	for i++; i < len(args) && isSkippable(args[i]); i++ {
+	}
+	if i == len(args) {
+		return ref, nil
+	}
+	if args[i].Type != csslexer.CommaToken {
+		return reference{}, ErrInvalidAtComputedTime
+	}
+
+	ref.fallback = tokenutil.TrimWhitespace(args[i+1:])
+	if ref.fallback == nil {
+		ref.fallback = []csslexer.Token{}
+	}
+	ref.hasFallback = true
+	return ref, nil
+}
+
+func isSkippable(t csslexer.Token) bool {
+	return t.Type == csslexer.WhitespaceToken || t.Type == csslexer.CommentToken
+}
+
+// ===== Substitution =====
+
+// Substitute replaces the var() functions of a value with the values of
+// the referenced custom properties, or with their fallbacks when the
+// properties are guaranteed-invalid. The properties should already be
+// resolved with Resolve.
+//
+// https://www.w3.org/TR/css-variables-1/#substitute-a-var
+func Substitute(value []csslexer.Token, props Properties) ([]csslexer.Token, error) {
+	return substitute(value, func(name string) ([]csslexer.Token, bool) {
+		v, ok := props[name]
+		return v, ok
+	})
+}
+
+func substitute(value []csslexer.Token, lookup func(name string) ([]csslexer.Token, bool)) ([]csslexer.Token, error) {
+	refs, err := findReferences(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return value, nil
+	}
+
+	result := make([]csslexer.Token, 0, len(value))
+	last := 0
+	for _, ref := range refs {
+		result = append(result, value[last:ref.start]...)
+		last = ref.end
+
+		if v, ok := lookup(ref.name); ok {
+			result = append(result, v...)
+			continue
+		}
+		if !ref.hasFallback {
+			return nil, ErrInvalidAtComputedTime
+		}
+		fallback, err := substitute(ref.fallback, lookup)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fallback...)
+	}
+	result = append(result, value[last:]...)
+
+	return result, nil
+}
+
+// Resolve computes the values of custom properties by substituting the
+// var() functions they contain.
+//
+// Properties that take part in a dependency cycle, whose value is the
+// `initial` keyword, or whose substitution fails are guaranteed-invalid
+// and are left out of the result.
+//
+// https://www.w3.org/TR/css-variables-1/#cycles
+func Resolve(props Properties) Properties {
+	r := &resolver{
+		props:    props,
+		resolved: make(Properties, len(props)),
+		invalid:  make(map[string]bool),
+	}
+
+	for name := range cyclicProperties(props) {
+		r.invalid[name] = true
+	}
+	for name := range props {
+		r.resolve(name)
+	}
+
+	return r.resolved
+}
+
+type resolver struct {
+	props    Properties
+	resolved Properties
+	invalid  map[string]bool
+}
+
+func (r *resolver) resolve(name string) ([]csslexer.Token, bool) {
+	if v, ok := r.resolved[name]; ok {
+		return v, true
+	}
+	value, ok := r.props[name]
+	if !ok || r.invalid[name] || isInitial(value) {
+		return nil, false
+	}
+
+	// Cycles are excluded beforehand, so the recursion terminates.
+	v, err := substitute(value, r.resolve)
+	if err != nil {
+		r.invalid[name] = true
+		return nil, false
+	}
+	if v == nil {
+		v = []csslexer.Token{}
+	}
+	r.resolved[name] = v
+	return v, true
+}
+
+func isInitial(value []csslexer.Token) bool {
+	value = tokenutil.TrimWhitespace(value)
+	return len(value) == 1 && value[0].Type == csslexer.IdentToken && strings.EqualFold(value[0].Value, "initial")
+}
+
+// dependencies returns the custom properties referenced by a value,
+// including the references in fallbacks.
+func dependencies(value []csslexer.Token) []string {
+	var names []string
+	refs, _ := findReferences(value)
+	for _, ref := range refs {
+		names = append(names, ref.name)
+		names = append(names, dependencies(ref.fallback)...)
+	}
+	return names
+}
+
+// cyclicProperties returns the properties that are part of a cycle in the
+// dependency graph, using Tarjan's strongly connected components
+// algorithm.
+func cyclicProperties(props Properties) map[string]bool {
+	var (
+		index   = 0
+		stack   []string
+		onStack = make(map[string]bool)
+		indices = make(map[string]int)
+		lowlink = make(map[string]int)
+		edges   = make(map[string][]string, len(props))
+		cyclic  = make(map[string]bool)
+	)
+
+	for name, value := range props {
+		for _, dep := range dependencies(value) {
+			if _, ok := props[dep]; ok {
+				edges[name] = append(edges[name], dep)
+			}
+		}
+	}
+
+	var connect func(name string)
+	connect = func(name string) {
+		indices[name] = index
+		lowlink[name] = index
+		index++
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfLoop := false
+		for _, dep := range edges[name] {
+			if dep == name {
+				selfLoop = true
+			}
+			if _, visited := indices[dep]; !visited {
+				connect(dep)
+				if lowlink[dep] < lowlink[name] {
+					lowlink[name] = lowlink[dep]
+				}
+			} else if onStack[dep] && indices[dep] < lowlink[name] {
+				lowlink[name] = indices[dep]
+			}
+		}
+
+		if lowlink[name] != indices[name] {
+			return
+		}
+
+		// name is the root of a strongly connected component.
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			for _, member := range component {
+				cyclic[member] = true
+			}
+		}
+	}
+
+	for name := range props {
+		if _, visited := indices[name]; !visited {
+			connect(name)
+		}
+	}
+
+	return cyclic
+}
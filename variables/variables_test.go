@@ -0,0 +1,133 @@
+package variables
+
+import (
+	"errors"
+	"testing"
+
+	"go.baoshuo.dev/csslexer"
+)
+
+func parseProperties(values map[string]string) Properties {
+	props := make(Properties, len(values))
+	for name, source := range values {
+		props[name], _ = ParseValue(source)
+	}
+	return props
+}
+
+func TestConsumeValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  string
+		important bool
+		rest      csslexer.TokenType
+	}{
+		{name: "Simple value", input: " red ; color: blue", expected: "red", rest: csslexer.SemicolonToken},
+		{name: "Stops at closing brace", input: "1px 2px}", expected: "1px 2px", rest: csslexer.RightBraceToken},
+		{name: "Nested blocks", input: "{ a; b } [;] (;) f(;);", expected: "{ a; b } [;] (;) f(;)", rest: csslexer.SemicolonToken},
+		{name: "Important", input: "10px ! IMPORTANT ;", expected: "10px", important: true, rest: csslexer.SemicolonToken},
+		{name: "Empty value", input: ";", expected: "", rest: csslexer.SemicolonToken},
+		{name: "Until EOF", input: "a b", expected: "a b", rest: csslexer.EOFToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := csslexer.NewLexer(csslexer.NewInput(tt.input))
+			value, important := ConsumeValue(lexer)
+			if result := csslexer.Serialize(value); result != tt.expected {
+				t.Errorf("ConsumeValue() = %q, expected %q", result, tt.expected)
+			}
+			if important != tt.important {
+				t.Errorf("ConsumeValue() important = %v, expected %v", important, tt.important)
+			}
+			if next := lexer.Next(); next.Type != tt.rest {
+				t.Errorf("next token after value is %s, expected %s", next.Type, tt.rest)
+			}
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	props := Resolve(parseProperties(map[string]string{
+		"--size":  "10",
+		"--unit":  "px",
+		"--color": "red",
+		"--empty": "",
+		"--a":     "var(--b)",
+		"--b":     "var(--a)",
+		"--c":     "var(--a, 1em)",
+		"--d":     "var(--missing)",
+		"--e":     "initial",
+		"--f":     "var(--color) var(--size)",
+		"--g":     "var(--missing, var(--f))",
+	}))
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{name: "Plain value", input: "1px solid", expected: "1px solid"},
+		{name: "Single reference", input: "var(--color)", expected: "red"},
+		{name: "Tokens are not merged", input: "var(--size)px", expected: "10/**/px"},
+		{name: "Adjacent references", input: "var(--size)var(--unit)", expected: "10/**/px"},
+		{name: "Inside a function", input: "calc(var(--size) * 2)", expected: "calc(10 * 2)"},
+		{name: "Empty value", input: "a var(--empty) b", expected: "a  b"},
+		{name: "Fallback", input: "var(--missing, blue)", expected: "blue"},
+		{name: "Empty fallback", input: "x var(--missing,)", expected: "x "},
+		{name: "Nested fallback", input: "var(--missing, var(--color))", expected: "red"},
+		{name: "Fallback with commas", input: "var(--missing, a, b)", expected: "a, b"},
+		{name: "Case insensitive function", input: "VAR(--color)", expected: "red"},
+		{name: "Cycle uses fallback", input: "var(--a, green)", expected: "green"},
+		{name: "Reference to a cycle uses its fallback", input: "var(--c)", expected: "1em"},
+		{name: "Initial is guaranteed-invalid", input: "var(--e, 0)", expected: "0"},
+		{name: "Resolved references", input: "var(--f)", expected: "red 10"},
+		{name: "Resolved fallback references", input: "var(--g)", expected: "red 10"},
+		{name: "Missing without fallback", input: "var(--missing)", err: ErrInvalidAtComputedTime},
+		{name: "Cycle without fallback", input: "var(--b)", err: ErrInvalidAtComputedTime},
+		{name: "Invalid property without fallback", input: "var(--d)", err: ErrInvalidAtComputedTime},
+		{name: "Malformed reference", input: "var(color)", err: ErrInvalidAtComputedTime},
+		{name: "Junk after name", input: "var(--color red)", err: ErrInvalidAtComputedTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, _ := ParseValue(tt.input)
+			result, err := Substitute(value, props)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Substitute(%q) error = %v, expected %v", tt.input, err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if s := csslexer.Serialize(result); s != tt.expected {
+				t.Errorf("Substitute(%q) = %q, expected %q", tt.input, s, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveCycles(t *testing.T) {
+	props := Resolve(parseProperties(map[string]string{
+		"--self":  "var(--self)",
+		"--x":     "var(--y)",
+		"--y":     "var(--z, 1)",
+		"--z":     "var(--x)",
+		"--ok":    "var(--y, 2)",
+		"--plain": "3",
+	}))
+
+	for _, name := range []string{"--self", "--x", "--y", "--z"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("expected %s to be guaranteed-invalid", name)
+		}
+	}
+	if s := csslexer.Serialize(props["--ok"]); s != "2" {
+		t.Errorf("--ok resolved to %q, expected %q", s, "2")
+	}
+	if s := csslexer.Serialize(props["--plain"]); s != "3" {
+		t.Errorf("--plain resolved to %q, expected %q", s, "3")
+	}
+}